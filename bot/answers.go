@@ -0,0 +1,351 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/Brawl345/gobot/plugin"
+	"github.com/Brawl345/gobot/utils/tgUtils"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+// Telegram doesn't allow bots to delete messages older than 48 hours in groups
+const answerTTL = 48 * time.Hour
+
+type answerKind int
+
+const (
+	answerText  answerKind = iota // Can be replaced with editMessageText
+	answerMedia                   // Can be replaced with editMessageMedia
+	answerOther                   // Has to be deleted and sent again
+)
+
+// answerKinds contains the methods whose messages count as the answer of a handler.
+var answerKinds = map[string]answerKind{
+	"sendMessage":    answerText,
+	"sendPhoto":      answerMedia,
+	"sendVideo":      answerMedia,
+	"sendAnimation":  answerMedia,
+	"sendDocument":   answerMedia,
+	"sendAudio":      answerMedia,
+	"sendMediaGroup": answerOther,
+	"sendLocation":   answerOther,
+	"sendVenue":      answerOther,
+	"sendVoice":      answerOther,
+	"sendSticker":    answerOther,
+	"copyMessage":    answerOther,
+	"forwardMessage": answerOther,
+}
+
+type (
+	answer struct {
+		messageID int64
+		kind      answerKind
+	}
+
+	answerKey struct {
+		chatID    int64
+		messageID int64
+	}
+
+	answerEntry struct {
+		byHandler map[plugin.Handler][]answer
+		expires   time.Time
+	}
+
+	// answerStore remembers which bot messages answered which user message, so they can be
+	// replaced when the user edits the message.
+	answerStore struct {
+		mu         sync.Mutex
+		entries    map[answerKey]*answerEntry
+		lastPruned time.Time
+		since      time.Time // The answers of older messages are unknown, e.g. after a restart
+	}
+)
+
+func newAnswerStore() *answerStore {
+	return &answerStore{
+		entries:    make(map[answerKey]*answerEntry),
+		lastPruned: time.Now(),
+		since:      time.Now(),
+	}
+}
+
+// knows reports whether the answers to the message are known. Edits of other messages are ignored,
+// since answering them again would post a second answer instead of replacing the first one.
+func (s *answerStore) knows(msg *gotgbot.Message) bool {
+	return msg.Date >= s.since.Unix() && time.Since(time.Unix(msg.Date, 0)) <= answerTTL
+}
+
+func (s *answerStore) get(key answerKey, handler plugin.Handler) []answer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil
+	}
+	return entry.byHandler[handler]
+}
+
+func (s *answerStore) set(key answerKey, handler plugin.Handler, answers []answer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastPruned) > time.Hour {
+		s.prune(now)
+	}
+
+	entry, ok := s.entries[key]
+	if !ok {
+		if len(answers) == 0 {
+			return
+		}
+		entry = &answerEntry{
+			byHandler: make(map[plugin.Handler][]answer),
+			expires:   now.Add(answerTTL),
+		}
+		s.entries[key] = entry
+	}
+
+	if len(answers) == 0 {
+		delete(entry.byHandler, handler)
+	} else {
+		entry.byHandler[handler] = answers
+	}
+
+	if len(entry.byHandler) == 0 {
+		delete(s.entries, key)
+	}
+}
+
+// takeStale removes and returns the answers of all handlers that are not in keep.
+func (s *answerStore) takeStale(key answerKey, keep map[plugin.Handler]bool) []answer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+
+	var stale []answer
+	for handler, answers := range entry.byHandler {
+		if keep[handler] {
+			continue
+		}
+		stale = append(stale, answers...)
+		delete(entry.byHandler, handler)
+	}
+
+	if len(entry.byHandler) == 0 {
+		delete(s.entries, key)
+	}
+
+	return stale
+}
+
+// prune removes expired entries. The caller must hold the lock.
+func (s *answerStore) prune(now time.Time) {
+	for key, entry := range s.entries {
+		if now.After(entry.expires) {
+			delete(s.entries, key)
+		}
+	}
+	s.lastPruned = now
+}
+
+// answerSession records the messages a handler sends into the chat of the triggering message.
+// If the handler answered the message before, its new messages replace the previous ones in order.
+type answerSession struct {
+	gotgbot.BotClient
+	bot      *gotgbot.Bot
+	store    *answerStore
+	key      answerKey
+	handler  plugin.Handler
+	mu       sync.Mutex
+	previous []answer
+	sent     []answer
+}
+
+func (s *answerStore) newSession(b *gotgbot.Bot, msg *gotgbot.Message, handler plugin.Handler) *answerSession {
+	key := answerKey{chatID: msg.Chat.Id, messageID: msg.MessageId}
+	return &answerSession{
+		BotClient: b.BotClient,
+		bot:       b,
+		store:     s,
+		key:       key,
+		handler:   handler,
+		previous:  s.get(key, handler),
+	}
+}
+
+// Bot returns a copy of the bot that sends its requests through the session.
+func (s *answerSession) Bot() *gotgbot.Bot {
+	b := *s.bot
+	b.BotClient = s
+	return &b
+}
+
+func (s *answerSession) RequestWithContext(ctx context.Context, token string, method string, params map[string]any, opts *gotgbot.RequestOpts) (json.RawMessage, error) {
+	kind, isAnswer := answerKinds[method]
+	if chatID, _ := params["chat_id"].(int64); !isAnswer || chatID != s.key.chatID {
+		return s.BotClient.RequestWithContext(ctx, token, method, params, opts)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.previous) > 0 {
+		previous := s.previous[0]
+		s.previous = s.previous[1:]
+
+		if editMethod, editParams, ok := toEdit(method, params, previous); ok && previous.kind == kind {
+			r, err := s.BotClient.RequestWithContext(ctx, token, editMethod, editParams, opts)
+			if tgUtils.IsNotModified(err) {
+				r, err = json.Marshal(gotgbot.Message{
+					MessageId: previous.messageID,
+					Date:      time.Now().Unix(),
+					Chat:      gotgbot.Chat{Id: s.key.chatID},
+				})
+			}
+			if err == nil {
+				s.sent = append(s.sent, previous)
+			}
+			return r, err
+		}
+
+		s.delete(previous)
+	}
+
+	r, err := s.BotClient.RequestWithContext(ctx, token, method, params, opts)
+	if err == nil {
+		s.sent = append(s.sent, answersOf(r, kind)...)
+	}
+	return r, err
+}
+
+// finish deletes previous answers that weren't replaced and remembers the new ones.
+func (s *answerSession) finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, previous := range s.previous {
+		s.delete(previous)
+	}
+	s.previous = nil
+	s.store.set(s.key, s.handler, s.sent)
+}
+
+func (s *answerSession) delete(a answer) {
+	_, err := s.bot.DeleteMessage(s.key.chatID, a.messageID, nil)
+	if err != nil {
+		log.Err(err).
+			Int64("chat_id", s.key.chatID).
+			Int64("message_id", a.messageID).
+			Msg("Failed to delete previous answer")
+	}
+}
+
+// removeStaleAnswers deletes the answers of handlers that no longer match the edited message.
+func (p *Processor) removeStaleAnswers(b *gotgbot.Bot, msg *gotgbot.Message, matched map[plugin.Handler]bool) {
+	stale := p.answers.takeStale(answerKey{chatID: msg.Chat.Id, messageID: msg.MessageId}, matched)
+	if len(stale) == 0 {
+		return
+	}
+
+	p.running.Go(func() {
+		for _, a := range stale {
+			_, err := b.DeleteMessage(msg.Chat.Id, a.messageID, nil)
+			if err != nil {
+				log.Err(err).
+					Int64("chat_id", msg.Chat.Id).
+					Int64("message_id", a.messageID).
+					Msg("Failed to delete stale answer")
+			}
+		}
+	})
+}
+
+// toEdit converts a send request into the request that edits the previous answer instead.
+func toEdit(method string, params map[string]any, previous answer) (string, map[string]any, bool) {
+	editParams := map[string]any{
+		"chat_id":    params["chat_id"],
+		"message_id": previous.messageID,
+	}
+	if markup, ok := inlineKeyboardOf(params); ok {
+		editParams["reply_markup"] = markup
+	}
+
+	if method == "sendMessage" {
+		editParams["text"] = params["text"]
+		for _, key := range []string{"parse_mode", "entities", "link_preview_options"} {
+			if value, ok := params[key]; ok {
+				editParams[key] = value
+			}
+		}
+		return "editMessageText", editParams, true
+	}
+
+	caption, _ := params["caption"].(string)
+	parseMode, _ := params["parse_mode"].(string)
+	captionEntities, _ := params["caption_entities"].([]gotgbot.MessageEntity)
+
+	var media gotgbot.InputMedia
+	switch method {
+	case "sendPhoto":
+		file, _ := params["photo"].(gotgbot.InputFileOrString)
+		media = gotgbot.InputMediaPhoto{Media: file, Caption: caption, ParseMode: parseMode, CaptionEntities: captionEntities}
+	case "sendVideo":
+		file, _ := params["video"].(gotgbot.InputFileOrString)
+		media = gotgbot.InputMediaVideo{Media: file, Caption: caption, ParseMode: parseMode, CaptionEntities: captionEntities}
+	case "sendAnimation":
+		file, _ := params["animation"].(gotgbot.InputFileOrString)
+		media = gotgbot.InputMediaAnimation{Media: file, Caption: caption, ParseMode: parseMode, CaptionEntities: captionEntities}
+	case "sendDocument":
+		file, _ := params["document"].(gotgbot.InputFileOrString)
+		media = gotgbot.InputMediaDocument{Media: file, Caption: caption, ParseMode: parseMode, CaptionEntities: captionEntities}
+	case "sendAudio":
+		file, _ := params["audio"].(gotgbot.InputFileOrString)
+		media = gotgbot.InputMediaAudio{Media: file, Caption: caption, ParseMode: parseMode, CaptionEntities: captionEntities}
+	default:
+		return "", nil, false
+	}
+
+	editParams["media"] = media
+	return "editMessageMedia", editParams, true
+}
+
+// inlineKeyboardOf returns the reply markup if it's an inline keyboard, since only those can be edited.
+func inlineKeyboardOf(params map[string]any) (gotgbot.InlineKeyboardMarkup, bool) {
+	switch markup := params["reply_markup"].(type) {
+	case gotgbot.InlineKeyboardMarkup:
+		return markup, true
+	case *gotgbot.InlineKeyboardMarkup:
+		if markup != nil {
+			return *markup, true
+		}
+	}
+	return gotgbot.InlineKeyboardMarkup{}, false
+}
+
+func answersOf(r json.RawMessage, kind answerKind) []answer {
+	var messages []gotgbot.Message
+	if err := json.Unmarshal(r, &messages); err == nil {
+		answers := make([]answer, 0, len(messages))
+		for _, msg := range messages {
+			answers = append(answers, answer{messageID: msg.MessageId, kind: answerOther})
+		}
+		return answers
+	}
+
+	// copyMessage only returns the ID
+	var msg gotgbot.MessageId
+	if err := json.Unmarshal(r, &msg); err != nil || msg.MessageId == 0 {
+		return nil
+	}
+	return []answer{{messageID: msg.MessageId, kind: kind}}
+}
@@ -1,6 +1,7 @@
 package bot
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -13,9 +14,11 @@ import (
 	"github.com/Brawl345/gobot/plugin"
 	"github.com/Brawl345/gobot/plugin/about"
 	"github.com/Brawl345/gobot/plugin/afk"
+	"github.com/Brawl345/gobot/plugin/ai"
 	"github.com/Brawl345/gobot/plugin/alive"
 	"github.com/Brawl345/gobot/plugin/allow"
 	"github.com/Brawl345/gobot/plugin/amazon_ref_cleaner"
+	"github.com/Brawl345/gobot/plugin/audit"
 	"github.com/Brawl345/gobot/plugin/birthdays"
 	"github.com/Brawl345/gobot/plugin/brave_images"
 	"github.com/Brawl345/gobot/plugin/calc"
@@ -27,15 +30,15 @@ import (
 	"github.com/Brawl345/gobot/plugin/echo"
 	"github.com/Brawl345/gobot/plugin/expand"
 	"github.com/Brawl345/gobot/plugin/gelbooru"
-	"github.com/Brawl345/gobot/plugin/gemini"
 	"github.com/Brawl345/gobot/plugin/getfile"
 	"github.com/Brawl345/gobot/plugin/google_images"
 	"github.com/Brawl345/gobot/plugin/google_search"
 	"github.com/Brawl345/gobot/plugin/gps"
-	"github.com/Brawl345/gobot/plugin/gpt"
+	"github.com/Brawl345/gobot/plugin/help"
 	"github.com/Brawl345/gobot/plugin/home"
 	"github.com/Brawl345/gobot/plugin/id"
 	"github.com/Brawl345/gobot/plugin/ids"
+	"github.com/Brawl345/gobot/plugin/image_generation"
 	"github.com/Brawl345/gobot/plugin/kaomoji"
 	"github.com/Brawl345/gobot/plugin/manager"
 	"github.com/Brawl345/gobot/plugin/myanimelist"
@@ -44,6 +47,7 @@ import (
 	"github.com/Brawl345/gobot/plugin/randoms"
 	"github.com/Brawl345/gobot/plugin/reminders"
 	"github.com/Brawl345/gobot/plugin/replace"
+	"github.com/Brawl345/gobot/plugin/settings"
 	"github.com/Brawl345/gobot/plugin/speech_to_text"
 	"github.com/Brawl345/gobot/plugin/stats"
 	"github.com/Brawl345/gobot/plugin/summarize"
@@ -54,6 +58,7 @@ import (
 	"github.com/Brawl345/gobot/plugin/wikipedia"
 	"github.com/Brawl345/gobot/plugin/worldclock"
 	"github.com/Brawl345/gobot/plugin/youtube"
+	"github.com/Brawl345/gobot/utils"
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
 	"github.com/jmoiron/sqlx"
@@ -63,29 +68,14 @@ var log = logger.New("bot")
 
 type (
 	Gobot struct {
-		GoTgBot *gotgbot.Bot
-		updater *ext.Updater
+		GoTgBot    *gotgbot.Bot
+		updater    *ext.Updater
+		recorder   *updateRecorder
+		cancelJobs context.CancelFunc
 	}
 )
 
 func New(db *sqlx.DB) (*Gobot, error) {
-	// General services
-	chatService := sql.NewChatService(db)
-	credentialService := sql.NewCredentialService(db)
-	geocodingService := sql.NewGeocodingService()
-	pluginService := sql.NewPluginService(db)
-	userService := sql.NewUserService(db)
-	chatsPluginsService := sql.NewChatsPluginsService(db, chatService, pluginService)
-	chatsUsersService := sql.NewChatsUsersService(db, chatService, userService)
-	allowService, err := sql.NewAllowService(chatService, userService)
-	if err != nil {
-		return nil, err
-	}
-	managerSrvce, err := NewManagerService(chatsPluginsService, pluginService)
-	if err != nil {
-		return nil, err
-	}
-
 	// Bot itself
 	bot, err := gotgbot.NewBot(strings.TrimSpace(os.Getenv("BOT_TOKEN")), &gotgbot.BotOpts{
 		BotClient: &gotgbot.BaseBotClient{
@@ -97,78 +87,10 @@ func New(db *sqlx.DB) (*Gobot, error) {
 		return nil, err
 	}
 
-	dispatcher := ext.NewDispatcher(&ext.DispatcherOpts{
-		Processor: NewProcessor(allowService, chatsUsersService, managerSrvce, userService),
-	})
-	updater := ext.NewUpdater(dispatcher, &ext.UpdaterOpts{
-		UnhandledErrFunc: OnError,
-	})
-
-	// Plugin-specific services
-	afkService := sql.NewAfkService(db)
-	birthdayService := sql.NewBirthdayService(db)
-	braveImagesService := sql.NewBraveImagesService(db)
-	braveImagesCleanupService := sql.NewBraveImagesCleanupService(db)
-	cleverbotService := sql.NewCleverbotService(db)
-	fileService := sql.NewFileService(db)
-	geminiService := sql.NewGeminiService(db)
-	gptService := sql.NewGPTService(db)
-	googleImagesService := sql.NewGoogleImagesService(db)
-	googleImagesCleanupService := sql.NewGoogleImagesCleanupService(db)
-	gelbooruService := sql.NewGelbooruService(db)
-	gelbooruCleanupService := sql.NewGelbooruCleanupService(db)
-	homeService := sql.NewHomeService(db)
-	notifyService := sql.NewNotifyService(db)
-	quoteService := sql.NewQuoteService(db)
-	randomService := sql.NewRandomService(db)
-	reminderService := sql.NewReminderService(db)
-
-	plugins := []plugin.Plugin{
-		about.New(),
-		afk.New(afkService),
-		alive.New(),
-		allow.New(allowService),
-		amazon_ref_cleaner.New(),
-		birthdays.New(bot, birthdayService),
-		brave_images.New(credentialService, braveImagesService, braveImagesCleanupService),
-		calc.New(),
-		cleverbot.New(credentialService, cleverbotService),
-		creds.New(credentialService),
-		currency.New(),
-		dcrypt.New(),
-		delmsg.New(),
-		echo.New(),
-		expand.New(),
-		gelbooru.New(credentialService, gelbooruService, gelbooruCleanupService),
-		gemini.New(credentialService, geminiService),
-		gpt.New(credentialService, gptService),
-		getfile.New(credentialService, fileService),
-		google_images.New(credentialService, googleImagesService, googleImagesCleanupService),
-		google_search.New(credentialService),
-		gps.New(geocodingService),
-		home.New(geocodingService, homeService),
-		id.New(),
-		ids.New(chatsUsersService),
-		kaomoji.New(),
-		manager.New(managerSrvce),
-		myanimelist.New(credentialService),
-		notify.New(notifyService),
-		quotes.New(quoteService),
-		randoms.New(randomService),
-		reminders.New(bot, reminderService),
-		replace.New(),
-		speech_to_text.New(credentialService),
-		stats.New(chatsUsersService),
-		summarize.New(credentialService),
-		twitter.New(),
-		upload_by_url.New(),
-		urbandictionary.New(),
-		weather.New(geocodingService, homeService),
-		wikipedia.New(),
-		worldclock.New(credentialService, geocodingService),
-		youtube.New(credentialService),
+	processor, plugins, err := newProcessor(db)
+	if err != nil {
+		return nil, err
 	}
-	managerSrvce.SetPlugins(plugins)
 
 	log.Info().Msgf("Loaded %d plugins", len(plugins))
 
@@ -190,17 +112,76 @@ func New(db *sqlx.DB) (*Gobot, error) {
 		}
 	}
 
+	_, resumePendingUpdates := os.LookupEnv("RESUME_PENDING_UPDATES")
+	updateOffsetService := sql.NewUpdateOffsetService(db)
+
+	var updateProcessor ext.Processor = processor
+	watermark := newUpdateWatermark()
+	if resumePendingUpdates {
+		updateProcessor = &offsetProcessor{
+			Processor:           processor,
+			updateOffsetService: updateOffsetService,
+			watermark:           watermark,
+		}
+	}
+
+	dispatcher := ext.NewDispatcher(&ext.DispatcherOpts{
+		Processor: updateProcessor,
+	})
+
+	var updateDispatcher ext.UpdateDispatcher = dispatcher
+	if resumePendingUpdates {
+		updateDispatcher = &offsetDispatcher{
+			UpdateDispatcher: dispatcher,
+			watermark:        watermark,
+		}
+	}
+	var recorder *updateRecorder
+	if recordDir := strings.TrimSpace(os.Getenv("RECORD_UPDATES")); recordDir != "" {
+		recorder, err = newUpdateRecorder(recordDir)
+		if err != nil {
+			return nil, err
+		}
+		updateDispatcher = &recordingDispatcher{
+			UpdateDispatcher: updateDispatcher,
+			recorder:         recorder,
+		}
+		log.Info().Str("dir", recordDir).Msg("Recording updates")
+	}
+
+	updater := ext.NewUpdater(updateDispatcher, &ext.UpdaterOpts{
+		UnhandledErrFunc: OnError,
+	})
+
 	webhookPort := strings.TrimSpace(os.Getenv("PORT"))
 	webhookURL := strings.TrimSpace(os.Getenv("WEBHOOK_PUBLIC_URL"))
 	webhookUrlPath := os.Getenv("WEBHOOK_URL_PATH")
 
-	allowedUpdates := []string{"message", "edited_message", "callback_query", "inline_query"}
+	allowedUpdates := []string{"message", "edited_message", "callback_query", "inline_query", "message_reaction", "channel_post", "edited_channel_post"}
+	useWebhook := webhookPort != "" && webhookURL != "" && webhookUrlPath != ""
+
+	var offset int64
+	if resumePendingUpdates {
+		if useWebhook {
+			// Pending updates can only be fetched manually while no webhook is set
+			_, err = bot.DeleteWebhook(nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		offset, err = processPendingUpdates(bot, dispatcher, updateProcessor, updateOffsetService, allowedUpdates)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	if webhookPort == "" || webhookURL == "" || webhookUrlPath == "" {
+	if !useWebhook {
 		log.Debug().Msg("Using long polling")
 		err = updater.StartPolling(bot, &ext.PollingOpts{
-			DropPendingUpdates: true,
+			DropPendingUpdates: !resumePendingUpdates,
 			GetUpdatesOpts: &gotgbot.GetUpdatesOpts{
+				Offset:         offset,
 				AllowedUpdates: allowedUpdates,
 				Timeout:        10,
 				RequestOpts: &gotgbot.RequestOpts{
@@ -235,7 +216,7 @@ func New(db *sqlx.DB) (*Gobot, error) {
 		ok, err := bot.SetWebhook(webhookURL, &gotgbot.SetWebhookOpts{
 			AllowedUpdates:     allowedUpdates,
 			MaxConnections:     50,
-			DropPendingUpdates: true,
+			DropPendingUpdates: !resumePendingUpdates,
 			SecretToken:        webhookSecret,
 		})
 		if err != nil {
@@ -246,9 +227,14 @@ func New(db *sqlx.DB) (*Gobot, error) {
 		}
 	}
 
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+	startJobs(jobsCtx, bot, db, plugins)
+
 	b := &Gobot{
-		GoTgBot: bot,
-		updater: updater,
+		GoTgBot:    bot,
+		updater:    updater,
+		recorder:   recorder,
+		cancelJobs: cancelJobs,
 	}
 
 	return b, nil
@@ -259,5 +245,142 @@ func (b *Gobot) Start() {
 }
 
 func (b *Gobot) Stop() error {
-	return b.updater.Stop()
+	b.cancelJobs()
+	err := b.updater.Stop()
+	if b.recorder != nil {
+		if closeErr := b.recorder.Close(); closeErr != nil {
+			log.Err(closeErr).Msg("Failed to close update recorder")
+		}
+	}
+	return err
+}
+
+// startJobs starts the background jobs of the bot and its plugins, see plugin.JobRunner.
+func startJobs(ctx context.Context, bot *gotgbot.Bot, db *sqlx.DB, plugins []plugin.Plugin) {
+	callbackService := sql.NewCallbackService(db)
+	// Expired button payloads
+	utils.RunPeriodically(ctx, 24*time.Hour, 24*time.Hour, func() {
+		if err := callbackService.Cleanup(); err != nil {
+			log.Err(err).Msg("Failed to clean up callbacks")
+		}
+	})
+
+	for _, plg := range plugins {
+		if runner, ok := plg.(plugin.JobRunner); ok {
+			runner.StartJobs(ctx, bot)
+		}
+	}
+}
+
+// newProcessor sets up all services and plugins and returns the processor dispatching updates to them.
+func newProcessor(db *sqlx.DB) (*Processor, []plugin.Plugin, error) {
+	// General services
+	auditService := sql.NewAuditService(db)
+	callbackService := sql.NewCallbackService(db)
+	chatService := sql.NewChatService(db)
+	credentialService := sql.NewCredentialService(db)
+	geocodingService := sql.NewGeocodingService()
+	pluginService := sql.NewPluginService(db)
+	settingsService := sql.NewSettingsService(db)
+	userService := sql.NewUserService(db)
+	chatsPluginsService := sql.NewChatsPluginsService(db, chatService, pluginService)
+	chatsUsersService := sql.NewChatsUsersService(db, chatService, userService)
+	allowService, err := sql.NewAllowService(chatService, userService)
+	if err != nil {
+		return nil, nil, err
+	}
+	managerSrvce, err := NewManagerService(chatsPluginsService, credentialService, pluginService)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	processor := NewProcessor(allowService, callbackService, chatsUsersService, managerSrvce, userService)
+
+	// Plugin-specific services
+	afkService := sql.NewAfkService(db)
+	birthdayService := sql.NewBirthdayService(db)
+	braveImagesService := sql.NewBraveImagesService(db)
+	braveImagesCleanupService := sql.NewBraveImagesCleanupService(db)
+	cleverbotService := sql.NewCleverbotService(db)
+	fileService := sql.NewFileService(db)
+	googleImagesService := sql.NewGoogleImagesService(db)
+	googleImagesCleanupService := sql.NewGoogleImagesCleanupService(db)
+	gelbooruService := sql.NewGelbooruService(db)
+	gelbooruCleanupService := sql.NewGelbooruCleanupService(db)
+	homeService := sql.NewHomeService(db)
+	imageGenerationService := sql.NewImageGenerationService(db)
+	llmService := sql.NewLLMService(db)
+	notifyService := sql.NewNotifyService(db)
+	personaService := sql.NewPersonaService(db)
+	quoteService := sql.NewQuoteService(db)
+	randomService := sql.NewRandomService(db)
+	reminderService := sql.NewReminderService(db)
+
+	speechToText := speech_to_text.New(credentialService, managerSrvce)
+
+	plugins := []plugin.Plugin{
+		about.New(),
+		afk.New(afkService),
+		ai.New(auditService, credentialService, llmService, managerSrvce, personaService, settingsService, speechToText),
+		alive.New(),
+		allow.New(allowService, auditService),
+		amazon_ref_cleaner.New(),
+		audit.New(auditService, callbackService),
+		birthdays.New(birthdayService, settingsService),
+		brave_images.New(credentialService, braveImagesService, braveImagesCleanupService),
+		calc.New(),
+		cleverbot.New(credentialService, cleverbotService),
+		creds.New(credentialService, auditService),
+		currency.New(),
+		dcrypt.New(),
+		delmsg.New(auditService),
+		echo.New(),
+		expand.New(),
+		gelbooru.New(credentialService, gelbooruService, gelbooruCleanupService),
+		getfile.New(credentialService, fileService),
+		google_images.New(credentialService, googleImagesService, googleImagesCleanupService),
+		google_search.New(credentialService),
+		gps.New(geocodingService),
+		help.New(managerSrvce),
+		home.New(geocodingService, homeService),
+		id.New(),
+		ids.New(chatsUsersService, callbackService),
+		image_generation.New(credentialService, imageGenerationService, settingsService),
+		kaomoji.New(),
+		manager.New(managerSrvce, auditService),
+		myanimelist.New(credentialService),
+		notify.New(notifyService),
+		quotes.New(quoteService),
+		randoms.New(randomService, auditService),
+		reminders.New(reminderService),
+		replace.New(),
+		settings.New(auditService, callbackService, managerSrvce, settingsService),
+		speechToText,
+		stats.New(chatsUsersService, callbackService),
+		summarize.New(credentialService),
+		twitter.New(),
+		upload_by_url.New(),
+		urbandictionary.New(),
+		weather.New(geocodingService, homeService),
+		wikipedia.New(callbackService, settingsService),
+		worldclock.New(credentialService, geocodingService),
+		youtube.New(credentialService),
+	}
+	managerSrvce.SetPlugins(plugins)
+
+	for _, plg := range plugins {
+		required, optional := managerSrvce.MissingCredentials(plg)
+		if len(required) > 0 {
+			log.Warn().
+				Strs("credentials", required).
+				Msgf("Plugin %s is unusable because of missing credentials", plg.Name())
+		}
+		if len(optional) > 0 {
+			log.Debug().
+				Strs("credentials", optional).
+				Msgf("Plugin %s is missing optional credentials", plg.Name())
+		}
+	}
+
+	return processor, plugins, nil
 }
@@ -37,12 +37,18 @@ type (
 		handler *plugin.InlineHandler
 	}
 
+	reactionEntry struct {
+		plugin  plugin.Plugin
+		handler *plugin.ReactionHandler
+	}
+
 	handlerRegistry struct {
 		regexpCommands []regexpCommandEntry
 		mediaCommands  []mediaCommandEntry
 		entityCommands []entityCommandEntry
 		callbacks      []callbackEntry
 		inlines        []inlineEntry
+		reactions      []reactionEntry
 	}
 )
 
@@ -69,6 +75,8 @@ func buildHandlerRegistry(plugins []plugin.Plugin, botInfo *gotgbot.User) *handl
 				r.callbacks = append(r.callbacks, callbackEntry{plg, handler})
 			case *plugin.InlineHandler:
 				r.inlines = append(r.inlines, inlineEntry{plg, handler})
+			case *plugin.ReactionHandler:
+				r.reactions = append(r.reactions, reactionEntry{plg, handler})
 			}
 		}
 	}
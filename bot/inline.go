@@ -0,0 +1,384 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Brawl345/gobot/plugin"
+	"github.com/Brawl345/gobot/utils"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+const (
+	inlineMaxResults       = 50 // Telegram doesn't accept more
+	inlineHelpCacheTime    = 300
+	inlineDefaultCacheTime = 300 // Used by Telegram if a handler doesn't set one
+	inlineCacheMaxSize     = 1000
+)
+
+type (
+	// inlineAnswer is what a single inline handler wanted to answer.
+	inlineAnswer struct {
+		results    []gotgbot.InlineQueryResult
+		cacheTime  int64
+		isPersonal bool
+		button     *gotgbot.InlineQueryResultsButton
+		nextOffset string
+	}
+
+	// inlineCollector merges the answers of all inline handlers that matched a query
+	// and answers the query once all of them are done.
+	inlineCollector struct {
+		bot     *gotgbot.Bot
+		query   *gotgbot.InlineQuery
+		cache   *inlineCache
+		mu      sync.Mutex
+		pending int
+		answers []inlineAnswer
+	}
+
+	// inlineSession captures the answer of one inline handler instead of sending it.
+	inlineSession struct {
+		gotgbot.BotClient
+		collector *inlineCollector
+		mu        sync.Mutex
+		answer    *inlineAnswer
+	}
+
+	inlineCacheKey struct {
+		handler plugin.Handler
+		query   string
+		offset  string // Page of paginated results
+		userID  int64  // Only set for personal results
+	}
+
+	inlineCacheEntry struct {
+		answer  inlineAnswer
+		expires time.Time
+	}
+
+	// inlineCache keeps the results of every inline handler for the cache time it requested.
+	inlineCache struct {
+		mu      sync.Mutex
+		entries map[inlineCacheKey]inlineCacheEntry
+	}
+)
+
+func newInlineCache() *inlineCache {
+	return &inlineCache{entries: make(map[inlineCacheKey]inlineCacheEntry)}
+}
+
+func (c *inlineCache) get(handler plugin.Handler, query *gotgbot.InlineQuery) (inlineAnswer, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for _, key := range []inlineCacheKey{
+		{handler: handler, query: query.Query, offset: query.Offset},
+		{handler: handler, query: query.Query, offset: query.Offset, userID: query.From.Id},
+	} {
+		entry, ok := c.entries[key]
+		if ok && now.Before(entry.expires) {
+			return entry.answer, true
+		}
+	}
+	return inlineAnswer{}, false
+}
+
+func (c *inlineCache) set(handler plugin.Handler, query *gotgbot.InlineQuery, answer inlineAnswer) {
+	if len(answer.results) == 0 || answer.cacheTime <= 0 {
+		return
+	}
+
+	key := inlineCacheKey{handler: handler, query: query.Query, offset: query.Offset}
+	if answer.isPersonal {
+		key.userID = query.From.Id
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= inlineCacheMaxSize {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	if len(c.entries) >= inlineCacheMaxSize {
+		return
+	}
+
+	c.entries[key] = inlineCacheEntry{
+		answer:  answer,
+		expires: now.Add(time.Duration(answer.cacheTime) * time.Second),
+	}
+}
+
+func newInlineCollector(b *gotgbot.Bot, query *gotgbot.InlineQuery, cache *inlineCache, pending int) *inlineCollector {
+	return &inlineCollector{
+		bot:     b,
+		query:   query,
+		cache:   cache,
+		pending: pending,
+	}
+}
+
+// session returns a bot for a single handler whose inline answer is collected instead of sent.
+func (c *inlineCollector) session(handler plugin.Handler) (*gotgbot.Bot, func()) {
+	s := &inlineSession{
+		BotClient: c.bot.BotClient,
+		collector: c,
+	}
+	b := *c.bot
+	b.BotClient = s
+
+	return &b, func() {
+		s.mu.Lock()
+		answer := s.answer
+		s.mu.Unlock()
+
+		if answer != nil {
+			c.cache.set(handler, c.query, *answer)
+		}
+		c.done(answer)
+	}
+}
+
+func (s *inlineSession) RequestWithContext(ctx context.Context, token string, method string, params map[string]any, opts *gotgbot.RequestOpts) (json.RawMessage, error) {
+	if queryID, _ := params["inline_query_id"].(string); method != "answerInlineQuery" || queryID != s.collector.query.Id {
+		return s.BotClient.RequestWithContext(ctx, token, method, params, opts)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.answer == nil {
+		answer := inlineAnswer{cacheTime: inlineDefaultCacheTime}
+		answer.results, _ = params["results"].([]gotgbot.InlineQueryResult)
+		if cacheTime, ok := params["cache_time"].(*int64); ok && cacheTime != nil {
+			answer.cacheTime = *cacheTime
+		}
+		answer.isPersonal, _ = params["is_personal"].(bool)
+		answer.button, _ = params["button"].(*gotgbot.InlineQueryResultsButton)
+		answer.nextOffset, _ = params["next_offset"].(string)
+		s.answer = &answer
+	}
+
+	return json.RawMessage(`true`), nil
+}
+
+// done adds the answer of a handler, nil if it didn't answer. The last one sends the merged answer.
+func (c *inlineCollector) done(answer *inlineAnswer) {
+	c.mu.Lock()
+	if answer != nil {
+		c.answers = append(c.answers, *answer)
+	}
+	c.pending--
+	finished := c.pending == 0
+	c.mu.Unlock()
+
+	if finished {
+		if err := c.send(); err != nil {
+			log.Err(err).
+				Int64("user_id", c.query.From.Id).
+				Str("query", c.query.Query).
+				Msg("Failed to answer inline query")
+		}
+	}
+}
+
+func (c *inlineCollector) send() error {
+	var (
+		results    []json.RawMessage
+		cacheTime  int64 = -1
+		isPersonal bool
+		button     *gotgbot.InlineQueryResultsButton
+		nextOffset string
+		answered   int
+	)
+
+	for i, answer := range c.answers {
+		if len(answer.results) == 0 {
+			continue
+		}
+		answered++
+		nextOffset = answer.nextOffset
+
+		for _, result := range answer.results {
+			raw, err := json.Marshal(result)
+			if err != nil {
+				return err
+			}
+			// IDs have to be unique, but every plugin numbers its results on its own
+			if len(c.answers) > 1 {
+				raw, err = prefixResultID(raw, i)
+				if err != nil {
+					return err
+				}
+			}
+			results = append(results, raw)
+		}
+
+		// Handlers that don't want to be cached don't prevent caching the results of the others
+		if answer.cacheTime > 0 && (cacheTime == -1 || answer.cacheTime < cacheTime) {
+			cacheTime = answer.cacheTime
+		}
+		isPersonal = isPersonal || answer.isPersonal
+		if button == nil {
+			button = answer.button
+		}
+	}
+
+	if len(results) == 0 {
+		cacheTime = utils.InlineQueryFailureCacheTime
+		isPersonal = true
+	}
+	if cacheTime == -1 {
+		cacheTime = 0
+	}
+	if len(results) > inlineMaxResults {
+		results = results[:inlineMaxResults]
+	}
+
+	params := map[string]any{
+		"inline_query_id": c.query.Id,
+		"results":         results,
+		"cache_time":      cacheTime,
+	}
+	if isPersonal {
+		params["is_personal"] = true
+	}
+	if button != nil {
+		params["button"] = button
+	}
+	// Pages of merged results can't be told apart, so only a single handler can paginate
+	if answered == 1 && nextOffset != "" {
+		params["next_offset"] = nextOffset
+	}
+
+	_, err := c.bot.RequestWithContext(context.Background(), "answerInlineQuery", params, nil)
+	return err
+}
+
+func prefixResultID(raw json.RawMessage, prefix int) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+
+	var id string
+	if err := json.Unmarshal(fields["id"], &id); err != nil {
+		return nil, err
+	}
+
+	id = fmt.Sprintf("%d_%s", prefix, id)
+	if len(id) > 64 {
+		id = id[:64]
+	}
+
+	prefixed, err := json.Marshal(id)
+	if err != nil {
+		return nil, err
+	}
+	fields["id"] = prefixed
+
+	return json.Marshal(fields)
+}
+
+// canUseInlineCache reports whether the user may get cached results without passing the middlewares again.
+// Custom middlewares might deny the user, so their handlers always run.
+func (p *Processor) canUseInlineCache(ctx *ext.Context, e inlineEntry) bool {
+	if e.handler.AdminOnly || !p.managerService.IsPluginEnabled(e.plugin.Name()) {
+		return false
+	}
+	if p.customMiddlewares {
+		return false
+	}
+	if provider, ok := e.plugin.(plugin.MiddlewareProvider); ok && len(provider.Middlewares()) > 0 {
+		return false
+	}
+	if missing, _ := p.managerService.MissingCredentials(e.plugin); len(missing) > 0 {
+		return false
+	}
+	return e.handler.CanBeUsedByEveryone || p.allowService.IsUserAllowed(ctx.EffectiveUser)
+}
+
+// answerInlineHelp lists the inline triggers of all usable plugins for an empty query.
+func (p *Processor) answerInlineHelp(b *gotgbot.Bot, ctx *ext.Context) error {
+	userAllowed := p.allowService.IsUserAllowed(ctx.EffectiveUser)
+
+	var plugins []plugin.Plugin
+	for _, e := range p.handlers(b).inlines {
+		if slices.Contains(plugins, e.plugin) {
+			continue
+		}
+		if !userAllowed && !e.handler.CanBeUsedByEveryone {
+			continue
+		}
+		if e.handler.AdminOnly || !p.managerService.IsPluginEnabled(e.plugin.Name()) {
+			continue
+		}
+		if missing, _ := p.managerService.MissingCredentials(e.plugin); len(missing) > 0 {
+			continue
+		}
+		plugins = append(plugins, e.plugin)
+	}
+
+	slices.SortFunc(plugins, func(a, b plugin.Plugin) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	var results []gotgbot.InlineQueryResult
+	for _, plg := range plugins {
+		provider, ok := plg.(plugin.HelpProvider)
+		if !ok {
+			continue
+		}
+		help := provider.Help()
+		for _, trigger := range help.InlineTriggers {
+			results = append(results, gotgbot.InlineQueryResultArticle{
+				Id:          fmt.Sprintf("help_%d", len(results)),
+				Title:       trigger,
+				Description: help.Description,
+				InputMessageContent: gotgbot.InputTextMessageContent{
+					MessageText: fmt.Sprintf("<code>@%s %s</code>", b.Username, html.EscapeString(trigger)),
+					ParseMode:   gotgbot.ParseModeHTML,
+				},
+				ReplyMarkup: &gotgbot.InlineKeyboardMarkup{
+					InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
+						{
+							{
+								Text:                         "Ausprobieren",
+								SwitchInlineQueryCurrentChat: &trigger,
+							},
+						},
+					},
+				},
+			})
+		}
+	}
+
+	if len(results) > inlineMaxResults {
+		results = results[:inlineMaxResults]
+	}
+
+	cacheTime := int64(inlineHelpCacheTime)
+	if len(results) == 0 {
+		cacheTime = utils.InlineQueryFailureCacheTime
+	}
+
+	_, err := ctx.InlineQuery.Answer(b, results, &gotgbot.AnswerInlineQueryOpts{
+		CacheTime:  &cacheTime,
+		IsPersonal: true,
+	})
+	return err
+}
@@ -2,6 +2,7 @@ package bot
 
 import (
 	"slices"
+	"strings"
 	"sync"
 
 	"github.com/Brawl345/gobot/model"
@@ -10,16 +11,18 @@ import (
 )
 
 type managerService struct {
-	chatsPluginsService    model.ChatsPluginsService
-	pluginService          model.PluginService
-	plugins                []plugin.Plugin
-	mu                     sync.RWMutex
-	enabledPlugins         []string
-	disabledPluginsForChat map[int64][]string
+	chatsPluginsService model.ChatsPluginsService
+	credentialService   model.CredentialService
+	pluginService       model.PluginService
+	plugins             []plugin.Plugin
+	mu                  sync.RWMutex
+	enabledPlugins      []string
+	disabledPlugins     map[model.Topic][]string
 }
 
 func NewManagerService(
 	chatsPluginsService model.ChatsPluginsService,
+	credentialService model.CredentialService,
 	pluginService model.PluginService,
 ) (*managerService, error) {
 
@@ -28,16 +31,17 @@ func NewManagerService(
 		return nil, err
 	}
 
-	disabledPluginsForChat, err := chatsPluginsService.GetAllDisabled()
+	disabledPlugins, err := chatsPluginsService.GetAllDisabled()
 	if err != nil {
 		return nil, err
 	}
 
 	return &managerService{
-		chatsPluginsService:    chatsPluginsService,
-		pluginService:          pluginService,
-		enabledPlugins:         enabledPlugins,
-		disabledPluginsForChat: disabledPluginsForChat,
+		chatsPluginsService: chatsPluginsService,
+		credentialService:   credentialService,
+		pluginService:       pluginService,
+		enabledPlugins:      enabledPlugins,
+		disabledPlugins:     disabledPlugins,
 	}, nil
 }
 
@@ -70,10 +74,10 @@ func (service *managerService) EnablePlugin(name string) error {
 	return model.ErrNotFound
 }
 
-// isPluginDisabledForChat reports whether the plugin is disabled for the chat.
+// isPluginDisabledForTopic reports whether the plugin is disabled for the topic, 0 being the whole chat.
 // The caller must hold at least a read lock.
-func (service *managerService) isPluginDisabledForChat(chat *gotgbot.Chat, name string) bool {
-	disabledPlugins, exists := service.disabledPluginsForChat[chat.Id]
+func (service *managerService) isPluginDisabledForTopic(chat *gotgbot.Chat, threadID int64, name string) bool {
+	disabledPlugins, exists := service.disabledPlugins[model.Topic{ChatID: chat.Id, ThreadID: threadID}]
 	if !exists {
 		return false
 	}
@@ -81,29 +85,39 @@ func (service *managerService) isPluginDisabledForChat(chat *gotgbot.Chat, name
 }
 
 func (service *managerService) IsPluginDisabledForChat(chat *gotgbot.Chat, name string) bool {
+	return service.IsPluginDisabledForTopic(chat, 0, name)
+}
+
+// IsPluginDisabledForTopic only reports whether the plugin is disabled for the topic itself,
+// use IsPluginDisabledForChat for the whole chat.
+func (service *managerService) IsPluginDisabledForTopic(chat *gotgbot.Chat, threadID int64, name string) bool {
 	service.mu.RLock()
 	defer service.mu.RUnlock()
-	return service.isPluginDisabledForChat(chat, name)
+	return service.isPluginDisabledForTopic(chat, threadID, name)
 }
 
 func (service *managerService) EnablePluginForChat(chat *gotgbot.Chat, name string) error {
+	return service.EnablePluginForTopic(chat, 0, name)
+}
+
+func (service *managerService) EnablePluginForTopic(chat *gotgbot.Chat, threadID int64, name string) error {
 	service.mu.Lock()
 	defer service.mu.Unlock()
 
-	if !service.isPluginDisabledForChat(chat, name) {
+	if !service.isPluginDisabledForTopic(chat, threadID, name) {
 		return model.ErrAlreadyExists
 	}
 
 	for _, plg := range service.plugins {
 		if plg.Name() == name {
-			err := service.chatsPluginsService.Enable(chat, name)
+			err := service.chatsPluginsService.Enable(chat, threadID, name)
 			if err != nil {
 				return err
 			}
 
-			index := slices.Index(service.disabledPluginsForChat[chat.Id], name)
-			service.disabledPluginsForChat[chat.Id] = slices.Delete(service.disabledPluginsForChat[chat.Id],
-				index, index+1)
+			topic := model.Topic{ChatID: chat.Id, ThreadID: threadID}
+			index := slices.Index(service.disabledPlugins[topic], name)
+			service.disabledPlugins[topic] = slices.Delete(service.disabledPlugins[topic], index, index+1)
 
 			return nil
 		}
@@ -129,21 +143,26 @@ func (service *managerService) DisablePlugin(name string) error {
 }
 
 func (service *managerService) DisablePluginForChat(chat *gotgbot.Chat, name string) error {
+	return service.DisablePluginForTopic(chat, 0, name)
+}
+
+func (service *managerService) DisablePluginForTopic(chat *gotgbot.Chat, threadID int64, name string) error {
 	service.mu.Lock()
 	defer service.mu.Unlock()
 
-	if service.isPluginDisabledForChat(chat, name) {
+	if service.isPluginDisabledForTopic(chat, threadID, name) {
 		return model.ErrAlreadyExists
 	}
 
 	for _, plg := range service.plugins {
 		if plg.Name() == name {
-			err := service.chatsPluginsService.Disable(chat, name)
+			err := service.chatsPluginsService.Disable(chat, threadID, name)
 			if err != nil {
 				return err
 			}
 
-			service.disabledPluginsForChat[chat.Id] = append(service.disabledPluginsForChat[chat.Id], name)
+			topic := model.Topic{ChatID: chat.Id, ThreadID: threadID}
+			service.disabledPlugins[topic] = append(service.disabledPlugins[topic], name)
 
 			return nil
 		}
@@ -156,3 +175,30 @@ func (service *managerService) IsPluginEnabled(name string) bool {
 	defer service.mu.RUnlock()
 	return slices.Contains(service.enabledPlugins, name)
 }
+
+// MissingCredentials returns the names of the credentials the plugin declared but that aren't set.
+// Groups of which no credential is set are reported as one, e.g. "a/b".
+func (service *managerService) MissingCredentials(plg plugin.Plugin) (required []string, optional []string) {
+	provider, ok := plg.(plugin.CredentialsProvider)
+	if !ok {
+		return nil, nil
+	}
+
+	credentials := provider.Credentials()
+	for _, name := range credentials.Required {
+		if service.credentialService.GetKey(name) == "" {
+			required = append(required, name)
+		}
+	}
+	for _, group := range credentials.AnyOf {
+		if !slices.ContainsFunc(group, func(name string) bool { return service.credentialService.GetKey(name) != "" }) {
+			required = append(required, strings.Join(group, "/"))
+		}
+	}
+	for _, name := range credentials.Optional {
+		if service.credentialService.GetKey(name) == "" {
+			optional = append(optional, name)
+		}
+	}
+	return required, optional
+}
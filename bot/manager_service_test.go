@@ -0,0 +1,56 @@
+package bot
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Brawl345/gobot/plugin"
+)
+
+type fakeCredentialService struct {
+	keys map[string]string
+}
+
+func (f *fakeCredentialService) GetAllCredentials() map[string]string { return f.keys }
+func (f *fakeCredentialService) GetKey(name string) string            { return f.keys[name] }
+func (f *fakeCredentialService) SetKey(string, string) error          { return nil }
+func (f *fakeCredentialService) DeleteKey(string) error               { return nil }
+
+type credentialsPlugin struct {
+	fakePlugin
+	credentials plugin.Credentials
+}
+
+func (p *credentialsPlugin) Credentials() plugin.Credentials { return p.credentials }
+
+func TestMissingCredentials(t *testing.T) {
+	plg := &credentialsPlugin{
+		fakePlugin: fakePlugin{name: "creds"},
+		credentials: plugin.Credentials{
+			Required: []string{"token"},
+			AnyOf:    [][]string{{"a_key", "b_url"}},
+			Optional: []string{"model"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		keys     map[string]string
+		required []string
+		optional []string
+	}{
+		{"nothing set", map[string]string{}, []string{"token", "a_key/b_url"}, []string{"model"}},
+		{"one of the group", map[string]string{"token": "x", "b_url": "http://localhost"}, nil, []string{"model"}},
+		{"everything", map[string]string{"token": "x", "a_key": "y", "model": "z"}, nil, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			service := &managerService{credentialService: &fakeCredentialService{keys: tc.keys}}
+			required, optional := service.MissingCredentials(plg)
+			if !slices.Equal(required, tc.required) || !slices.Equal(optional, tc.optional) {
+				t.Errorf("MissingCredentials() = %v, %v, want %v, %v", required, optional, tc.required, tc.optional)
+			}
+		})
+	}
+}
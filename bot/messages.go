@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+const (
+	messageCacheTTL     = 48 * time.Hour
+	messageCacheMaxSize = 10000
+)
+
+type (
+	messageCacheEntry struct {
+		key     answerKey
+		message *gotgbot.Message
+		expires time.Time
+	}
+
+	// messageCache remembers recent messages, since reaction updates only contain the message ID.
+	// When it's full, the oldest messages are evicted since reactions mostly come in for new ones.
+	messageCache struct {
+		mu      sync.Mutex
+		entries map[answerKey]*list.Element
+		order   *list.List // Newest first, so expired entries are at the back
+	}
+)
+
+func newMessageCache() *messageCache {
+	return &messageCache{
+		entries: make(map[answerKey]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (c *messageCache) get(chatID int64, messageID int64) *gotgbot.Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[answerKey{chatID: chatID, messageID: messageID}]
+	if !ok {
+		return nil
+	}
+	entry := element.Value.(*messageCacheEntry)
+	if time.Now().After(entry.expires) {
+		return nil
+	}
+	return entry.message
+}
+
+func (c *messageCache) set(msg *gotgbot.Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	key := answerKey{chatID: msg.Chat.Id, messageID: msg.MessageId}
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*messageCacheEntry)
+		entry.message = msg
+		entry.expires = now.Add(messageCacheTTL)
+		c.order.MoveToFront(element)
+	} else {
+		c.entries[key] = c.order.PushFront(&messageCacheEntry{
+			key:     key,
+			message: msg,
+			expires: now.Add(messageCacheTTL),
+		})
+	}
+
+	for c.order.Len() > 0 {
+		oldest := c.order.Back()
+		if c.order.Len() <= messageCacheMaxSize && !now.After(oldest.Value.(*messageCacheEntry).expires) {
+			break
+		}
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*messageCacheEntry).key)
+	}
+}
@@ -0,0 +1,24 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+func TestMessageCacheEvictsOldest(t *testing.T) {
+	c := newMessageCache()
+	for i := range messageCacheMaxSize + 1 {
+		c.set(&gotgbot.Message{MessageId: int64(i), Chat: gotgbot.Chat{Id: 1}})
+	}
+
+	if c.get(1, 0) != nil {
+		t.Error("the oldest message must be evicted when the cache is full")
+	}
+	if c.get(1, messageCacheMaxSize) == nil {
+		t.Error("the newest message must be cached")
+	}
+	if len(c.entries) != messageCacheMaxSize {
+		t.Errorf("got %d entries, want %d", len(c.entries), messageCacheMaxSize)
+	}
+}
@@ -87,13 +87,23 @@ func onMessage(msg *gotgbot.Message) string {
 	sb.WriteString(reset)
 
 	// Sender
-	if msg.GetSender() != nil {
+	if msg.From != nil {
 		sb.WriteString(
 			fmt.Sprintf(
 				" %s",
 				printUser(msg.From),
 			),
 		)
+	} else if msg.SenderChat != nil && msg.SenderChat.Id != msg.Chat.Id {
+		sb.WriteString(
+			fmt.Sprintf(
+				" %s%s%s%s",
+				bold,
+				red,
+				msg.SenderChat.Title,
+				reset,
+			),
+		)
 	}
 
 	// Begin message
@@ -754,6 +764,57 @@ func onInlineQuery(query *gotgbot.InlineQuery) string {
 	return sb.String()
 }
 
+func onReaction(reaction *gotgbot.MessageReactionUpdated) string {
+	var sb strings.Builder
+
+	// Time
+	sb.WriteString(
+		fmt.Sprintf(
+			"%s[%v]%s ",
+			cyan,
+			utils.TimestampToTime(reaction.Date).Format("15:04:05"),
+			reset,
+		),
+	)
+
+	// Chat Title
+	if reaction.Chat.Title != "" {
+		sb.WriteString(
+			fmt.Sprintf(
+				"%s%s:%s ",
+				cyan,
+				reaction.Chat.Title,
+				reset,
+			),
+		)
+	}
+
+	// Sender
+	if reaction.User != nil {
+		sb.WriteString(printUser(reaction.User))
+	}
+
+	// Begin message
+	sb.WriteString(
+		fmt.Sprintf(
+			"%s >>> %s%s(Reaktion auf %d)%s ",
+			cyan,
+			reset,
+			green,
+			reaction.MessageId,
+			reset,
+		),
+	)
+
+	for _, r := range reaction.NewReaction {
+		if emoji, ok := r.(gotgbot.ReactionTypeEmoji); ok {
+			sb.WriteString(emoji.Emoji)
+		}
+	}
+
+	return sb.String()
+}
+
 func PrintMessage(c *ext.Context) {
 	var text string
 
@@ -762,10 +823,16 @@ func PrintMessage(c *ext.Context) {
 		text = onMessage(c.Message)
 	case gotgbot.UpdateTypeEditedMessage:
 		text = onMessage(c.EditedMessage)
+	case gotgbot.UpdateTypeChannelPost:
+		text = onMessage(c.ChannelPost)
+	case gotgbot.UpdateTypeEditedChannelPost:
+		text = onMessage(c.EditedChannelPost)
 	case gotgbot.UpdateTypeCallbackQuery:
 		text = onCallback(c.CallbackQuery)
 	case gotgbot.UpdateTypeInlineQuery:
 		text = onInlineQuery(c.InlineQuery)
+	case gotgbot.UpdateTypeMessageReaction:
+		text = onReaction(c.MessageReaction)
 	default:
 		text = fmt.Sprintf(
 			"%s>>> %s%sUnbekannter Nachrichtentyp%s",
@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/Brawl345/gobot/model"
+	"github.com/Brawl345/gobot/utils/tgUtils"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+var regexPagination = regexp.MustCompile(fmt.Sprintf(`^%s:(\w+):(\d+)$`, tgUtils.PaginationPrefix))
+
+// onPage turns the pages of messages sent with tgUtils.ReplyPaginated. It's part of the bot instead of
+// a plugin so the buttons of all plugins keep working regardless of which plugins are enabled.
+func (p *Processor) onPage(b *gotgbot.Bot, ctx *ext.Context, matches []string) error {
+	callback := ctx.CallbackQuery
+
+	if !p.isAllowed(ctx) {
+		_, err := callback.Answer(b, nil)
+		return err
+	}
+
+	var pages []string
+	err := p.callbackService.Get(matches[1], ctx.EffectiveUser, &pages)
+	if err != nil {
+		var text string
+		switch {
+		case errors.Is(err, model.ErrNotFound):
+			text = "❌ Diese Nachricht kann nicht mehr geblättert werden."
+		case errors.Is(err, model.ErrNotOwner):
+			text = "❌ Nur wer gefragt hat, kann blättern."
+		default:
+			log.Err(err).
+				Str("data", callback.Data).
+				Msg("Failed to get pages")
+			text = "❌ Es ist ein Fehler aufgetreten."
+		}
+		_, err := callback.Answer(b, &gotgbot.AnswerCallbackQueryOpts{
+			Text:      text,
+			ShowAlert: true,
+		})
+		return err
+	}
+
+	page, err := strconv.Atoi(matches[2])
+	if err != nil || page >= len(pages) || ctx.EffectiveMessage == nil {
+		_, err := callback.Answer(b, nil)
+		return err
+	}
+
+	_, _, err = ctx.EffectiveMessage.EditText(b, pages[page], &gotgbot.EditMessageTextOpts{
+		ParseMode: gotgbot.ParseModeHTML,
+		LinkPreviewOptions: &gotgbot.LinkPreviewOptions{
+			IsDisabled: true,
+		},
+		ReplyMarkup: *tgUtils.PaginationKeyboard(matches[1], page, len(pages)),
+	})
+	// Pressing the button of the current page doesn't change anything
+	if err != nil && !tgUtils.IsNotModified(err) {
+		log.Err(err).
+			Int64("chat_id", ctx.EffectiveChat.Id).
+			Int("page", page).
+			Msg("Failed to edit page")
+	}
+
+	_, err = callback.Answer(b, nil)
+	return err
+}
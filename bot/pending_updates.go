@@ -0,0 +1,208 @@
+package bot
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Brawl345/gobot/model"
+	"github.com/Brawl345/gobot/utils"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+const pendingUpdatesBatchSize = 100
+
+// pendingUpdateMaxAge is the maximum age of an update that was queued while the bot was offline.
+// Callback and inline queries don't carry a timestamp and can't be answered anymore after a restart,
+// so they are always skipped. Update types not listed here are processed regardless of their age.
+var pendingUpdateMaxAge = map[string]time.Duration{
+	gotgbot.UpdateTypeMessage:           30 * time.Minute,
+	gotgbot.UpdateTypeEditedMessage:     30 * time.Minute,
+	gotgbot.UpdateTypeChannelPost:       30 * time.Minute,
+	gotgbot.UpdateTypeEditedChannelPost: 30 * time.Minute,
+	gotgbot.UpdateTypeMessageReaction:   30 * time.Minute,
+	gotgbot.UpdateTypeCallbackQuery:     0,
+	gotgbot.UpdateTypeInlineQuery:       0,
+}
+
+type (
+	// updateWatermark tracks the updates that are being processed concurrently. The watermark is the
+	// highest update ID up to which all updates have been processed, so no update is lost after a crash.
+	updateWatermark struct {
+		mu       sync.Mutex
+		inFlight []int64 // Sorted by ID, webhooks may deliver updates out of order
+		finished map[int64]struct{}
+	}
+
+	// offsetDispatcher registers every update in the order it was received, before it is
+	// processed concurrently by the actual dispatcher.
+	offsetDispatcher struct {
+		ext.UpdateDispatcher
+		watermark *updateWatermark
+	}
+
+	// offsetProcessor persists the watermark after every processed update so that pending updates
+	// can be resumed after a restart without handling any update twice or missing one.
+	offsetProcessor struct {
+		ext.Processor
+		updateOffsetService model.UpdateOffsetService
+		watermark           *updateWatermark
+	}
+)
+
+func newUpdateWatermark() *updateWatermark {
+	return &updateWatermark{finished: make(map[int64]struct{})}
+}
+
+func (w *updateWatermark) start(updateID int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	i, found := slices.BinarySearch(w.inFlight, updateID)
+	if !found {
+		w.inFlight = slices.Insert(w.inFlight, i, updateID)
+	}
+}
+
+// finish marks the update as processed and returns the new watermark if it moved.
+// Updates that were never started (e.g. pending ones) are ignored.
+func (w *updateWatermark) finish(updateID int64) (int64, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, found := slices.BinarySearch(w.inFlight, updateID); !found {
+		return 0, false
+	}
+	w.finished[updateID] = struct{}{}
+
+	var watermark int64
+	moved := false
+	for len(w.inFlight) > 0 {
+		if _, ok := w.finished[w.inFlight[0]]; !ok {
+			break
+		}
+		watermark = w.inFlight[0]
+		delete(w.finished, watermark)
+		w.inFlight = w.inFlight[1:]
+		moved = true
+	}
+	return watermark, moved
+}
+
+func (d *offsetDispatcher) Start(b *gotgbot.Bot, updates <-chan json.RawMessage) {
+	registered := make(chan json.RawMessage)
+	go func() {
+		defer close(registered)
+		for update := range updates {
+			var header struct {
+				UpdateID int64 `json:"update_id"`
+			}
+			if err := json.Unmarshal(update, &header); err == nil {
+				d.watermark.start(header.UpdateID)
+			}
+			registered <- update
+		}
+	}()
+	d.UpdateDispatcher.Start(b, registered)
+}
+
+func (p *offsetProcessor) ProcessUpdate(d *ext.Dispatcher, b *gotgbot.Bot, ctx *ext.Context) error {
+	err := p.Processor.ProcessUpdate(d, b, ctx)
+	watermark, moved := p.watermark.finish(ctx.UpdateId)
+	if !moved {
+		return err
+	}
+	if setErr := p.updateOffsetService.SetLastUpdateID(watermark); setErr != nil {
+		log.Err(setErr).
+			Int64("update_id", watermark).
+			Msg("Failed to persist update offset")
+	}
+	return err
+}
+
+func isPendingUpdateStale(update *gotgbot.Update, now time.Time) bool {
+	maxAge, limited := pendingUpdateMaxAge[update.GetType()]
+	if !limited {
+		return false
+	}
+	if maxAge == 0 {
+		return true
+	}
+
+	var date int64
+	switch {
+	case update.Message != nil:
+		date = update.Message.Date
+	case update.EditedMessage != nil:
+		date = update.EditedMessage.EditDate
+	case update.ChannelPost != nil:
+		date = update.ChannelPost.Date
+	case update.EditedChannelPost != nil:
+		date = update.EditedChannelPost.EditDate
+	case update.MessageReaction != nil:
+		date = update.MessageReaction.Date
+	}
+
+	return now.Sub(utils.TimestampToTime(date)) > maxAge
+}
+
+// processPendingUpdates fetches all updates Telegram queued while the bot was offline and processes
+// those that were neither handled before the restart nor are too old. It returns the offset
+// the updater should continue with. Webhooks have to be removed beforehand.
+func processPendingUpdates(b *gotgbot.Bot, d *ext.Dispatcher, processor ext.Processor, updateOffsetService model.UpdateOffsetService, allowedUpdates []string) (int64, error) {
+	lastUpdateID, err := updateOffsetService.GetLastUpdateID()
+	if err != nil {
+		return 0, err
+	}
+
+	var offset int64
+	var processed, skipped int
+
+	for {
+		updates, err := b.GetUpdates(&gotgbot.GetUpdatesOpts{
+			Offset:         offset,
+			Limit:          pendingUpdatesBatchSize,
+			AllowedUpdates: allowedUpdates,
+		})
+		if err != nil {
+			return offset, err
+		}
+		if len(updates) == 0 {
+			break
+		}
+
+		now := time.Now()
+		for _, update := range updates {
+			offset = update.UpdateId + 1
+
+			if update.UpdateId <= lastUpdateID {
+				continue
+			}
+
+			if isPendingUpdateStale(&update, now) {
+				log.Debug().
+					Int64("update_id", update.UpdateId).
+					Str("type", update.GetType()).
+					Msg("Skipping stale pending update")
+				skipped++
+				continue
+			}
+
+			if err := processor.ProcessUpdate(d, b, ext.NewContext(b, &update, nil)); err != nil {
+				OnError(err)
+			}
+			processed++
+		}
+	}
+
+	if offset != 0 {
+		if err := updateOffsetService.SetLastUpdateID(offset - 1); err != nil {
+			return offset, err
+		}
+	}
+
+	log.Info().Msgf("Processed %d pending updates, skipped %d stale ones", processed, skipped)
+
+	return offset, nil
+}
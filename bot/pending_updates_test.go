@@ -0,0 +1,77 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+func TestPendingUpdateStaleness(t *testing.T) {
+	now := time.Now()
+	fresh := now.Add(-time.Minute).Unix()
+	old := now.Add(-time.Hour).Unix()
+
+	tests := []struct {
+		name   string
+		update *gotgbot.Update
+		stale  bool
+	}{
+		{"fresh message", &gotgbot.Update{Message: &gotgbot.Message{Date: fresh}}, false},
+		{"old message", &gotgbot.Update{Message: &gotgbot.Message{Date: old}}, true},
+		{"fresh edit of old message", &gotgbot.Update{EditedMessage: &gotgbot.Message{Date: old, EditDate: fresh}}, false},
+		{"old channel post", &gotgbot.Update{ChannelPost: &gotgbot.Message{Date: old}}, true},
+		{"fresh edit of old channel post", &gotgbot.Update{EditedChannelPost: &gotgbot.Message{Date: old, EditDate: fresh}}, false},
+		{"old reaction", &gotgbot.Update{MessageReaction: &gotgbot.MessageReactionUpdated{Date: old}}, true},
+		{"fresh reaction", &gotgbot.Update{MessageReaction: &gotgbot.MessageReactionUpdated{Date: fresh}}, false},
+		{"callback query", &gotgbot.Update{CallbackQuery: &gotgbot.CallbackQuery{Data: "x"}}, true},
+		{"inline query", &gotgbot.Update{InlineQuery: &gotgbot.InlineQuery{Query: "x"}}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isPendingUpdateStale(tc.update, now); got != tc.stale {
+				t.Errorf("isPendingUpdateStale() = %v, want %v", got, tc.stale)
+			}
+		})
+	}
+}
+
+func TestUpdateWatermark(t *testing.T) {
+	w := newUpdateWatermark()
+	for _, id := range []int64{10, 11, 13} {
+		w.start(id)
+	}
+
+	steps := []struct {
+		finish    int64
+		watermark int64
+		moved     bool
+	}{
+		{11, 0, false}, // 10 is still running
+		{13, 0, false},
+		{10, 13, true},
+		{42, 0, false}, // Never started
+	}
+
+	for _, step := range steps {
+		watermark, moved := w.finish(step.finish)
+		if watermark != step.watermark || moved != step.moved {
+			t.Errorf("finish(%d) = %d, %v, want %d, %v", step.finish, watermark, moved, step.watermark, step.moved)
+		}
+	}
+}
+
+func TestUpdateWatermarkOutOfOrder(t *testing.T) {
+	w := newUpdateWatermark()
+	for _, id := range []int64{11, 10, 12} {
+		w.start(id)
+	}
+
+	if watermark, moved := w.finish(11); moved {
+		t.Errorf("finish(11) moved the watermark to %d although 10 is still running", watermark)
+	}
+	if watermark, moved := w.finish(10); watermark != 11 || !moved {
+		t.Errorf("finish(10) = %d, %v, want 11, true", watermark, moved)
+	}
+}
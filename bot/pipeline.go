@@ -0,0 +1,295 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Brawl345/gobot/plugin"
+	"github.com/Brawl345/gobot/utils"
+	"github.com/Brawl345/gobot/utils/tgUtils"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/rs/xid"
+)
+
+// Use adds global middlewares that wrap the handlers of all plugins.
+// They run after the built-in ones and before the plugin's own middlewares.
+func (p *Processor) Use(middlewares ...plugin.Middleware) {
+	p.middlewares = append(p.middlewares, middlewares...)
+	p.customMiddlewares = p.customMiddlewares || len(middlewares) > 0
+}
+
+// builtinMiddlewares contains the checks every handler has to pass, in order.
+func (p *Processor) builtinMiddlewares() []plugin.Middleware {
+	return []plugin.Middleware{
+		p.checkAllowed,
+		logMatch,
+		p.checkPluginEnabled,
+		checkAdminOnly,
+		checkCooldown,
+		p.deleteButton,
+	}
+}
+
+// dispatch runs the handler through the middleware pipeline in a new goroutine.
+func (p *Processor) dispatch(b *gotgbot.Bot, ctx *ext.Context, plg plugin.Plugin, handler plugin.Handler, matches []string, namedMatches map[string]string) {
+	p.dispatchThen(b, ctx, plg, handler, matches, namedMatches, nil)
+}
+
+// dispatchThen is like dispatch, but calls after once the handler has returned.
+func (p *Processor) dispatchThen(b *gotgbot.Bot, ctx *ext.Context, plg plugin.Plugin, handler plugin.Handler, matches []string, namedMatches map[string]string, after func()) {
+	middlewares := p.middlewares
+	if provider, ok := plg.(plugin.MiddlewareProvider); ok {
+		middlewares = append(slices.Clip(middlewares), provider.Middlewares()...)
+	}
+	run := plugin.Chain(handler.Run, middlewares...)
+
+	c := plugin.GobotContext{
+		Context:      ctx,
+		Matches:      matches,
+		NamedMatches: namedMatches,
+		Plugin:       plg,
+		Handler:      handler,
+		ThreadID:     threadIDOf(ctx),
+	}
+
+	if c.ThreadID != 0 {
+		b = topicBot(b, ctx.EffectiveChat.Id, c.ThreadID)
+	}
+
+	// Answers of handlers that handle edits are remembered so they can be replaced later
+	var session *answerSession
+	if commandHandler, ok := handler.(*plugin.CommandHandler); ok && commandHandler.HandleEdits && ctx.EffectiveMessage != nil {
+		session = p.answers.newSession(b, ctx.EffectiveMessage, handler)
+		b = session.Bot()
+	}
+
+	p.running.Go(func() {
+		defer func() {
+			if r := recover(); r != nil {
+				reportHandlerError(b, c, errors.New("panic"), r)
+			}
+			if session != nil {
+				session.finish()
+			}
+			if after != nil {
+				after()
+			}
+		}()
+		if err := run(b, c); err != nil {
+			reportHandlerError(b, c, err, nil)
+		}
+	})
+}
+
+// reportHandlerError logs a failed handler. Users are only notified for messages
+// since callback queries, inline queries and reactions can't be answered with an error message.
+func reportHandlerError(b *gotgbot.Bot, c plugin.GobotContext, err error, recovered any) {
+	lg := log.Err(err).Str("component", c.Plugin.Name())
+
+	switch {
+	case c.CallbackQuery != nil:
+		var chatId int64
+		if c.EffectiveChat != nil {
+			chatId = c.EffectiveChat.Id
+		}
+		lg = lg.Int64("chat_id", chatId).Str("callback_data", c.CallbackQuery.Data)
+	case c.InlineQuery != nil:
+		lg = lg.Int64("user_id", c.EffectiveUser.Id).Str("query", c.InlineQuery.Query)
+	case c.MessageReaction != nil:
+		lg = lg.Int64("chat_id", c.EffectiveChat.Id).Int64("message_id", c.MessageReaction.MessageId)
+	default:
+		guid := xid.New().String()
+		lg = lg.Str("guid", guid).Interface("ctx", c.Context)
+		defer func() {
+			_, _ = c.EffectiveMessage.Reply(b, fmt.Sprintf("❌ Es ist ein Fehler aufgetreten.%s", utils.EmbedGUID(guid)), utils.DefaultSendOptions())
+		}()
+	}
+
+	if recovered != nil {
+		lg.Msgf("%s", recovered)
+		return
+	}
+	lg.Send()
+}
+
+// deny tells the user why the handler didn't run, where possible.
+func deny(b *gotgbot.Bot, c plugin.GobotContext, text string) error {
+	switch {
+	case c.CallbackQuery != nil:
+		_, err := c.CallbackQuery.Answer(b, &gotgbot.AnswerCallbackQueryOpts{
+			Text:      text,
+			ShowAlert: true,
+		})
+		return err
+	case c.InlineQuery != nil:
+		_, err := c.InlineQuery.Answer(b, nil, &gotgbot.AnswerInlineQueryOpts{
+			CacheTime:  utils.Ptr(utils.InlineQueryFailureCacheTime),
+			IsPersonal: true,
+		})
+		return err
+	default:
+		return nil
+	}
+}
+
+// fromGroup reports whether the update was sent in a group, also for callback queries on inaccessible messages
+// and reactions on messages the bot doesn't know.
+func fromGroup(ctx *ext.Context) bool {
+	switch {
+	case ctx.CallbackQuery != nil:
+		return ctx.CallbackQuery.Message != nil && tgUtils.FromGroup(ctx.CallbackQuery.Message)
+	case ctx.EffectiveMessage != nil:
+		return tgUtils.FromGroup(ctx.EffectiveMessage)
+	case ctx.MessageReaction != nil:
+		return ctx.EffectiveChat.Type == gotgbot.ChatTypeGroup || ctx.EffectiveChat.Type == gotgbot.ChatTypeSupergroup
+	default:
+		return false
+	}
+}
+
+// fromChannel reports whether the update is a post in a channel.
+func fromChannel(ctx *ext.Context) bool {
+	return ctx.EffectiveMessage != nil && tgUtils.FromChannel(ctx.EffectiveMessage)
+}
+
+// isAllowed reports whether the user is allowed to use the bot. In groups, it's sufficient for the chat to be allowed.
+// Channel posts have no user, so only the channel counts there.
+func (p *Processor) isAllowed(ctx *ext.Context) bool {
+	if ctx.EffectiveUser != nil && p.allowService.IsUserAllowed(ctx.EffectiveUser) {
+		return true
+	}
+	return (fromGroup(ctx) || fromChannel(ctx)) && p.allowService.IsChatAllowed(ctx.EffectiveChat)
+}
+
+func logMatch(next plugin.GobotHandlerFunc) plugin.GobotHandlerFunc {
+	return func(b *gotgbot.Bot, c plugin.GobotContext) error {
+		command := c.Handler.Command()
+		log.Printf("Matched plugin '%s': %s (%T)", c.Plugin.Name(), command, command)
+
+		start := time.Now()
+		err := next(b, c)
+		log.Debug().
+			Str("plugin", c.Plugin.Name()).
+			Dur("duration", time.Since(start)).
+			Msg("Handler finished")
+		return err
+	}
+}
+
+func (p *Processor) checkAllowed(next plugin.GobotHandlerFunc) plugin.GobotHandlerFunc {
+	return func(b *gotgbot.Bot, c plugin.GobotContext) error {
+		if handler, ok := c.Handler.(*plugin.InlineHandler); ok && handler.CanBeUsedByEveryone {
+			return next(b, c)
+		}
+
+		if !p.isAllowed(c.Context) {
+			if c.EffectiveUser == nil {
+				log.Debug().Int64("chat_id", c.EffectiveChat.Id).Msg("Chat is not allowed")
+			} else {
+				log.Debug().Int64("user_id", c.EffectiveUser.Id).Msg("User/Chat is not allowed")
+			}
+			return deny(b, c, "Du darfst diesen Bot nicht nutzen.")
+		}
+
+		return next(b, c)
+	}
+}
+
+func (p *Processor) checkPluginEnabled(next plugin.GobotHandlerFunc) plugin.GobotHandlerFunc {
+	return func(b *gotgbot.Bot, c plugin.GobotContext) error {
+		name := c.Plugin.Name()
+
+		if !p.managerService.IsPluginEnabled(name) {
+			log.Printf("Plugin %s is disabled globally", name)
+			return deny(b, c, "Dieser Befehl ist nicht verfügbar.")
+		}
+
+		if (fromGroup(c.Context) || fromChannel(c.Context)) && p.managerService.IsPluginDisabledForChat(c.EffectiveChat, name) {
+			log.Printf("Plugin %s is disabled for this chat", name)
+			return deny(b, c, "Dieser Befehl ist nicht verfügbar.")
+		}
+
+		if c.ThreadID != 0 && p.managerService.IsPluginDisabledForTopic(c.EffectiveChat, c.ThreadID, name) {
+			log.Printf("Plugin %s is disabled for this topic", name)
+			return deny(b, c, "Dieser Befehl ist nicht verfügbar.")
+		}
+
+		if missing, _ := p.managerService.MissingCredentials(c.Plugin); len(missing) > 0 {
+			log.Printf("Plugin %s is missing credentials: %s", name, strings.Join(missing, ", "))
+			return deny(b, c, "Dieser Befehl ist nicht verfügbar.")
+		}
+
+		return next(b, c)
+	}
+}
+
+func checkAdminOnly(next plugin.GobotHandlerFunc) plugin.GobotHandlerFunc {
+	return func(b *gotgbot.Bot, c plugin.GobotContext) error {
+		var adminOnly bool
+		switch handler := c.Handler.(type) {
+		case *plugin.CommandHandler:
+			adminOnly = handler.AdminOnly
+		case *plugin.CallbackHandler:
+			adminOnly = handler.AdminOnly
+		case *plugin.InlineHandler:
+			adminOnly = handler.AdminOnly
+		case *plugin.ReactionHandler:
+			adminOnly = handler.AdminOnly
+		}
+
+		if adminOnly && !tgUtils.IsAdmin(c.EffectiveUser) {
+			log.Print("User is not an admin.")
+			return deny(b, c, "Du bist kein Bot-Administrator.")
+		}
+
+		return next(b, c)
+	}
+}
+
+// checkCooldown prevents callback buttons from being pressed until the handler's cooldown
+// since the message was sent has passed.
+func checkCooldown(next plugin.GobotHandlerFunc) plugin.GobotHandlerFunc {
+	return func(b *gotgbot.Bot, c plugin.GobotContext) error {
+		handler, ok := c.Handler.(*plugin.CallbackHandler)
+		if !ok || handler.Cooldown <= 0 || c.CallbackQuery.Message == nil {
+			return next(b, c)
+		}
+
+		callbackTime := utils.TimestampToTime(c.CallbackQuery.Message.GetDate())
+		waitTime := handler.Cooldown - time.Since(callbackTime)
+
+		if waitTime > 0 {
+			waitTimeStr := strings.ReplaceAll(fmt.Sprintf("%.1f", waitTime.Seconds()), ".", ",")
+			_, err := c.CallbackQuery.Answer(b, &gotgbot.AnswerCallbackQueryOpts{
+				Text:      fmt.Sprintf("🕒 Bitte warte noch %s Sekunden.", waitTimeStr),
+				ShowAlert: true,
+			})
+			return err
+		}
+
+		return next(b, c)
+	}
+}
+
+// deleteButton removes the inline keyboard of callback handlers with DeleteButton set
+// while the handler is running.
+func (p *Processor) deleteButton(next plugin.GobotHandlerFunc) plugin.GobotHandlerFunc {
+	return func(b *gotgbot.Bot, c plugin.GobotContext) error {
+		handler, ok := c.Handler.(*plugin.CallbackHandler)
+		if ok && handler.DeleteButton && c.EffectiveMessage != nil {
+			p.running.Go(func() {
+				_, _, err := c.EffectiveMessage.EditReplyMarkup(b, nil)
+				if err != nil {
+					log.Err(err).
+						Int64("chat_id", c.EffectiveChat.Id).
+						Msg("Error removing inline keyboard")
+				}
+			})
+		}
+		return next(b, c)
+	}
+}
@@ -1,31 +1,33 @@
 package bot
 
 import (
-	"errors"
-	"fmt"
 	"os"
 	"regexp"
-	"strings"
+	"slices"
 	"sync"
-	"time"
 
 	"github.com/Brawl345/gobot/model"
 	"github.com/Brawl345/gobot/plugin"
-	"github.com/Brawl345/gobot/utils"
 	"github.com/Brawl345/gobot/utils/tgUtils"
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
-	"github.com/rs/xid"
 )
 
 type Processor struct {
 	allowService      model.AllowService
+	callbackService   model.CallbackService
 	chatsUsersService model.ChatsUsersService
 	managerService    model.ManagerService
 	userService       model.UserService
 	shouldPrintMsgs   bool
 	registryOnce      sync.Once
 	registry          *handlerRegistry
+	running           sync.WaitGroup
+	middlewares       []plugin.Middleware
+	customMiddlewares bool // Added with Use
+	answers           *answerStore
+	inlineCache       *inlineCache
+	messages          *messageCache
 }
 
 // handlers returns the cached handler registry, building it on first use once
@@ -37,15 +39,26 @@ func (p *Processor) handlers(b *gotgbot.Bot) *handlerRegistry {
 	return p.registry
 }
 
-func NewProcessor(allowService model.AllowService, chatsUsersService model.ChatsUsersService, managerService model.ManagerService, userService model.UserService) *Processor {
+// Wait blocks until all handlers that were started so far have returned.
+func (p *Processor) Wait() {
+	p.running.Wait()
+}
+
+func NewProcessor(allowService model.AllowService, callbackService model.CallbackService, chatsUsersService model.ChatsUsersService, managerService model.ManagerService, userService model.UserService) *Processor {
 	_, shouldPrintMsgs := os.LookupEnv("PRINT_MSGS")
-	return &Processor{
+	p := &Processor{
 		allowService:      allowService,
+		callbackService:   callbackService,
 		chatsUsersService: chatsUsersService,
 		managerService:    managerService,
 		userService:       userService,
 		shouldPrintMsgs:   shouldPrintMsgs,
+		answers:           newAnswerStore(),
+		inlineCache:       newInlineCache(),
+		messages:          newMessageCache(),
 	}
+	p.middlewares = p.builtinMiddlewares()
+	return p
 }
 
 func (p *Processor) ProcessUpdate(d *ext.Dispatcher, b *gotgbot.Bot, ctx *ext.Context) error {
@@ -75,6 +88,14 @@ func (p *Processor) ProcessUpdate(d *ext.Dispatcher, b *gotgbot.Bot, ctx *ext.Co
 		return p.onMessage(b, ctx)
 	}
 
+	if ctx.GetType() == gotgbot.UpdateTypeChannelPost || ctx.GetType() == gotgbot.UpdateTypeEditedChannelPost {
+		if ctx.EffectiveMessage.NewChatTitle != "" || ctx.EffectiveMessage.NewChatPhoto != nil {
+			return nil
+		}
+
+		return p.onMessage(b, ctx)
+	}
+
 	if ctx.GetType() == gotgbot.UpdateTypeCallbackQuery {
 		return p.onCallback(b, ctx)
 	}
@@ -83,6 +104,10 @@ func (p *Processor) ProcessUpdate(d *ext.Dispatcher, b *gotgbot.Bot, ctx *ext.Co
 		return p.onInlineQuery(b, ctx)
 	}
 
+	if ctx.GetType() == gotgbot.UpdateTypeMessageReaction {
+		return p.onReaction(b, ctx)
+	}
+
 	return nil
 }
 
@@ -90,19 +115,11 @@ func (p *Processor) onMessage(b *gotgbot.Bot, ctx *ext.Context) error {
 	msg := ctx.EffectiveMessage
 	isEdited := msg.EditDate != 0
 
-	isAllowed := p.allowService.IsUserAllowed(ctx.EffectiveUser)
-	if tgUtils.FromGroup(msg) && !isAllowed {
-		isAllowed = p.allowService.IsChatAllowed(ctx.EffectiveChat)
-	}
-
-	if !isAllowed {
-		log.Debug().Int64("chat_id", ctx.EffectiveChat.Id).Msg("User/Chat is not allowed")
-		return nil
-	}
-
 	var err error
 
-	if !isEdited {
+	// Only track users of allowed chats, the handlers themselves are guarded by the middlewares.
+	// Channel posts have no user.
+	if !isEdited && ctx.EffectiveUser != nil && !tgUtils.FromChannel(msg) && p.isAllowed(ctx) {
 		if tgUtils.IsPrivate(msg) {
 			err = p.userService.Create(ctx.EffectiveUser)
 		} else {
@@ -113,50 +130,72 @@ func (p *Processor) onMessage(b *gotgbot.Bot, ctx *ext.Context) error {
 		}
 	}
 
+	if p.isAllowed(ctx) {
+		p.messages.set(msg)
+	}
+
+	if isEdited && !p.answers.knows(msg) {
+		return nil
+	}
+
 	text := msg.GetText()
 	registry := p.handlers(b)
+	matched := make(map[plugin.Handler]bool)
 
 	for _, e := range registry.regexpCommands {
-		if !commandApplies(e.handler, msg, isEdited) {
+		if !commandApplies(b, e.handler, msg, isEdited) {
 			continue
 		}
 		matches := e.regexp.FindStringSubmatch(text)
 		if len(matches) == 0 {
 			continue
 		}
-		p.dispatchCommand(b, ctx, e.plugin, e.handler, matches, namedMatchesOf(e.regexp, matches))
+		matched[e.handler] = true
+		p.dispatch(b, ctx, e.plugin, e.handler, matches, namedMatchesOf(e.regexp, matches))
 	}
 
 	for _, e := range registry.mediaCommands {
-		if !commandApplies(e.handler, msg, isEdited) {
+		if !commandApplies(b, e.handler, msg, isEdited) {
 			continue
 		}
 		if !mediaMatches(e.trigger, msg) {
 			continue
 		}
-		p.dispatchCommand(b, ctx, e.plugin, e.handler, nil, map[string]string{})
+		matched[e.handler] = true
+		p.dispatch(b, ctx, e.plugin, e.handler, nil, map[string]string{})
 	}
 
 	for _, e := range registry.entityCommands {
-		if !commandApplies(e.handler, msg, isEdited) {
+		if !commandApplies(b, e.handler, msg, isEdited) {
 			continue
 		}
 		if !entityMatches(e.entity, msg) {
 			continue
 		}
-		p.dispatchCommand(b, ctx, e.plugin, e.handler, nil, map[string]string{})
+		matched[e.handler] = true
+		p.dispatch(b, ctx, e.plugin, e.handler, nil, map[string]string{})
+	}
+
+	if isEdited {
+		p.removeStaleAnswers(b, msg, matched)
 	}
 
 	return nil
 }
 
-func commandApplies(handler *plugin.CommandHandler, msg *gotgbot.Message, isEdited bool) bool {
+func commandApplies(b *gotgbot.Bot, handler *plugin.CommandHandler, msg *gotgbot.Message, isEdited bool) bool {
 	if isEdited && !handler.HandleEdits {
 		return false
 	}
+	if tgUtils.FromChannel(msg) && !handler.HandleChannelPosts {
+		return false
+	}
 	if !tgUtils.FromGroup(msg) && handler.GroupOnly {
 		return false
 	}
+	if handler.RepliesToBot && (msg.ReplyToMessage == nil || msg.ReplyToMessage.From == nil || msg.ReplyToMessage.From.Id != b.Id) {
+		return false
+	}
 	return true
 }
 
@@ -209,251 +248,109 @@ func namedMatchesOf(command *regexp.Regexp, matches []string) map[string]string
 	return namedMatches
 }
 
-func (p *Processor) dispatchCommand(b *gotgbot.Bot, ctx *ext.Context, plg plugin.Plugin, handler *plugin.CommandHandler, matches []string, namedMatches map[string]string) {
-	log.Printf("Matched plugin '%s': %s (%T)", plg.Name(), handler.Trigger, handler.Trigger)
-
-	if !p.managerService.IsPluginEnabled(plg.Name()) {
-		log.Printf("Plugin %s is disabled globally", plg.Name())
-		return
-	}
-
-	if tgUtils.FromGroup(ctx.EffectiveMessage) && p.managerService.IsPluginDisabledForChat(ctx.EffectiveChat, plg.Name()) {
-		log.Printf("Plugin %s is disabled for this chat", plg.Name())
-		return
-	}
-
-	if handler.AdminOnly && !tgUtils.IsAdmin(ctx.EffectiveUser) {
-		log.Print("User is not an admin.")
-		return
-	}
-
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				guid := xid.New().String()
-				log.Err(errors.New("panic")).
-					Str("guid", guid).
-					Interface("ctx", ctx).
-					Str("component", plg.Name()).
-					Msgf("%s", r)
-				_, _ = ctx.EffectiveMessage.Reply(b, fmt.Sprintf("❌ Es ist ein Fehler aufgetreten.%s", utils.EmbedGUID(guid)), utils.DefaultSendOptions())
-			}
-		}()
-		err := handler.Run(b, plugin.GobotContext{
-			Context:      ctx,
-			Matches:      matches,
-			NamedMatches: namedMatches,
-		})
-		if err != nil {
-			guid := xid.New().String()
-			log.Err(err).
-				Str("guid", guid).
-				Interface("ctx", ctx).
-				Str("component", plg.Name()).
-				Send()
-			_, _ = ctx.EffectiveMessage.Reply(b, fmt.Sprintf("❌ Es ist ein Fehler aufgetreten.%s", utils.EmbedGUID(guid)), utils.DefaultSendOptions())
-		}
-	}()
-}
-
 func (p *Processor) onCallback(b *gotgbot.Bot, ctx *ext.Context) error {
 	callback := ctx.CallbackQuery
-	msg := callback.Message
 
 	if callback.Data == "" {
 		_, err := callback.Answer(b, nil)
 		return err
 	}
 
-	isAllowed := p.allowService.IsUserAllowed(&ctx.CallbackQuery.From)
-	if msg != nil && tgUtils.FromGroup(msg) && !isAllowed {
-		isAllowed = p.allowService.IsChatAllowed(ctx.EffectiveChat)
+	if matches := regexPagination.FindStringSubmatch(callback.Data); matches != nil {
+		return p.onPage(b, ctx, matches)
 	}
 
-	if !isAllowed {
-		_, err := callback.Answer(b, &gotgbot.AnswerCallbackQueryOpts{
-			Text:      "Du darfst diesen Bot nicht nutzen.",
-			ShowAlert: true,
-		})
-		return err
+	for _, e := range p.handlers(b).callbacks {
+		matches := e.handler.Trigger.FindStringSubmatch(callback.Data)
+		if len(matches) == 0 {
+			continue
+		}
+		p.dispatch(b, ctx, e.plugin, e.handler, matches, namedMatchesOf(e.handler.Trigger, matches))
 	}
 
-	for _, e := range p.handlers(b).callbacks {
-		plg := e.plugin
-		handler := e.handler
-		command := handler.Trigger
-
-		matches := command.FindStringSubmatch(callback.Data)
-		if len(matches) > 0 {
-			log.Printf("Matched plugin %s: %s", plg.Name(), handler.Trigger)
-
-			if !p.managerService.IsPluginEnabled(plg.Name()) {
-				log.Printf("Plugin %s is disabled globally", plg.Name())
-				_, err := ctx.CallbackQuery.Answer(b, &gotgbot.AnswerCallbackQueryOpts{
-					Text:      "Dieser Befehl ist nicht verfügbar.",
-					ShowAlert: true,
-				})
-				return err
-			}
+	return nil
+}
 
-			if msg != nil && tgUtils.FromGroup(msg) && p.managerService.IsPluginDisabledForChat(ctx.EffectiveChat, plg.Name()) {
-				log.Printf("Plugin %s is disabled for this chat", plg.Name())
-				_, err := ctx.CallbackQuery.Answer(b, &gotgbot.AnswerCallbackQueryOpts{
-					Text:      "Dieser Befehl ist nicht verfügbar.",
-					ShowAlert: true,
-				})
-				return err
-			}
+func (p *Processor) onInlineQuery(b *gotgbot.Bot, ctx *ext.Context) error {
+	inlineQuery := ctx.InlineQuery
 
-			if handler.AdminOnly && !tgUtils.IsAdmin(ctx.EffectiveUser) {
-				log.Print("User is not an admin.")
-				_, err := ctx.CallbackQuery.Answer(b, &gotgbot.AnswerCallbackQueryOpts{
-					Text:      "Du bist kein Bot-Administrator.",
-					ShowAlert: true,
-				})
-				return err
-			}
+	if inlineQuery.Query == "" {
+		return p.answerInlineHelp(b, ctx)
+	}
 
-			if handler.Cooldown > 0 && msg != nil {
-				callbackTime := utils.TimestampToTime(ctx.CallbackQuery.Message.GetDate())
-				currentTime := time.Now()
-				waitTime := handler.Cooldown - currentTime.Sub(callbackTime)
-
-				if waitTime > 0 {
-					waitTimeStr := fmt.Sprintf("%.1f", waitTime.Seconds())
-					waittimeStr := strings.ReplaceAll(waitTimeStr, ".", ",")
-					_, err := ctx.CallbackQuery.Answer(b, &gotgbot.AnswerCallbackQueryOpts{
-						Text:      fmt.Sprintf("🕒 Bitte warte noch %s Sekunden.", waittimeStr),
-						ShowAlert: true,
-					})
-					return err
-				}
-			}
+	type match struct {
+		entry   inlineEntry
+		matches []string
+	}
 
-			if handler.DeleteButton && ctx.EffectiveMessage != nil {
-				go func() {
-					_, _, err := ctx.EffectiveMessage.EditReplyMarkup(b, nil)
-					if err != nil {
-						log.Err(err).
-							Int64("chat_id", ctx.EffectiveChat.Id).
-							Msg("Error removing inline keyboard")
-					}
-				}()
-			}
+	var matched []match
+	for _, e := range p.handlers(b).inlines {
+		matches := e.handler.Trigger.FindStringSubmatch(inlineQuery.Query)
+		if len(matches) == 0 {
+			continue
+		}
+		matched = append(matched, match{e, matches})
+	}
 
-			namedMatches := namedMatchesOf(command, matches)
+	if len(matched) == 0 {
+		return nil
+	}
 
-			var chatId int64
-			if ctx.EffectiveChat != nil {
-				chatId = ctx.EffectiveChat.Id
+	// All handlers contribute to one answer, cached results are used without running the handler again
+	collector := newInlineCollector(b, inlineQuery, p.inlineCache, len(matched))
+	for _, m := range matched {
+		if p.canUseInlineCache(ctx, m.entry) {
+			if answer, ok := p.inlineCache.get(m.entry.handler, inlineQuery); ok {
+				collector.done(&answer)
+				continue
 			}
-
-			go func() {
-				defer func() {
-					if r := recover(); r != nil {
-						log.Err(errors.New("panic")).
-							Int64("chat_id", chatId).
-							Str("callback_data", callback.Data).
-							Str("component", plg.Name()).
-							Msgf("%s", r)
-					}
-				}()
-				err := handler.Run(b, plugin.GobotContext{
-					Context:      ctx,
-					Matches:      matches,
-					NamedMatches: namedMatches,
-				})
-				if err != nil {
-					log.Err(err).
-						Int64("chat_id", chatId).
-						Str("callback_data", callback.Data).
-						Str("component", plg.Name()).
-						Send()
-				}
-			}()
-
 		}
+		sessionBot, done := collector.session(m.entry.handler)
+		p.dispatchThen(sessionBot, ctx, m.entry.plugin, m.entry.handler, m.matches, namedMatchesOf(m.entry.handler.Trigger, m.matches), done)
 	}
 
 	return nil
 }
 
-func (p *Processor) onInlineQuery(b *gotgbot.Bot, ctx *ext.Context) error {
-	inlineQuery := ctx.InlineQuery
+func (p *Processor) onReaction(b *gotgbot.Bot, ctx *ext.Context) error {
+	reaction := ctx.MessageReaction
 
-	if inlineQuery.Query == "" {
-		_, err := ctx.InlineQuery.Answer(b,
-			nil,
-			&gotgbot.AnswerInlineQueryOpts{
-				CacheTime:  utils.Ptr(utils.InlineQueryFailureCacheTime),
-				IsPersonal: true,
-			})
-		return err
+	// Anonymous admins react as the chat, they can't be checked against the allowlist
+	if reaction.User == nil {
+		return nil
 	}
 
-	for _, e := range p.handlers(b).inlines {
-		plg := e.plugin
-		handler := e.handler
-		command := handler.Trigger
-
-		matches := command.FindStringSubmatch(inlineQuery.Query)
-		if len(matches) > 0 {
-			log.Printf("Matched plugin %s: %s", plg.Name(), handler.Trigger)
-			if !p.managerService.IsPluginEnabled(plg.Name()) {
-				log.Printf("Plugin %s is disabled globally", plg.Name())
-				_, err := ctx.InlineQuery.Answer(b, nil, &gotgbot.AnswerInlineQueryOpts{
-					CacheTime:  utils.Ptr(utils.InlineQueryFailureCacheTime),
-					IsPersonal: true,
-				})
-				return err
-			}
+	// Only newly added emoji count, not the ones that were already there or got removed
+	var added []string
+	for _, r := range reaction.NewReaction {
+		emoji, ok := r.(gotgbot.ReactionTypeEmoji)
+		if !ok {
+			continue
+		}
+		if !slices.ContainsFunc(reaction.OldReaction, func(old gotgbot.ReactionType) bool {
+			oldEmoji, ok := old.(gotgbot.ReactionTypeEmoji)
+			return ok && oldEmoji.Emoji == emoji.Emoji
+		}) {
+			added = append(added, emoji.Emoji)
+		}
+	}
+	if len(added) == 0 {
+		return nil
+	}
 
-			if handler.AdminOnly && !tgUtils.IsAdmin(ctx.EffectiveUser) {
-				log.Print("User is not an admin.")
-				_, err := ctx.InlineQuery.Answer(b, nil, &gotgbot.AnswerInlineQueryOpts{
-					CacheTime:  utils.Ptr(utils.InlineQueryFailureCacheTime),
-					IsPersonal: true,
-				})
-				return err
-			}
+	reactionCtx := *ctx
+	reactionCtx.EffectiveMessage = p.messages.get(reaction.Chat.Id, reaction.MessageId)
+	isGroup := fromGroup(&reactionCtx)
 
-			if !handler.CanBeUsedByEveryone {
-				isAllowed := p.allowService.IsUserAllowed(ctx.EffectiveUser)
-				if !isAllowed {
-					_, err := ctx.InlineQuery.Answer(b, nil, &gotgbot.AnswerInlineQueryOpts{
-						CacheTime:  utils.Ptr(utils.InlineQueryFailureCacheTime),
-						IsPersonal: true,
-					})
-					return err
-				}
+	for _, e := range p.handlers(b).reactions {
+		if e.handler.GroupOnly && !isGroup {
+			continue
+		}
+		for _, emoji := range added {
+			if len(e.handler.Emoji) > 0 && !slices.Contains(e.handler.Emoji, emoji) {
+				continue
 			}
-
-			namedMatches := namedMatchesOf(command, matches)
-
-			go func() {
-				defer func() {
-					if r := recover(); r != nil {
-						log.Err(errors.New("panic")).
-							Int64("user_id", ctx.EffectiveUser.Id).
-							Str("query", ctx.InlineQuery.Query).
-							Str("component", plg.Name()).
-							Msgf("%s", r)
-					}
-				}()
-				err := handler.Run(b, plugin.GobotContext{
-					Context:      ctx,
-					Matches:      matches,
-					NamedMatches: namedMatches,
-				})
-				if err != nil {
-					log.Err(err).
-						Int64("user_id", ctx.EffectiveUser.Id).
-						Str("query", ctx.InlineQuery.Query).
-						Str("component", plg.Name()).
-						Send()
-				}
-			}()
-
+			p.dispatch(b, &reactionCtx, e.plugin, e.handler, []string{emoji}, map[string]string{})
 		}
 	}
 
@@ -7,11 +7,13 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/Brawl345/gobot/model"
 	"github.com/Brawl345/gobot/plugin"
+	"github.com/Brawl345/gobot/utils"
 	"github.com/Brawl345/gobot/utils/tgUtils"
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
@@ -41,6 +43,27 @@ func (f *fakeAllowService) DenyUser(*gotgbot.User) error          { return nil }
 func (f *fakeAllowService) IsChatAllowed(*gotgbot.Chat) bool      { return f.chatAllowed }
 func (f *fakeAllowService) IsUserAllowed(user *gotgbot.User) bool { return f.userAllowed }
 
+type fakeCallbackService struct {
+	payloads map[string]any
+}
+
+func (f *fakeCallbackService) Create(any, *gotgbot.User, time.Duration) (string, error) {
+	return "", nil
+}
+func (f *fakeCallbackService) Get(token string, _ *gotgbot.User, v any) error {
+	payload, ok := f.payloads[token]
+	if !ok {
+		return model.ErrNotFound
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+func (f *fakeCallbackService) Delete(string) error { return nil }
+func (f *fakeCallbackService) Cleanup() error      { return nil }
+
 type fakeChatsUsersService struct {
 	created     []int64
 	batches     [][]gotgbot.User
@@ -85,9 +108,11 @@ func (f *fakeUserService) Deny(*gotgbot.User) error               { return nil }
 func (f *fakeUserService) GetAllAllowed() ([]int64, error)        { return nil, nil }
 
 type fakeManagerService struct {
-	plugins          []plugin.Plugin
-	disabledGlobally map[string]bool
-	disabledForChat  map[string]bool
+	plugins            []plugin.Plugin
+	disabledGlobally   map[string]bool
+	disabledForChat    map[string]bool
+	disabledForTopic   map[string]bool
+	missingCredentials map[string][]string
 }
 
 func (f *fakeManagerService) Plugins() []plugin.Plugin                        { return f.plugins }
@@ -101,6 +126,14 @@ func (f *fakeManagerService) IsPluginEnabled(name string) bool { return !f.disab
 func (f *fakeManagerService) IsPluginDisabledForChat(_ *gotgbot.Chat, name string) bool {
 	return f.disabledForChat[name]
 }
+func (f *fakeManagerService) EnablePluginForTopic(*gotgbot.Chat, int64, string) error  { return nil }
+func (f *fakeManagerService) DisablePluginForTopic(*gotgbot.Chat, int64, string) error { return nil }
+func (f *fakeManagerService) IsPluginDisabledForTopic(_ *gotgbot.Chat, _ int64, name string) bool {
+	return f.disabledForTopic[name]
+}
+func (f *fakeManagerService) MissingCredentials(plg plugin.Plugin) ([]string, []string) {
+	return f.missingCredentials[plg.Name()], nil
+}
 
 type fakePlugin struct {
 	name     string
@@ -146,6 +179,7 @@ type testEnv struct {
 	bot        *gotgbot.Bot
 	client     *fakeBotClient
 	allow      *fakeAllowService
+	callbacks  *fakeCallbackService
 	manager    *fakeManagerService
 	users      *fakeUserService
 	chatsUsers *fakeChatsUsersService
@@ -154,10 +188,13 @@ type testEnv struct {
 func newTestEnv(plugins ...plugin.Plugin) *testEnv {
 	allow := &fakeAllowService{userAllowed: true, chatAllowed: true}
 	manager := &fakeManagerService{
-		plugins:          plugins,
-		disabledGlobally: map[string]bool{},
-		disabledForChat:  map[string]bool{},
+		plugins:            plugins,
+		disabledGlobally:   map[string]bool{},
+		disabledForChat:    map[string]bool{},
+		disabledForTopic:   map[string]bool{},
+		missingCredentials: map[string][]string{},
 	}
+	callbacks := &fakeCallbackService{payloads: map[string]any{}}
 	users := &fakeUserService{}
 	chatsUsers := &fakeChatsUsersService{}
 	client := newFakeBotClient()
@@ -167,10 +204,11 @@ func newTestEnv(plugins ...plugin.Plugin) *testEnv {
 		BotClient: client,
 	}
 	return &testEnv{
-		processor:  NewProcessor(allow, chatsUsers, manager, users),
+		processor:  NewProcessor(allow, callbacks, chatsUsers, manager, users),
 		bot:        bot,
 		client:     client,
 		allow:      allow,
+		callbacks:  callbacks,
 		manager:    manager,
 		users:      users,
 		chatsUsers: chatsUsers,
@@ -183,6 +221,7 @@ func (e *testEnv) process(t *testing.T, update *gotgbot.Update) {
 	if err := e.processor.ProcessUpdate(nil, e.bot, ctx); err != nil {
 		t.Fatalf("ProcessUpdate returned error: %v", err)
 	}
+	e.processor.Wait()
 }
 
 func commandHandler(trigger any, dispatched chan dispatchRecord) *plugin.CommandHandler {
@@ -396,6 +435,21 @@ func TestEditedMessage(t *testing.T) {
 	}
 }
 
+func TestEditOfUnknownMessageIsIgnored(t *testing.T) {
+	dispatched := make(chan dispatchRecord, 8)
+	handler := commandHandler(regexp.MustCompile(`^/edit$`), dispatched)
+	handler.HandleEdits = true
+	env := newTestEnv(&fakePlugin{name: "edits", handlers: []plugin.Handler{handler}})
+
+	// Sent before the bot was restarted, so its answer is unknown
+	msg := textMessage(privateChat(), "/edit")
+	msg.Date = time.Now().Add(-time.Hour).Unix()
+	msg.EditDate = time.Now().Unix()
+	env.process(t, &gotgbot.Update{UpdateId: 1, EditedMessage: msg})
+
+	expectNoDispatch(t, dispatched)
+}
+
 func TestGroupOnly(t *testing.T) {
 	dispatched := make(chan dispatchRecord, 8)
 	handler := commandHandler(regexp.MustCompile(`^/group$`), dispatched)
@@ -409,6 +463,27 @@ func TestGroupOnly(t *testing.T) {
 	expectDispatch(t, dispatched)
 }
 
+func TestRepliesToBot(t *testing.T) {
+	dispatched := make(chan dispatchRecord, 8)
+	handler := commandHandler(regexp.MustCompile(`^weiter$`), dispatched)
+	handler.RepliesToBot = true
+	env := newTestEnv(&fakePlugin{name: "reply", handlers: []plugin.Handler{handler}})
+
+	env.process(t, messageUpdate(textMessage(groupChat(), "weiter")))
+	expectNoDispatch(t, dispatched)
+
+	toUser := textMessage(groupChat(), "weiter")
+	toUser.ReplyToMessage = textMessage(groupChat(), "Hallo")
+	env.process(t, messageUpdate(toUser))
+	expectNoDispatch(t, dispatched)
+
+	toBot := textMessage(groupChat(), "weiter")
+	toBot.ReplyToMessage = textMessage(groupChat(), "Hallo")
+	toBot.ReplyToMessage.From = &env.bot.User
+	env.process(t, messageUpdate(toBot))
+	expectDispatch(t, dispatched)
+}
+
 func TestPluginDisabledGlobally(t *testing.T) {
 	dispatched := make(chan dispatchRecord, 8)
 	env := newTestEnv(&fakePlugin{
@@ -456,6 +531,22 @@ func TestPluginDisableToggleBetweenUpdates(t *testing.T) {
 	expectDispatch(t, dispatched)
 }
 
+func TestPluginMissingCredentials(t *testing.T) {
+	dispatched := make(chan dispatchRecord, 8)
+	env := newTestEnv(&fakePlugin{
+		name:     "search",
+		handlers: []plugin.Handler{commandHandler(regexp.MustCompile(`^/search$`), dispatched)},
+	})
+	env.manager.missingCredentials["search"] = []string{"search_api_key"}
+
+	env.process(t, messageUpdate(textMessage(privateChat(), "/search")))
+	expectNoDispatch(t, dispatched)
+
+	delete(env.manager.missingCredentials, "search")
+	env.process(t, messageUpdate(textMessage(privateChat(), "/search")))
+	expectDispatch(t, dispatched)
+}
+
 func TestAdminOnlyCommand(t *testing.T) {
 	dispatched := make(chan dispatchRecord, 8)
 	handler := commandHandler(regexp.MustCompile(`^/admin$`), dispatched)
@@ -615,6 +706,54 @@ func TestHandlerPanicIsRecovered(t *testing.T) {
 	expectRequest(t, env.client, "sendMessage")
 }
 
+func echoHandler() *plugin.CommandHandler {
+	return &plugin.CommandHandler{
+		Trigger: regexp.MustCompile(`^/echo (.+)$`),
+		HandlerFunc: func(b *gotgbot.Bot, c plugin.GobotContext) error {
+			_, err := c.EffectiveMessage.Reply(b, c.Matches[1], nil)
+			return err
+		},
+		HandleEdits: true,
+	}
+}
+
+func editedMessageUpdate(msg *gotgbot.Message) *gotgbot.Update {
+	msg.EditDate = time.Now().Unix()
+	return &gotgbot.Update{UpdateId: 4, EditedMessage: msg}
+}
+
+func TestEditReplacesAnswer(t *testing.T) {
+	env := newTestEnv(&fakePlugin{name: "echo", handlers: []plugin.Handler{echoHandler()}})
+
+	env.process(t, messageUpdate(textMessage(privateChat(), "/echo hello")))
+	expectRequest(t, env.client, "sendMessage")
+
+	env.process(t, editedMessageUpdate(textMessage(privateChat(), "/echo world")))
+	r := expectRequest(t, env.client, "editMessageText")
+	if r.params["message_id"] != int64(1) {
+		t.Errorf("expected previous answer to be edited, got message_id %v", r.params["message_id"])
+	}
+	if r.params["text"] != "world" {
+		t.Errorf("expected new text, got %v", r.params["text"])
+	}
+}
+
+func TestEditWithoutMatchDeletesAnswer(t *testing.T) {
+	env := newTestEnv(&fakePlugin{name: "echo", handlers: []plugin.Handler{echoHandler()}})
+
+	env.process(t, messageUpdate(textMessage(privateChat(), "/echo hello")))
+	expectRequest(t, env.client, "sendMessage")
+
+	env.process(t, editedMessageUpdate(textMessage(privateChat(), "nevermind")))
+	r := expectRequest(t, env.client, "deleteMessage")
+	if r.params["message_id"] != int64(1) {
+		t.Errorf("expected previous answer to be deleted, got message_id %v", r.params["message_id"])
+	}
+
+	env.process(t, editedMessageUpdate(textMessage(privateChat(), "/echo again")))
+	expectRequest(t, env.client, "sendMessage")
+}
+
 func TestUserJoined(t *testing.T) {
 	env := newTestEnv()
 
@@ -690,6 +829,40 @@ func TestCallbackDispatch(t *testing.T) {
 	}
 }
 
+func TestCallbackAllMatchesAreDispatched(t *testing.T) {
+	first := make(chan dispatchRecord, 8)
+	second := make(chan dispatchRecord, 8)
+	env := newTestEnv(
+		&fakePlugin{name: "first", handlers: []plugin.Handler{callbackHandler(regexp.MustCompile(`^btn`), first)}},
+		&fakePlugin{name: "second", handlers: []plugin.Handler{callbackHandler(regexp.MustCompile(`^btn:save$`), second)}},
+	)
+
+	env.process(t, callbackUpdate("btn:save", privateChat(), time.Now().Unix()))
+
+	expectDispatch(t, first)
+	expectDispatch(t, second)
+}
+
+func TestPageCallbacksWorkWithoutPlugins(t *testing.T) {
+	env := newTestEnv()
+	env.callbacks.payloads["abc"] = []string{"Seite 1", "Seite 2"}
+
+	env.process(t, callbackUpdate("pg:abc:1", groupChat(), time.Now().Unix()))
+
+	r := expectRequest(t, env.client, "editMessageText")
+	if r.params["text"] != "Seite 2" {
+		t.Errorf("expected the second page, got %v", r.params["text"])
+	}
+	expectRequest(t, env.client, "answerCallbackQuery")
+
+	env.process(t, callbackUpdate("pg:expired:0", groupChat(), time.Now().Unix()))
+
+	r = expectRequest(t, env.client, "answerCallbackQuery")
+	if r.params["show_alert"] != true {
+		t.Errorf("expired pages must be reported, got %v", r.params)
+	}
+}
+
 func messagelessCallbackUpdate(data string) *gotgbot.Update {
 	return &gotgbot.Update{
 		UpdateId: 2,
@@ -887,3 +1060,412 @@ func TestInlineQueryRestrictedToAllowedUsers(t *testing.T) {
 	env.process(t, inlineQueryUpdate("find"))
 	expectDispatch(t, dispatched)
 }
+
+type middlewarePlugin struct {
+	fakePlugin
+	middlewares []plugin.Middleware
+}
+
+func (p *middlewarePlugin) Middlewares() []plugin.Middleware { return p.middlewares }
+
+func recordingMiddleware(name string, calls *[]string) plugin.Middleware {
+	return func(next plugin.GobotHandlerFunc) plugin.GobotHandlerFunc {
+		return func(b *gotgbot.Bot, c plugin.GobotContext) error {
+			*calls = append(*calls, name+":before")
+			err := next(b, c)
+			*calls = append(*calls, name+":after")
+			return err
+		}
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var calls []string
+	handler := &plugin.CommandHandler{
+		Trigger: regexp.MustCompile(`^/mw$`),
+		HandlerFunc: func(*gotgbot.Bot, plugin.GobotContext) error {
+			calls = append(calls, "handler")
+			return nil
+		},
+	}
+	env := newTestEnv(&middlewarePlugin{
+		fakePlugin:  fakePlugin{name: "mw", handlers: []plugin.Handler{handler}},
+		middlewares: []plugin.Middleware{recordingMiddleware("plugin", &calls)},
+	})
+	env.processor.Use(recordingMiddleware("global", &calls))
+
+	env.process(t, messageUpdate(textMessage(privateChat(), "/mw")))
+
+	want := []string{"global:before", "plugin:before", "handler", "plugin:after", "global:after"}
+	if fmt.Sprint(calls) != fmt.Sprint(want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestMiddlewareCanStopHandler(t *testing.T) {
+	dispatched := make(chan dispatchRecord, 8)
+	env := newTestEnv(&middlewarePlugin{
+		fakePlugin: fakePlugin{name: "mw", handlers: []plugin.Handler{commandHandler(regexp.MustCompile(`^/mw$`), dispatched)}},
+		middlewares: []plugin.Middleware{func(plugin.GobotHandlerFunc) plugin.GobotHandlerFunc {
+			return func(*gotgbot.Bot, plugin.GobotContext) error { return nil }
+		}},
+	})
+
+	env.process(t, messageUpdate(textMessage(privateChat(), "/mw")))
+
+	expectNoDispatch(t, dispatched)
+}
+
+func TestGlobalMiddlewareSkippedForDisabledPlugin(t *testing.T) {
+	var calls []string
+	dispatched := make(chan dispatchRecord, 8)
+	env := newTestEnv(&fakePlugin{
+		name:     "start",
+		handlers: []plugin.Handler{commandHandler(regexp.MustCompile(`^/start$`), dispatched)},
+	})
+	env.processor.Use(recordingMiddleware("global", &calls))
+	env.manager.disabledGlobally["start"] = true
+
+	env.process(t, messageUpdate(textMessage(privateChat(), "/start")))
+
+	expectNoDispatch(t, dispatched)
+	if len(calls) != 0 {
+		t.Errorf("custom middlewares must not run for disabled plugins, got %v", calls)
+	}
+}
+
+func articleHandler(trigger string, id string, calls *atomic.Int32) *plugin.InlineHandler {
+	return &plugin.InlineHandler{
+		Trigger:             regexp.MustCompile(trigger),
+		CanBeUsedByEveryone: true,
+		HandlerFunc: func(b *gotgbot.Bot, c plugin.GobotContext) error {
+			calls.Add(1)
+			_, err := c.InlineQuery.Answer(b, []gotgbot.InlineQueryResult{
+				gotgbot.InlineQueryResultArticle{
+					Id:                  id,
+					Title:               id,
+					InputMessageContent: gotgbot.InputTextMessageContent{MessageText: id},
+				},
+			}, &gotgbot.AnswerInlineQueryOpts{CacheTime: utils.Ptr(int64(60))})
+			return err
+		},
+	}
+}
+
+func TestInlineResultsAreMerged(t *testing.T) {
+	var calls atomic.Int32
+	env := newTestEnv(
+		&fakePlugin{name: "first", handlers: []plugin.Handler{articleHandler(`^find`, "1", &calls)}},
+		&fakePlugin{name: "second", handlers: []plugin.Handler{articleHandler(`cats$`, "1", &calls)}},
+	)
+
+	env.process(t, inlineQueryUpdate("find cats"))
+
+	r := expectRequest(t, env.client, "answerInlineQuery")
+	results, _ := r.params["results"].([]json.RawMessage)
+	if len(results) != 2 {
+		t.Fatalf("expected 2 merged results, got %d", len(results))
+	}
+	if string(results[0]) == string(results[1]) {
+		t.Error("expected merged results to have unique IDs")
+	}
+	if cacheTime, _ := r.params["cache_time"].(int64); cacheTime != 60 {
+		t.Errorf("expected cache time 60, got %v", r.params["cache_time"])
+	}
+}
+
+func TestInlineResultsAreCached(t *testing.T) {
+	var calls atomic.Int32
+	env := newTestEnv(&fakePlugin{name: "search", handlers: []plugin.Handler{articleHandler(`^find$`, "1", &calls)}})
+
+	env.process(t, inlineQueryUpdate("find"))
+	expectRequest(t, env.client, "answerInlineQuery")
+	env.process(t, inlineQueryUpdate("find"))
+	expectRequest(t, env.client, "answerInlineQuery")
+
+	if calls.Load() != 1 {
+		t.Errorf("expected handler to run once, ran %d times", calls.Load())
+	}
+}
+
+func TestInlinePagesAreCachedSeparately(t *testing.T) {
+	var calls atomic.Int32
+	handler := &plugin.InlineHandler{
+		Trigger:             regexp.MustCompile(`^find$`),
+		CanBeUsedByEveryone: true,
+		HandlerFunc: func(b *gotgbot.Bot, c plugin.GobotContext) error {
+			calls.Add(1)
+			page := c.InlineQuery.Offset + "x"
+			_, err := c.InlineQuery.Answer(b, []gotgbot.InlineQueryResult{
+				gotgbot.InlineQueryResultArticle{
+					Id:                  page,
+					Title:               page,
+					InputMessageContent: gotgbot.InputTextMessageContent{MessageText: page},
+				},
+			}, &gotgbot.AnswerInlineQueryOpts{CacheTime: utils.Ptr(int64(60)), NextOffset: page})
+			return err
+		},
+	}
+	env := newTestEnv(&fakePlugin{name: "search", handlers: []plugin.Handler{handler}})
+
+	env.process(t, inlineQueryUpdate("find"))
+	r := expectRequest(t, env.client, "answerInlineQuery")
+	if r.params["next_offset"] != "x" {
+		t.Errorf("expected next_offset x, got %v", r.params["next_offset"])
+	}
+
+	update := inlineQueryUpdate("find")
+	update.InlineQuery.Offset = "x"
+	env.process(t, update)
+	r = expectRequest(t, env.client, "answerInlineQuery")
+	if r.params["next_offset"] != "xx" {
+		t.Errorf("expected next_offset xx, got %v", r.params["next_offset"])
+	}
+
+	if calls.Load() != 2 {
+		t.Errorf("expected handler to run for every page, ran %d times", calls.Load())
+	}
+}
+
+func TestInlineZeroCacheTimeDoesNotDisableMergedCaching(t *testing.T) {
+	var calls atomic.Int32
+	uncached := &plugin.InlineHandler{
+		Trigger:             regexp.MustCompile(`^find`),
+		CanBeUsedByEveryone: true,
+		HandlerFunc: func(b *gotgbot.Bot, c plugin.GobotContext) error {
+			_, err := c.InlineQuery.Answer(b, []gotgbot.InlineQueryResult{
+				gotgbot.InlineQueryResultArticle{
+					Id:                  "live",
+					Title:               "live",
+					InputMessageContent: gotgbot.InputTextMessageContent{MessageText: "live"},
+				},
+			}, &gotgbot.AnswerInlineQueryOpts{CacheTime: utils.Ptr(int64(0))})
+			return err
+		},
+	}
+	env := newTestEnv(
+		&fakePlugin{name: "first", handlers: []plugin.Handler{articleHandler(`^find`, "1", &calls)}},
+		&fakePlugin{name: "second", handlers: []plugin.Handler{uncached}},
+	)
+
+	env.process(t, inlineQueryUpdate("find cats"))
+
+	r := expectRequest(t, env.client, "answerInlineQuery")
+	if cacheTime, _ := r.params["cache_time"].(int64); cacheTime != 60 {
+		t.Errorf("expected cache time 60, got %v", r.params["cache_time"])
+	}
+	if _, ok := r.params["next_offset"]; ok {
+		t.Error("merged results must not be paginated")
+	}
+}
+
+func TestInlineCacheSkippedForPluginMiddlewares(t *testing.T) {
+	var calls atomic.Int32
+	var denied atomic.Bool
+	env := newTestEnv(&middlewarePlugin{
+		fakePlugin: fakePlugin{name: "search", handlers: []plugin.Handler{articleHandler(`^find$`, "1", &calls)}},
+		middlewares: []plugin.Middleware{func(next plugin.GobotHandlerFunc) plugin.GobotHandlerFunc {
+			return func(b *gotgbot.Bot, c plugin.GobotContext) error {
+				if denied.Load() {
+					_, err := c.InlineQuery.Answer(b, nil, nil)
+					return err
+				}
+				return next(b, c)
+			}
+		}},
+	})
+
+	env.process(t, inlineQueryUpdate("find"))
+	expectRequest(t, env.client, "answerInlineQuery")
+
+	denied.Store(true)
+	env.process(t, inlineQueryUpdate("find"))
+	r := expectRequest(t, env.client, "answerInlineQuery")
+	if results, _ := r.params["results"].([]json.RawMessage); len(results) != 0 {
+		t.Errorf("denied user got %d cached results", len(results))
+	}
+}
+
+type helpPlugin struct {
+	fakePlugin
+}
+
+func (p *helpPlugin) Help() plugin.Help {
+	return plugin.Help{Description: "Rechnet", InlineTriggers: []string{"calc 1+1"}}
+}
+
+func TestInlineQueryEmptyListsTriggers(t *testing.T) {
+	var calls atomic.Int32
+	env := newTestEnv(&helpPlugin{fakePlugin{name: "calc", handlers: []plugin.Handler{articleHandler(`^calc`, "1", &calls)}}})
+
+	env.process(t, inlineQueryUpdate(""))
+
+	r := expectRequest(t, env.client, "answerInlineQuery")
+	results, _ := r.params["results"].([]gotgbot.InlineQueryResult)
+	if len(results) != 1 {
+		t.Fatalf("expected 1 help result, got %d", len(results))
+	}
+	if article, _ := results[0].(gotgbot.InlineQueryResultArticle); article.Title != "calc 1+1" {
+		t.Errorf("unexpected help result: %+v", results[0])
+	}
+}
+
+func reactionHandler(emoji []string, dispatched chan dispatchRecord, texts chan string) *plugin.ReactionHandler {
+	return &plugin.ReactionHandler{
+		Emoji: emoji,
+		HandlerFunc: func(_ *gotgbot.Bot, c plugin.GobotContext) error {
+			if c.EffectiveMessage != nil {
+				texts <- c.EffectiveMessage.Text
+			}
+			dispatched <- dispatchRecord{matches: c.Matches}
+			return nil
+		},
+	}
+}
+
+func reactionUpdate(chat gotgbot.Chat, messageID int64, old []string, new []string) *gotgbot.Update {
+	toReactions := func(emoji []string) []gotgbot.ReactionType {
+		var reactions []gotgbot.ReactionType
+		for _, e := range emoji {
+			reactions = append(reactions, gotgbot.ReactionTypeEmoji{Emoji: e})
+		}
+		return reactions
+	}
+	return &gotgbot.Update{
+		UpdateId: 4,
+		MessageReaction: &gotgbot.MessageReactionUpdated{
+			Chat:        chat,
+			MessageId:   messageID,
+			User:        &gotgbot.User{Id: testUserID, FirstName: "Tester"},
+			Date:        time.Now().Unix(),
+			OldReaction: toReactions(old),
+			NewReaction: toReactions(new),
+		},
+	}
+}
+
+func TestReactionDispatchWithKnownMessage(t *testing.T) {
+	dispatched := make(chan dispatchRecord, 8)
+	texts := make(chan string, 8)
+	env := newTestEnv(&fakePlugin{name: "quotes", handlers: []plugin.Handler{reactionHandler([]string{"✍"}, dispatched, texts)}})
+
+	env.process(t, messageUpdate(textMessage(groupChat(), "Zitierwürdig")))
+	env.process(t, reactionUpdate(groupChat(), 1, nil, []string{"✍"}))
+
+	r := expectDispatch(t, dispatched)
+	if r.matches[0] != "✍" {
+		t.Errorf("unexpected matches: %v", r.matches)
+	}
+	if text := <-texts; text != "Zitierwürdig" {
+		t.Errorf("expected reacted message, got %q", text)
+	}
+}
+
+func TestReactionOnlyNewEmojiTrigger(t *testing.T) {
+	dispatched := make(chan dispatchRecord, 8)
+	texts := make(chan string, 8)
+	env := newTestEnv(&fakePlugin{name: "quotes", handlers: []plugin.Handler{reactionHandler([]string{"✍"}, dispatched, texts)}})
+
+	// Adding another emoji or removing one doesn't trigger the handler again
+	env.process(t, reactionUpdate(groupChat(), 1, []string{"✍"}, []string{"✍", "👍"}))
+	env.process(t, reactionUpdate(groupChat(), 1, []string{"✍"}, nil))
+	expectNoDispatch(t, dispatched)
+}
+
+func TestReactionNotAllowed(t *testing.T) {
+	dispatched := make(chan dispatchRecord, 8)
+	texts := make(chan string, 8)
+	env := newTestEnv(&fakePlugin{name: "quotes", handlers: []plugin.Handler{reactionHandler(nil, dispatched, texts)}})
+	env.allow.userAllowed = false
+	env.allow.chatAllowed = false
+
+	env.process(t, reactionUpdate(groupChat(), 1, nil, []string{"👍"}))
+	expectNoDispatch(t, dispatched)
+}
+
+func topicMessage(text string) *gotgbot.Message {
+	msg := textMessage(groupChat(), text)
+	msg.IsTopicMessage = true
+	msg.MessageThreadId = 55
+	return msg
+}
+
+func TestTopicIsCarriedThrough(t *testing.T) {
+	env := newTestEnv(&fakePlugin{name: "say", handlers: []plugin.Handler{
+		&plugin.CommandHandler{
+			Trigger: regexp.MustCompile(`^/say$`),
+			HandlerFunc: func(b *gotgbot.Bot, c plugin.GobotContext) error {
+				if c.ThreadID != 55 {
+					t.Errorf("expected thread ID 55, got %d", c.ThreadID)
+				}
+				_, err := c.EffectiveChat.SendMessage(b, "Hallo", nil)
+				return err
+			},
+		},
+	}})
+
+	env.process(t, messageUpdate(topicMessage("/say")))
+
+	r := expectRequest(t, env.client, "sendMessage")
+	if threadID, _ := r.params["message_thread_id"].(int64); threadID != 55 {
+		t.Errorf("expected message in topic 55, got params %v", r.params)
+	}
+}
+
+func TestPluginDisabledForTopic(t *testing.T) {
+	dispatched := make(chan dispatchRecord, 8)
+	env := newTestEnv(&fakePlugin{name: "echo", handlers: []plugin.Handler{commandHandler(regexp.MustCompile(`^/echo$`), dispatched)}})
+	env.manager.disabledForTopic["echo"] = true
+
+	env.process(t, messageUpdate(topicMessage("/echo")))
+	expectNoDispatch(t, dispatched)
+
+	env.process(t, messageUpdate(textMessage(groupChat(), "/echo")))
+	expectDispatch(t, dispatched)
+}
+
+func channelPostUpdate(text string) *gotgbot.Update {
+	chat := gotgbot.Chat{Id: -300, Type: gotgbot.ChatTypeChannel, Title: "Kanal"}
+	return &gotgbot.Update{
+		UpdateId: 5,
+		ChannelPost: &gotgbot.Message{
+			MessageId:  1,
+			Date:       time.Now().Unix(),
+			Text:       text,
+			Chat:       chat,
+			SenderChat: &chat,
+		},
+	}
+}
+
+func TestChannelPostOnlyForOptedInHandlers(t *testing.T) {
+	dispatched := make(chan dispatchRecord, 8)
+	dispatchedChannel := make(chan dispatchRecord, 8)
+	channelHandler := commandHandler(regexp.MustCompile(`example\.com`), dispatchedChannel)
+	channelHandler.HandleChannelPosts = true
+	env := newTestEnv(
+		&fakePlugin{name: "links", handlers: []plugin.Handler{channelHandler}},
+		&fakePlugin{name: "echo", handlers: []plugin.Handler{commandHandler(regexp.MustCompile(`example\.com`), dispatched)}},
+	)
+	env.allow.userAllowed = false
+
+	env.process(t, channelPostUpdate("https://example.com"))
+
+	expectDispatch(t, dispatchedChannel)
+	expectNoDispatch(t, dispatched)
+	if len(env.users.created) != 0 || len(env.chatsUsers.created) != 0 {
+		t.Errorf("channel posts should not track users, got %v and %v", env.users.created, env.chatsUsers.created)
+	}
+}
+
+func TestChannelPostNotAllowed(t *testing.T) {
+	dispatched := make(chan dispatchRecord, 8)
+	handler := commandHandler(regexp.MustCompile(`.*`), dispatched)
+	handler.HandleChannelPosts = true
+	env := newTestEnv(&fakePlugin{name: "links", handlers: []plugin.Handler{handler}})
+	env.allow.chatAllowed = false
+
+	env.process(t, channelPostUpdate("https://example.com"))
+
+	expectNoDispatch(t, dispatched)
+}
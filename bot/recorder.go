@@ -0,0 +1,122 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+const (
+	recordMaxFileSize = 10 * 1024 * 1024 // Start a new file after 10 MB
+	recordMaxFiles    = 10               // Older files are deleted
+	recordFilePattern = "updates-*.jsonl"
+)
+
+// updateRecorder writes raw updates as JSON lines to rotating files so they can be replayed later.
+type updateRecorder struct {
+	mu   sync.Mutex
+	dir  string
+	file *os.File
+	size int64
+}
+
+func newUpdateRecorder(dir string) (*updateRecorder, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &updateRecorder{dir: dir}, nil
+}
+
+func (r *updateRecorder) Record(update json.RawMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, update); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	line := buf.Bytes()
+
+	if r.file == nil || r.size+int64(len(line)) > recordMaxFileSize {
+		if err := r.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := r.file.Write(line)
+	r.size += int64(n)
+	return err
+}
+
+// rotate closes the current file, opens a new one and deletes the oldest files.
+// The caller must hold the lock.
+func (r *updateRecorder) rotate() error {
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			log.Err(err).Str("file", r.file.Name()).Msg("Failed to close update recording")
+		}
+		r.file = nil
+	}
+
+	name := filepath.Join(r.dir, fmt.Sprintf("updates-%s.jsonl", time.Now().Format("20060102-150405.000")))
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	r.file = file
+	r.size = 0
+
+	files, err := filepath.Glob(filepath.Join(r.dir, recordFilePattern))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	for len(files) > recordMaxFiles {
+		if err := os.Remove(files[0]); err != nil {
+			log.Err(err).Str("file", files[0]).Msg("Failed to delete old update recording")
+		}
+		files = files[1:]
+	}
+
+	return nil
+}
+
+func (r *updateRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// recordingDispatcher records every raw update before handing it to the actual dispatcher.
+type recordingDispatcher struct {
+	ext.UpdateDispatcher
+	recorder *updateRecorder
+}
+
+func (d *recordingDispatcher) Start(b *gotgbot.Bot, updates <-chan json.RawMessage) {
+	recorded := make(chan json.RawMessage)
+	go func() {
+		defer close(recorded)
+		for update := range updates {
+			if err := d.recorder.Record(update); err != nil {
+				log.Err(err).Msg("Failed to record update")
+			}
+			recorded <- update
+		}
+	}()
+	d.UpdateDispatcher.Start(b, recorded)
+}
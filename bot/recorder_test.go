@@ -0,0 +1,50 @@
+package bot
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpdateRecorderWritesJSONLines(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := newUpdateRecorder(dir)
+	if err != nil {
+		t.Fatalf("newUpdateRecorder: %v", err)
+	}
+
+	updates := []string{
+		"{\n  \"update_id\": 1,\n  \"message\": {\"text\": \"hi\"}\n}",
+		`{"update_id":2}`,
+	}
+	for _, update := range updates {
+		if err := recorder.Record(json.RawMessage(update)); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, recordFilePattern))
+	if len(files) != 1 {
+		t.Fatalf("expected 1 recording, got %d", len(files))
+	}
+
+	content, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	want := []string{`{"update_id":1,"message":{"text":"hi"}}`, `{"update_id":2}`}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got %d: %q", len(want), len(lines), lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %s, want %s", i, lines[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package bot
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	replayBotID       = 1
+	replayMaxLineSize = 10 * 1024 * 1024
+)
+
+// replayBotClient is a fake Bot API that prints every request instead of sending it
+// and answers with minimal responses so plugins can continue.
+type replayBotClient struct {
+	mu        sync.Mutex
+	out       io.Writer
+	botUser   gotgbot.User
+	messageID int64
+}
+
+func (c *replayBotClient) RequestWithContext(_ context.Context, _ string, method string, params map[string]any, _ *gotgbot.RequestOpts) (json.RawMessage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	encodedParams, err := json.Marshal(params)
+	if err != nil {
+		encodedParams = fmt.Appendf(nil, "%v", params)
+	}
+	_, _ = fmt.Fprintf(c.out, "%s%s%s %s\n", bold, method, reset, encodedParams)
+
+	switch {
+	case method == "getMe":
+		return json.Marshal(c.botUser)
+	case method == "getFile":
+		return json.RawMessage(`{"file_id":"replay","file_unique_id":"replay","file_path":"replay"}`), nil
+	case method == "copyMessage":
+		c.messageID++
+		return json.Marshal(gotgbot.MessageId{MessageId: c.messageID})
+	case method == "sendMediaGroup":
+		msg, err := c.fakeMessage(params)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal([]json.RawMessage{msg})
+	case strings.HasPrefix(method, "send"), strings.HasPrefix(method, "editMessage"), method == "forwardMessage":
+		if _, isInline := params["inline_message_id"]; isInline {
+			return json.RawMessage(`true`), nil
+		}
+		return c.fakeMessage(params)
+	default:
+		return json.RawMessage(`true`), nil
+	}
+}
+
+// fakeMessage builds the message Telegram would return for a sent or edited message.
+// The caller must hold the lock.
+func (c *replayBotClient) fakeMessage(params map[string]any) (json.RawMessage, error) {
+	chatID, _ := params["chat_id"].(int64)
+	messageID, isEdit := params["message_id"].(int64)
+	if !isEdit {
+		c.messageID++
+		messageID = c.messageID
+	}
+
+	text, _ := params["text"].(string)
+	return json.Marshal(gotgbot.Message{
+		MessageId: messageID,
+		Date:      time.Now().Unix(),
+		Chat:      gotgbot.Chat{Id: chatID, Type: gotgbot.ChatTypePrivate},
+		From:      &c.botUser,
+		Text:      text,
+	})
+}
+
+func (c *replayBotClient) GetAPIURL(*gotgbot.RequestOpts) string { return gotgbot.DefaultAPIURL }
+
+func (c *replayBotClient) FileURL(string, string, *gotgbot.RequestOpts) string { return "" }
+
+// Replay feeds updates recorded with RECORD_UPDATES through the processor one after another.
+// Bot API requests are printed to stdout instead of being sent to Telegram,
+// database changes are applied to the configured database. Background jobs are not started.
+func Replay(db *sqlx.DB, path string, username string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	botUser := gotgbot.User{
+		Id:        replayBotID,
+		IsBot:     true,
+		FirstName: username,
+		Username:  username,
+	}
+	bot := &gotgbot.Bot{
+		Token: "replay",
+		User:  botUser,
+		BotClient: &replayBotClient{
+			out:     os.Stdout,
+			botUser: botUser,
+		},
+	}
+
+	processor, plugins, err := newProcessor(db)
+	if err != nil {
+		return err
+	}
+	log.Info().Msgf("Loaded %d plugins", len(plugins))
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), replayMaxLineSize)
+
+	var replayed int
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var update gotgbot.Update
+		if err := json.Unmarshal(line, &update); err != nil {
+			return fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+
+		log.Info().
+			Int64("update_id", update.UpdateId).
+			Str("type", update.GetType()).
+			Msg("Replaying update")
+
+		if err := processor.ProcessUpdate(nil, bot, ext.NewContext(bot, &update, nil)); err != nil {
+			OnError(err)
+		}
+		processor.Wait()
+		replayed++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	log.Info().Msgf("Replayed %d updates", replayed)
+	return nil
+}
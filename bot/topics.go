@@ -0,0 +1,56 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// topicClient sends messages into the forum topic of the update. Without it, everything
+// a handler sends to the chat without replying would land in the General topic.
+type topicClient struct {
+	gotgbot.BotClient
+	chatID   int64
+	threadID int64
+}
+
+// threadIDOf returns the forum topic of the update, 0 if it doesn't belong to one.
+func threadIDOf(ctx *ext.Context) int64 {
+	var msg *gotgbot.Message
+	switch {
+	case ctx.EffectiveMessage != nil:
+		msg = ctx.EffectiveMessage
+	case ctx.CallbackQuery != nil:
+		msg, _ = ctx.CallbackQuery.Message.(*gotgbot.Message)
+	}
+
+	if msg == nil || !msg.IsTopicMessage {
+		return 0
+	}
+	return msg.MessageThreadId
+}
+
+// topicBot returns a copy of the bot that sends messages to the chat into the topic.
+func topicBot(b *gotgbot.Bot, chatID int64, threadID int64) *gotgbot.Bot {
+	topicBot := *b
+	topicBot.BotClient = &topicClient{
+		BotClient: b.BotClient,
+		chatID:    chatID,
+		threadID:  threadID,
+	}
+	return &topicBot
+}
+
+func (c *topicClient) RequestWithContext(ctx context.Context, token string, method string, params map[string]any, opts *gotgbot.RequestOpts) (json.RawMessage, error) {
+	isSend := strings.HasPrefix(method, "send") || method == "copyMessage" || method == "forwardMessage"
+	chatID, _ := params["chat_id"].(int64)
+	if _, hasThread := params["message_thread_id"]; isSend && chatID == c.chatID && !hasThread {
+		params = maps.Clone(params)
+		params["message_thread_id"] = c.threadID
+	}
+	return c.BotClient.RequestWithContext(ctx, token, method, params, opts)
+}
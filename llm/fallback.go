@@ -0,0 +1,52 @@
+package llm
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Brawl345/gobot/utils/httpUtils"
+)
+
+type fallbackProvider struct {
+	providers []Provider
+	current   int
+}
+
+// Fallback returns a provider that switches to the next provider when one fails with
+// a server or quota error, see ShouldFallback. It stays with the provider that worked,
+// so use a new one for every conversation turn.
+func Fallback(providers ...Provider) Provider {
+	if len(providers) == 1 {
+		return providers[0]
+	}
+	return &fallbackProvider{providers: providers}
+}
+
+// ShouldFallback reports whether another provider might succeed where the one that returned err failed.
+func ShouldFallback(err error) bool {
+	httpError, ok := errors.AsType[*httpUtils.HttpError](err)
+	if !ok {
+		return false
+	}
+	return httpError.StatusCode == http.StatusTooManyRequests || httpError.StatusCode >= http.StatusInternalServerError
+}
+
+func (f *fallbackProvider) Name() string {
+	return f.providers[f.current].Name()
+}
+
+func (f *fallbackProvider) Generate(req *Request) (*Response, error) {
+	for {
+		provider := f.providers[f.current]
+		resp, err := provider.Generate(req)
+		if err == nil || f.current == len(f.providers)-1 || !ShouldFallback(err) {
+			return resp, err
+		}
+		f.current++
+		log.Warn().
+			Err(err).
+			Str("provider", provider.Name()).
+			Str("fallback", f.providers[f.current].Name()).
+			Msg("Provider failed, falling back")
+	}
+}
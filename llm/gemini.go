@@ -0,0 +1,345 @@
+package llm
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Models: https://ai.google.dev/gemini-api/docs/models/gemini
+
+const (
+	GeminiAPIBase      = "https://generativelanguage.googleapis.com"
+	DefaultGeminiModel = "gemini-2.5-flash"
+
+	geminiRoleModel      = "model"
+	geminiRoleUser       = "user"
+	geminiTemperature    = 0.8
+	geminiTopK           = 1
+	geminiTopP           = 1
+	geminiFinishMaxToken = "MAX_TOKENS"
+)
+
+type (
+	Gemini struct {
+		apiKey  string
+		apiBase string
+		model   string
+	}
+
+	geminiContent struct {
+		Role  string       `json:"role"`
+		Parts []geminiPart `json:"parts"`
+	}
+
+	geminiInlineData struct {
+		MimeType string `json:"mimeType"`
+		Data     []byte `json:"data"` // Encoded as base64 by encoding/json
+	}
+
+	geminiFunctionCall struct {
+		ID   string          `json:"id,omitempty"`
+		Name string          `json:"name"`
+		Args json.RawMessage `json:"args"`
+	}
+
+	geminiFunctionResponse struct {
+		ID       string `json:"id,omitempty"`
+		Name     string `json:"name"`
+		Response struct {
+			Output string `json:"output"`
+		} `json:"response"`
+	}
+
+	geminiPart struct {
+		Text             string                  `json:"text,omitempty"`
+		Thought          bool                    `json:"thought,omitempty"`
+		ThoughtSignature string                  `json:"thoughtSignature,omitempty"`
+		InlineData       *geminiInlineData       `json:"inlineData,omitempty"`
+		FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+		FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+	}
+
+	geminiSafetySetting struct {
+		Category  string `json:"category"`  // https://ai.google.dev/api/generate-content#v1beta.HarmCategory
+		Threshold string `json:"threshold"` // https://ai.google.dev/api/generate-content#HarmBlockThreshold
+	}
+
+	geminiGenerationConfig struct {
+		Temperature     float64              `json:"temperature"`
+		TopK            int                  `json:"topK"`
+		TopP            int                  `json:"topP"`
+		MaxOutputTokens int                  `json:"maxOutputTokens,omitempty"`
+		ThinkingConfig  geminiThinkingConfig `json:"thinkingConfig"`
+	}
+
+	// geminiThinkingConfig - https://ai.google.dev/api/generate-content#ThinkingConfig
+	geminiThinkingConfig struct {
+		IncludeThoughts bool `json:"includeThoughts"`
+		ThinkingBudget  int  `json:"thinkingBudget"`
+	}
+
+	geminiSystemInstruction struct {
+		Parts []geminiPart `json:"parts"`
+	}
+
+	geminiFunctionDeclaration struct {
+		Name                 string `json:"name"`
+		Description          string `json:"description"`
+		ParametersJsonSchema any    `json:"parametersJsonSchema"`
+	}
+
+	// geminiTool - https://ai.google.dev/api/caching#Tool
+	geminiTool struct {
+		FunctionDeclarations []geminiFunctionDeclaration `json:"functionDeclarations"`
+	}
+
+	// geminiToolConfig - https://ai.google.dev/api/caching#ToolConfig
+	geminiToolConfig struct {
+		FunctionCallingConfig struct {
+			Mode string `json:"mode"`
+		} `json:"functionCallingConfig"`
+	}
+
+	// geminiRequest - https://ai.google.dev/api/generate-content#request-body
+	geminiRequest struct {
+		Contents          []geminiContent         `json:"contents"`
+		SafetySettings    []geminiSafetySetting   `json:"safetySettings"`
+		GenerationConfig  geminiGenerationConfig  `json:"generationConfig"`
+		SystemInstruction geminiSystemInstruction `json:"system_instruction"`
+		Tools             []geminiTool            `json:"tools,omitempty"`
+		ToolConfig        *geminiToolConfig       `json:"toolConfig,omitempty"`
+	}
+
+	// geminiResponse - https://ai.google.dev/api/generate-content#generatecontentresponse
+	geminiResponse struct {
+		Candidates []struct {
+			Content      geminiContent `json:"content"`
+			FinishReason string        `json:"finishReason"`
+		} `json:"candidates"`
+		UsageMetadata struct {
+			PromptTokenCount     int `json:"promptTokenCount"`
+			CandidatesTokenCount int `json:"candidatesTokenCount"`
+		} `json:"usageMetadata"`
+	}
+
+	geminiErrorResponse struct {
+		Error struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+			Status  string `json:"status"`
+		} `json:"error"`
+	}
+)
+
+// NewGemini creates a Gemini provider. apiBase can point to a proxy, empty means GeminiAPIBase.
+func NewGemini(apiKey string, apiBase string, model string) (*Gemini, error) {
+	apiBase = cmp.Or(apiBase, GeminiAPIBase)
+	if !strings.HasPrefix(apiBase, "http://") && !strings.HasPrefix(apiBase, "https://") {
+		return nil, fmt.Errorf("invalid API base: %s", apiBase)
+	}
+
+	return &Gemini{
+		apiKey:  apiKey,
+		apiBase: strings.TrimSuffix(apiBase, "/"),
+		model:   cmp.Or(model, DefaultGeminiModel),
+	}, nil
+}
+
+func (g *Gemini) Name() string {
+	return "Gemini"
+}
+
+func (g *Gemini) Generate(req *Request) (*Response, error) {
+	temperature := geminiTemperature
+	if req.Temperature != nil {
+		temperature = *req.Temperature
+	}
+
+	apiRequest := geminiRequest{
+		Contents:          geminiContents(req.Messages),
+		SystemInstruction: geminiSystemInstruction{Parts: []geminiPart{{Text: req.Instructions}}},
+		SafetySettings: []geminiSafetySetting{
+			{
+				Category:  "HARM_CATEGORY_HARASSMENT",
+				Threshold: "OFF",
+			},
+			{
+				Category:  "HARM_CATEGORY_HATE_SPEECH",
+				Threshold: "OFF",
+			},
+			{
+				Category:  "HARM_CATEGORY_SEXUALLY_EXPLICIT",
+				Threshold: "OFF",
+			},
+			{
+				Category:  "HARM_CATEGORY_DANGEROUS_CONTENT",
+				Threshold: "OFF",
+			},
+			{
+				Category:  "HARM_CATEGORY_CIVIC_INTEGRITY",
+				Threshold: "OFF",
+			},
+		},
+		GenerationConfig: geminiGenerationConfig{
+			Temperature:     temperature,
+			TopK:            geminiTopK,
+			TopP:            geminiTopP,
+			MaxOutputTokens: req.MaxOutputTokens,
+			ThinkingConfig: geminiThinkingConfig{
+				IncludeThoughts: false,
+				ThinkingBudget:  0,
+			},
+		},
+	}
+
+	if len(req.Tools) > 0 {
+		declarations := make([]geminiFunctionDeclaration, len(req.Tools))
+		for i, tool := range req.Tools {
+			declarations[i] = geminiFunctionDeclaration{
+				Name:                 tool.Name,
+				Description:          tool.Description,
+				ParametersJsonSchema: tool.parameters(),
+			}
+		}
+		apiRequest.Tools = []geminiTool{{FunctionDeclarations: declarations}}
+
+		if req.ForceText {
+			apiRequest.ToolConfig = &geminiToolConfig{}
+			apiRequest.ToolConfig.FunctionCallingConfig.Mode = "NONE"
+		}
+	}
+
+	headers := map[string]string{"x-goog-api-key": g.apiKey}
+	var apiResponse geminiResponse
+	var apiErr geminiErrorResponse
+	var err error
+	if req.OnText != nil {
+		url := fmt.Sprintf("%s/v1beta/models/%s:streamGenerateContent?alt=sse", g.apiBase, g.model)
+		err = postStream(url, headers, &apiRequest, &apiErr, func(data []byte) error {
+			return handleGeminiChunk(data, req.OnText, &apiResponse)
+		})
+	} else {
+		url := fmt.Sprintf("%s/v1beta/models/%s:generateContent", g.apiBase, g.model)
+		err = post(url, headers, &apiRequest, &apiResponse, &apiErr)
+	}
+	if err != nil {
+		if apiErr.Error.Message != "" {
+			log.Err(err).
+				Str("api_error_status", apiErr.Error.Status).
+				Str("api_error_message", apiErr.Error.Message).
+				Msg("Gemini API error")
+		}
+		return nil, err
+	}
+
+	resp := &Response{
+		Provider: g.Name(),
+		Usage: Usage{
+			InputTokens:  apiResponse.UsageMetadata.PromptTokenCount,
+			OutputTokens: apiResponse.UsageMetadata.CandidatesTokenCount,
+		},
+	}
+	if len(apiResponse.Candidates) == 0 {
+		// Blocked prompts have no candidates
+		return resp, nil
+	}
+
+	candidate := apiResponse.Candidates[0]
+	resp.Incomplete = candidate.FinishReason == geminiFinishMaxToken
+	for i, part := range candidate.Content.Parts {
+		switch {
+		case part.Thought:
+			continue
+		case part.FunctionCall != nil:
+			resp.ToolCalls = append(resp.ToolCalls, ToolCall{
+				ID:        cmp.Or(part.FunctionCall.ID, fmt.Sprintf("call_%d", i)),
+				Name:      part.FunctionCall.Name,
+				Arguments: string(part.FunctionCall.Args),
+				Signature: part.ThoughtSignature,
+			})
+		default:
+			resp.Text += part.Text
+		}
+	}
+
+	return resp, nil
+}
+
+// handleGeminiChunk passes text to onText and appends the parts of the streamed chunk to apiResponse.
+func handleGeminiChunk(data []byte, onText func(string), apiResponse *geminiResponse) error {
+	var chunk geminiResponse
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		return err
+	}
+
+	// Every chunk has the usage so far
+	apiResponse.UsageMetadata = chunk.UsageMetadata
+	if len(chunk.Candidates) == 0 {
+		return nil
+	}
+
+	candidate := chunk.Candidates[0]
+	if len(apiResponse.Candidates) == 0 {
+		apiResponse.Candidates = append(apiResponse.Candidates, candidate)
+		apiResponse.Candidates[0].Content.Parts = nil
+	}
+	for _, part := range candidate.Content.Parts {
+		if part.Text != "" && !part.Thought && part.FunctionCall == nil {
+			onText(part.Text)
+		}
+	}
+	apiResponse.Candidates[0].Content.Parts = append(apiResponse.Candidates[0].Content.Parts, candidate.Content.Parts...)
+	apiResponse.Candidates[0].FinishReason = cmp.Or(candidate.FinishReason, apiResponse.Candidates[0].FinishReason)
+	return nil
+}
+
+func geminiContents(messages []Message) []geminiContent {
+	contents := make([]geminiContent, 0, len(messages))
+	for _, message := range messages {
+		content := geminiContent{Role: geminiRoleUser}
+		if message.Role == RoleAssistant {
+			content.Role = geminiRoleModel
+		}
+
+		for _, part := range message.Parts {
+			switch {
+			case part.ToolCall != nil:
+				args := json.RawMessage(cmp.Or(part.ToolCall.Arguments, "{}"))
+				content.Parts = append(content.Parts, geminiPart{
+					ThoughtSignature: part.ToolCall.Signature,
+					FunctionCall: &geminiFunctionCall{
+						Name: part.ToolCall.Name,
+						Args: args,
+					},
+				})
+			case part.ToolResult != nil:
+				functionResponse := &geminiFunctionResponse{Name: part.ToolResult.Name}
+				functionResponse.Response.Output = part.ToolResult.Text
+				content.Parts = append(content.Parts, geminiPart{FunctionResponse: functionResponse})
+				// Images can't be part of the function response
+				for _, image := range part.ToolResult.Images {
+					content.Parts = append(content.Parts, geminiPart{
+						InlineData: &geminiInlineData{MimeType: image.MimeType, Data: image.Data},
+					})
+				}
+			case part.Image != nil:
+				content.Parts = append(content.Parts, geminiPart{
+					InlineData: &geminiInlineData{MimeType: part.Image.MimeType, Data: part.Image.Data},
+				})
+			case part.File != nil:
+				content.Parts = append(content.Parts, geminiPart{
+					InlineData: &geminiInlineData{MimeType: part.File.MimeType, Data: part.File.Data},
+				})
+			case part.Text != "":
+				content.Parts = append(content.Parts, geminiPart{Text: part.Text})
+			}
+		}
+
+		if len(content.Parts) > 0 {
+			contents = append(contents, content)
+		}
+	}
+	return contents
+}
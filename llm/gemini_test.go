@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestGemini(t *testing.T, handler http.HandlerFunc) *Gemini {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	g, err := NewGemini("secret", server.URL, "test-model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestGeminiGenerate(t *testing.T) {
+	g := newTestGemini(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1beta/models/test-model:generateContent" {
+			t.Errorf("got path %s", r.URL.Path)
+		}
+		if r.Header.Get("x-goog-api-key") != "secret" {
+			t.Errorf("got API key %q", r.Header.Get("x-goog-api-key"))
+		}
+
+		var req geminiRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		if req.ToolConfig == nil || req.ToolConfig.FunctionCallingConfig.Mode != "NONE" {
+			t.Errorf("ForceText must disable function calling, got %+v", req.ToolConfig)
+		}
+
+		_, _ = fmt.Fprint(w, `{
+			"candidates": [{
+				"content": {"role": "model", "parts": [
+					{"text": "Nachdenken", "thought": true},
+					{"text": "Hallo"},
+					{"functionCall": {"name": "weather", "args": {"city": "Berlin"}}, "thoughtSignature": "sig"}
+				]},
+				"finishReason": "MAX_TOKENS"
+			}],
+			"usageMetadata": {"promptTokenCount": 5, "candidatesTokenCount": 3}
+		}`)
+	})
+
+	resp, err := g.Generate(&Request{Tools: []ToolDefinition{{Name: "weather"}}, ForceText: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Text != "Hallo" || !resp.Incomplete {
+		t.Errorf("got response %+v", resp)
+	}
+	want := ToolCall{ID: "call_2", Name: "weather", Arguments: `{"city": "Berlin"}`, Signature: "sig"}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0] != want {
+		t.Errorf("got tool calls %+v, want %+v", resp.ToolCalls, want)
+	}
+	if resp.Usage != (Usage{InputTokens: 5, OutputTokens: 3}) {
+		t.Errorf("got usage %+v", resp.Usage)
+	}
+}
+
+func TestGeminiGenerateStream(t *testing.T) {
+	g := newTestGemini(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1beta/models/test-model:streamGenerateContent" || r.URL.Query().Get("alt") != "sse" {
+			t.Errorf("got URL %s", r.URL)
+		}
+
+		_, _ = fmt.Fprint(w, `data: {"candidates":[{"content":{"role":"model","parts":[{"text":"Hal"}]}}],"usageMetadata":{"promptTokenCount":5}}
+
+data: {"candidates":[{"content":{"role":"model","parts":[{"text":"lo"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":5,"candidatesTokenCount":2}}
+
+`)
+	})
+
+	var streamed strings.Builder
+	resp, err := g.Generate(&Request{OnText: func(delta string) { streamed.WriteString(delta) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if streamed.String() != "Hallo" || resp.Text != "Hallo" || resp.Incomplete {
+		t.Errorf("streamed %q, got response %+v", streamed.String(), resp)
+	}
+	if resp.Usage != (Usage{InputTokens: 5, OutputTokens: 2}) {
+		t.Errorf("got usage %+v", resp.Usage)
+	}
+}
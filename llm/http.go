@@ -0,0 +1,149 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Brawl345/gobot/utils/httpUtils"
+)
+
+const (
+	MaxRetries = 3
+
+	maxEventSize = 10 * 1024 * 1024 // Streamed events can contain whole responses
+)
+
+var httpClient = httpUtils.NewHTTPClientWithTimeout(60 * time.Second)
+
+// post sends a JSON request and retries server errors with exponential backoff.
+func post(url string, headers map[string]string, body any, response any, errorResponse any) error {
+	return withRetries(url, func() error {
+		return httpUtils.MakeRequest(httpUtils.RequestOptions{
+			Method:        httpUtils.MethodPost,
+			URL:           url,
+			Headers:       headers,
+			Body:          body,
+			Response:      response,
+			ErrorResponse: errorResponse,
+			Client:        httpClient,
+		})
+	})
+}
+
+// postStream sends a JSON request and calls onEvent with the data of every server-sent event.
+// Server errors are retried like in post as long as nothing was streamed yet.
+func postStream(url string, headers map[string]string, body any, errorResponse any, onEvent func(data []byte) error) error {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	var resp *http.Response
+	err = withRetries(url, func() error {
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(jsonData))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "text/event-stream")
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+
+		resp, err = httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			defer closeBody(resp.Body)
+			if errorResponse != nil {
+				if bodyBytes, err := io.ReadAll(io.LimitReader(resp.Body, maxEventSize)); err == nil {
+					_ = json.Unmarshal(bodyBytes, errorResponse)
+				}
+			}
+			return &httpUtils.HttpError{StatusCode: resp.StatusCode}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp.Body)
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEventSize)
+	var data []byte
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			// An empty line ends the event
+			if len(data) > 0 && !bytes.Equal(data, []byte("[DONE]")) {
+				if err := onEvent(data); err != nil {
+					return err
+				}
+			}
+			data = nil
+			continue
+		}
+		if value, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+			if len(data) > 0 {
+				data = append(data, '\n')
+			}
+			data = append(data, bytes.TrimPrefix(value, []byte(" "))...)
+		}
+		// "event:", "id:" and comments are not needed, the data contains everything
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(data) > 0 && !bytes.Equal(data, []byte("[DONE]")) {
+		return onEvent(data)
+	}
+	return nil
+}
+
+func withRetries(url string, do func() error) error {
+	err := do()
+	for retryCount := 0; retryCount < MaxRetries; retryCount++ {
+		if err == nil {
+			return nil
+		}
+		httpError, ok := errors.AsType[*httpUtils.HttpError](err)
+		if !ok || !isRetryableStatus(httpError.StatusCode) {
+			return err
+		}
+		wait := time.Duration(1<<retryCount) * time.Second
+		log.Warn().
+			Err(err).
+			Str("url", httpUtils.RedactURL(url)).
+			Int("status_code", httpError.StatusCode).
+			Int("retry_count", retryCount).
+			Dur("wait", wait).
+			Msg("Received server error, retrying")
+		time.Sleep(wait)
+		err = do()
+	}
+	return err
+}
+
+func closeBody(body io.ReadCloser) {
+	if err := body.Close(); err != nil {
+		log.Err(err).Msg("Failed to close response body")
+	}
+}
+
+func isRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/Brawl345/gobot/utils/httpUtils"
+)
+
+func TestPostStream(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "events",
+			body: "data: eins\n\ndata: zwei\n\n",
+			want: []string{"eins", "zwei"},
+		},
+		{
+			name: "multi-line data",
+			body: "data: {\"a\":\ndata: 1}\n\n",
+			want: []string{"{\"a\":\n1}"},
+		},
+		{
+			name: "ignores other fields and comments",
+			body: ": ping\nevent: message\nid: 1\ndata: eins\n\n",
+			want: []string{"eins"},
+		},
+		{
+			name: "without space after colon",
+			body: "data:eins\n\n",
+			want: []string{"eins"},
+		},
+		{
+			name: "done marker",
+			body: "data: eins\n\ndata: [DONE]\n\n",
+			want: []string{"eins"},
+		},
+		{
+			name: "last event without blank line",
+			body: "data: eins\n\ndata: zwei",
+			want: []string{"eins", "zwei"},
+		},
+		{
+			name: "carriage returns",
+			body: "data: eins\r\n\r\ndata: zwei\r\n\r\n",
+			want: []string{"eins", "zwei"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Accept") != "text/event-stream" || r.Header.Get("X-Test") != "1" {
+					t.Errorf("got headers %v", r.Header)
+				}
+				w.Header().Set("Content-Type", "text/event-stream")
+				_, _ = fmt.Fprint(w, tc.body)
+			}))
+			defer server.Close()
+
+			var got []string
+			err := postStream(server.URL, map[string]string{"X-Test": "1"}, struct{}{}, nil, func(data []byte) error {
+				got = append(got, string(data))
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("got events %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPostStreamStopsOnEventError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "data: eins\n\ndata: zwei\n\n")
+	}))
+	defer server.Close()
+
+	errStop := errors.New("stop")
+	var calls int
+	err := postStream(server.URL, nil, struct{}{}, nil, func(data []byte) error {
+		calls++
+		return errStop
+	})
+
+	if !errors.Is(err, errStop) || calls != 1 {
+		t.Errorf("got error %v after %d calls", err, calls)
+	}
+}
+
+func TestPostStreamErrorResponse(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprint(w, `{"error":{"message":"kaputt","type":"invalid_request_error"}}`)
+	}))
+	defer server.Close()
+
+	var apiErr openAIErrorResponse
+	err := postStream(server.URL, nil, struct{}{}, &apiErr, func(data []byte) error {
+		t.Error("no events expected")
+		return nil
+	})
+
+	httpError, ok := errors.AsType[*httpUtils.HttpError](err)
+	if !ok || httpError.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected HTTP 400, got %v", err)
+	}
+	if apiErr.Error.Message != "kaputt" {
+		t.Errorf("error response was not parsed, got %+v", apiErr)
+	}
+	if requests != 1 {
+		t.Errorf("client errors must not be retried, got %d requests", requests)
+	}
+}
@@ -0,0 +1,164 @@
+// Package llm provides a common interface for large language model APIs.
+package llm
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/Brawl345/gobot/logger"
+)
+
+const (
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+var log = logger.New("llm")
+
+type (
+	// Provider is a large language model API.
+	Provider interface {
+		// Name is shown to users
+		Name() string
+		// Generate sends the request once. Tool calls are returned and not executed, see Run.
+		Generate(req *Request) (*Response, error)
+	}
+
+	Role string
+
+	Request struct {
+		Instructions    string
+		Messages        []Message
+		Tools           []ToolDefinition
+		ForceText       bool // Tools are only declared for the history, the model must answer with text
+		MaxOutputTokens int
+		Temperature     *float64 // nil uses the provider default
+		// OnText is called with every streamed piece of the answer. If it is nil, the response is not streamed.
+		OnText func(delta string)
+	}
+
+	Message struct {
+		Role  Role   `json:"role"`
+		Parts []Part `json:"parts"`
+	}
+
+	// Part of a message, exactly one field is set.
+	Part struct {
+		Text       string      `json:"text,omitempty"`
+		Image      *Image      `json:"image,omitempty"`
+		File       *File       `json:"file,omitempty"`
+		ToolCall   *ToolCall   `json:"tool_call,omitempty"`
+		ToolResult *ToolResult `json:"tool_result,omitempty"`
+	}
+
+	Image struct {
+		MimeType string `json:"mime_type"`
+		Data     []byte `json:"data"`
+	}
+
+	// File is a document that the model reads itself, e.g. a PDF.
+	File struct {
+		Name     string `json:"name"`
+		MimeType string `json:"mime_type"`
+		Data     []byte `json:"data"`
+		Text     string `json:"text,omitempty"` // Extracted text, sent instead to models that can't read the file
+	}
+
+	ToolCall struct {
+		ID        string `json:"id"`
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`           // JSON object
+		Signature string `json:"signature,omitempty"` // Opaque, some providers need it sent back
+	}
+
+	ToolResult struct {
+		CallID string  `json:"call_id"`
+		Name   string  `json:"name"`
+		Text   string  `json:"text,omitempty"`
+		Images []Image `json:"images,omitempty"`
+	}
+
+	Response struct {
+		Provider   string
+		Text       string
+		ToolCalls  []ToolCall
+		Incomplete bool // Cut off, e.g. because of MaxOutputTokens
+		Usage      Usage
+	}
+
+	Usage struct {
+		InputTokens  int
+		OutputTokens int
+	}
+
+	// Tool can be called by the model, see Run.
+	Tool interface {
+		Definition() ToolDefinition
+		Execute(arguments string) (ToolOutput, error)
+		Emoji() string
+	}
+
+	ToolOutput struct {
+		Text   string
+		Images []Image // For visual results
+	}
+
+	// SourcesTool is a tool whose results should be linked below the answer.
+	SourcesTool interface {
+		Tool
+		Sources() []Source
+	}
+
+	Source struct {
+		Title string
+		URL   string
+	}
+
+	ToolDefinition struct {
+		Name        string
+		Description string
+		Parameters  Parameters
+		Schema      json.RawMessage // Used instead of Parameters if set, e.g. for tools of MCP servers
+		Strict      bool            // All properties have to be required, only used by OpenAI
+	}
+
+	// Parameters is the JSON schema of the tool arguments.
+	Parameters struct {
+		Type       string              `json:"type"`
+		Properties map[string]Property `json:"properties"`
+		Required   []string            `json:"required"`
+	}
+
+	Property struct {
+		Type        string   `json:"type"`
+		Description string   `json:"description"`
+		Enum        []string `json:"enum,omitempty"`
+	}
+)
+
+// parameters returns the JSON schema of the tool arguments.
+func (t ToolDefinition) parameters() any {
+	if t.Schema != nil {
+		return t.Schema
+	}
+	return t.Parameters
+}
+
+// TextMessage creates a message that only contains text.
+func TextMessage(role Role, text string) Message {
+	return Message{Role: role, Parts: []Part{{Text: text}}}
+}
+
+// Text returns the text parts of the message.
+func (m *Message) Text() string {
+	var sb strings.Builder
+	for _, part := range m.Parts {
+		sb.WriteString(part.Text)
+	}
+	return sb.String()
+}
+
+func (u *Usage) add(other Usage) {
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+}
@@ -0,0 +1,451 @@
+package llm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Brawl345/gobot/utils/httpUtils"
+)
+
+// OpenAI Responses API: https://platform.openai.com/docs/api-reference/responses
+// Servers without it (e.g. llama.cpp) get the Chat Completions API, see openai_chat.go.
+
+const (
+	OpenAIBaseURL      = "https://api.openai.com/v1"
+	DefaultOpenAIModel = "gpt-5.6-sol"
+
+	openAITypeInputText          = "input_text"
+	openAITypeInputImage         = "input_image"
+	openAITypeInputFile          = "input_file"
+	openAITypeMessage            = "message"
+	openAITypeOutputText         = "output_text"
+	openAITypeFunctionCall       = "function_call"
+	openAITypeFunctionCallOutput = "function_call_output"
+
+	openAIStatusIncomplete = "incomplete"
+
+	openAIEventTextDelta  = "response.output_text.delta"
+	openAIEventCompleted  = "response.completed"
+	openAIEventIncomplete = "response.incomplete"
+	openAIEventFailed     = "response.failed"
+	openAIEventError      = "error"
+
+	// How long a server is assumed to lack the Responses API before it is tried again,
+	// since a 404 can also be a temporary misconfiguration of the server
+	chatCompletionsOnlyTTL = time.Hour
+)
+
+type (
+	OpenAI struct {
+		baseURL  string
+		headers  map[string]string
+		model    string
+		official bool
+	}
+
+	OpenAIConfig struct {
+		APIKey     string // Optional for self-hosted servers
+		BaseURL    string // Empty means OpenAIBaseURL, e.g. http://localhost:11434/v1 for Ollama
+		Model      string // Required for self-hosted servers
+		AuthHeader string // See AuthHeaders
+	}
+
+	openAIInputText struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+
+	openAIInputImage struct {
+		Type     string `json:"type"`
+		ImageURL string `json:"image_url"`
+	}
+
+	openAIInputFile struct {
+		Type     string `json:"type"`
+		Filename string `json:"filename"`
+		FileData string `json:"file_data"`
+	}
+
+	openAIInputMessage struct {
+		Role    Role  `json:"role"`
+		Content []any `json:"content"`
+	}
+
+	openAIAssistantMessage struct {
+		Role    Role   `json:"role"`
+		Content string `json:"content"`
+	}
+
+	openAIFunctionCall struct {
+		Type      string `json:"type"`
+		CallID    string `json:"call_id"`
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	}
+
+	// openAIFunctionCallOutput carries a tool result; Output is either a plain string
+	// or a list of input parts when a tool returns visual content.
+	openAIFunctionCallOutput struct {
+		Type   string `json:"type"`
+		CallID string `json:"call_id"`
+		Output any    `json:"output"`
+	}
+
+	openAIParameters struct {
+		Parameters
+		AdditionalProperties bool `json:"additionalProperties"`
+	}
+
+	openAIFunctionTool struct {
+		Type        string `json:"type"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Parameters  any    `json:"parameters"` // openAIParameters or a raw schema
+		Strict      bool   `json:"strict"`
+	}
+
+	openAIReasoning struct {
+		Effort string `json:"effort"`
+	}
+
+	openAIRequest struct {
+		Model           string               `json:"model"`
+		Input           []any                `json:"input"`
+		Instructions    string               `json:"instructions"`
+		Store           bool                 `json:"store"`
+		MaxOutputTokens int                  `json:"max_output_tokens,omitempty"`
+		Temperature     *float64             `json:"temperature,omitempty"`
+		Tools           []openAIFunctionTool `json:"tools,omitempty"`
+		ToolChoice      string               `json:"tool_choice,omitempty"`
+		Reasoning       *openAIReasoning     `json:"reasoning,omitempty"`
+		Stream          bool                 `json:"stream,omitempty"`
+	}
+
+	// openAIOutputItem handles both "message" and "function_call" output types.
+	openAIOutputItem struct {
+		Type string `json:"type"`
+		// "message" fields
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content,omitempty"`
+		// "function_call" fields
+		CallID    string `json:"call_id,omitempty"`
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments,omitempty"`
+	}
+
+	openAIResponse struct {
+		Status string `json:"status"`
+		Error  *struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+		Output []openAIOutputItem `json:"output"`
+		Usage  struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+
+	// openAIStreamEvent - https://platform.openai.com/docs/api-reference/responses-streaming
+	openAIStreamEvent struct {
+		Type     string          `json:"type"`
+		Delta    string          `json:"delta"`
+		Response *openAIResponse `json:"response"`
+		// "error" event fields
+		Code    json.RawMessage `json:"code"`
+		Message string          `json:"message"`
+	}
+
+	openAIErrorResponse struct {
+		Error struct {
+			Message string          `json:"message"`
+			Type    string          `json:"type"`
+			Code    json.RawMessage `json:"code"` // A string at OpenAI, a number at some other servers
+		} `json:"error"`
+	}
+)
+
+// Base URLs of servers that don't have the Responses API, mapped to when that was noticed
+var chatCompletionsOnly sync.Map
+
+// NewOpenAI creates a provider for the OpenAI API or a compatible server.
+func NewOpenAI(config OpenAIConfig) (*OpenAI, error) {
+	o := &OpenAI{
+		baseURL:  strings.TrimSuffix(config.BaseURL, "/"),
+		headers:  AuthHeaders(config.AuthHeader, config.APIKey),
+		model:    config.Model,
+		official: config.BaseURL == "",
+	}
+
+	if o.official {
+		o.baseURL = OpenAIBaseURL
+		if o.model == "" {
+			o.model = DefaultOpenAIModel
+		}
+	} else {
+		if !strings.HasPrefix(o.baseURL, "http://") && !strings.HasPrefix(o.baseURL, "https://") {
+			return nil, fmt.Errorf("invalid base URL: %s", config.BaseURL)
+		}
+		if o.model == "" {
+			return nil, errors.New("model is required for custom base URLs")
+		}
+	}
+
+	return o, nil
+}
+
+// AuthHeaders returns the headers to authenticate with apiKey. The default header is
+// "Authorization: Bearer <key>", other headers (e.g. "api-key" for Azure) get the plain key.
+func AuthHeaders(header string, apiKey string) map[string]string {
+	if apiKey == "" {
+		return map[string]string{}
+	}
+	if header == "" || strings.EqualFold(header, "Authorization") {
+		return map[string]string{"Authorization": fmt.Sprintf("Bearer %s", apiKey)}
+	}
+	return map[string]string{header: apiKey}
+}
+
+// Name returns "OpenAI" for the official API and the model name for other servers.
+func (o *OpenAI) Name() string {
+	if o.official {
+		return "OpenAI"
+	}
+	return o.model
+}
+
+func (o *OpenAI) Generate(req *Request) (*Response, error) {
+	if !o.official {
+		// Most other servers can't read files and fail with HTTP 400
+		req = withoutFiles(req)
+	}
+
+	if noticed, ok := chatCompletionsOnly.Load(o.baseURL); ok {
+		if time.Since(noticed.(time.Time)) < chatCompletionsOnlyTTL {
+			return o.generateChat(req)
+		}
+		chatCompletionsOnly.Delete(o.baseURL)
+	}
+
+	resp, err := o.generateResponses(req)
+	if httpError, ok := errors.AsType[*httpUtils.HttpError](err); ok && !o.official {
+		switch httpError.StatusCode {
+		case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+			log.Info().
+				Str("base_url", o.baseURL).
+				Msg("Server has no Responses API, using Chat Completions")
+			chatCompletionsOnly.Store(o.baseURL, time.Now())
+			return o.generateChat(req)
+		}
+	}
+	return resp, err
+}
+
+// withoutFiles returns a copy of the request whose files are replaced by their text, or by a note
+// for the model if it couldn't be extracted.
+func withoutFiles(req *Request) *Request {
+	stripped := *req
+	stripped.Messages = make([]Message, len(req.Messages))
+	for i, message := range req.Messages {
+		stripped.Messages[i] = message
+		if !slices.ContainsFunc(message.Parts, func(part Part) bool { return part.File != nil }) {
+			continue
+		}
+		stripped.Messages[i].Parts = make([]Part, len(message.Parts))
+		for j, part := range message.Parts {
+			switch {
+			case part.File != nil && part.File.Text != "":
+				part = Part{Text: fmt.Sprintf("[Text aus \"%s\"]\n%s", part.File.Name, part.File.Text)}
+			case part.File != nil:
+				part = Part{Text: fmt.Sprintf("[Die Datei \"%s\" kann von diesem Modell nicht gelesen werden]", part.File.Name)}
+			}
+			stripped.Messages[i].Parts[j] = part
+		}
+	}
+	return &stripped
+}
+
+func (o *OpenAI) generateResponses(req *Request) (*Response, error) {
+	apiRequest := openAIRequest{
+		Model:           o.model,
+		Input:           openAIInput(req.Messages),
+		Instructions:    req.Instructions,
+		Store:           false,
+		MaxOutputTokens: req.MaxOutputTokens,
+		Temperature:     req.Temperature,
+	}
+	if o.official {
+		// Other servers and models might not support disabling reasoning
+		apiRequest.Reasoning = &openAIReasoning{Effort: "none"}
+	}
+	for _, tool := range req.Tools {
+		apiRequest.Tools = append(apiRequest.Tools, openAIFunctionTool{
+			Type:        "function",
+			Name:        tool.Name,
+			Description: tool.Description,
+			Parameters:  openAIToolParameters(tool),
+			Strict:      tool.Strict,
+		})
+	}
+	if req.ForceText && len(apiRequest.Tools) > 0 {
+		apiRequest.ToolChoice = "none"
+	}
+
+	var apiResponse openAIResponse
+	var apiErr openAIErrorResponse
+	var err error
+	if req.OnText != nil {
+		apiRequest.Stream = true
+		err = postStream(o.baseURL+"/responses", o.headers, &apiRequest, &apiErr, func(data []byte) error {
+			return o.handleStreamEvent(data, req.OnText, &apiResponse)
+		})
+	} else {
+		err = post(o.baseURL+"/responses", o.headers, &apiRequest, &apiResponse, &apiErr)
+	}
+	if err != nil {
+		if apiErr.Error.Message != "" {
+			log.Err(err).
+				Str("api_error_type", apiErr.Error.Type).
+				Str("api_error_code", string(apiErr.Error.Code)).
+				Str("api_error_message", apiErr.Error.Message).
+				Msg("OpenAI API error")
+		}
+		return nil, err
+	}
+
+	resp := &Response{
+		Provider:   o.Name(),
+		Incomplete: apiResponse.Status == openAIStatusIncomplete,
+		Usage: Usage{
+			InputTokens:  apiResponse.Usage.InputTokens,
+			OutputTokens: apiResponse.Usage.OutputTokens,
+		},
+	}
+	for _, item := range apiResponse.Output {
+		switch item.Type {
+		case openAITypeMessage:
+			for _, content := range item.Content {
+				if content.Type == openAITypeOutputText {
+					resp.Text += content.Text
+				}
+			}
+		case openAITypeFunctionCall:
+			resp.ToolCalls = append(resp.ToolCalls, ToolCall{
+				ID:        item.CallID,
+				Name:      item.Name,
+				Arguments: item.Arguments,
+			})
+		}
+	}
+
+	return resp, nil
+}
+
+// handleStreamEvent passes text deltas to onText and stores the final response in apiResponse.
+func (o *OpenAI) handleStreamEvent(data []byte, onText func(string), apiResponse *openAIResponse) error {
+	var event openAIStreamEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return err
+	}
+
+	switch event.Type {
+	case openAIEventTextDelta:
+		onText(event.Delta)
+	case openAIEventCompleted, openAIEventIncomplete:
+		if event.Response != nil {
+			*apiResponse = *event.Response
+		}
+	case openAIEventFailed:
+		if event.Response != nil && event.Response.Error != nil {
+			return fmt.Errorf("response failed: %s (%s)", event.Response.Error.Message, event.Response.Error.Code)
+		}
+		return errors.New("response failed")
+	case openAIEventError:
+		return fmt.Errorf("stream error: %s (%s)", event.Message, string(event.Code))
+	}
+	return nil
+}
+
+func openAIInput(messages []Message) []any {
+	var input []any
+	for _, message := range messages {
+		var content []any
+		for _, part := range message.Parts {
+			switch {
+			case part.ToolCall != nil:
+				input = append(input, openAIFunctionCall{
+					Type:      openAITypeFunctionCall,
+					CallID:    part.ToolCall.ID,
+					Name:      part.ToolCall.Name,
+					Arguments: part.ToolCall.Arguments,
+				})
+			case part.ToolResult != nil:
+				input = append(input, openAIFunctionCallOutput{
+					Type:   openAITypeFunctionCallOutput,
+					CallID: part.ToolResult.CallID,
+					Output: openAIToolOutput(part.ToolResult),
+				})
+			case part.Image != nil:
+				content = append(content, openAIImage(part.Image))
+			case part.File != nil:
+				content = append(content, openAIFile(part.File))
+			case part.Text != "":
+				content = append(content, openAIInputText{Type: openAITypeInputText, Text: part.Text})
+			}
+		}
+		if len(content) == 0 {
+			continue
+		}
+		if message.Role == RoleAssistant {
+			input = append(input, openAIAssistantMessage{Role: RoleAssistant, Content: message.Text()})
+		} else {
+			input = append(input, openAIInputMessage{Role: message.Role, Content: content})
+		}
+	}
+	return input
+}
+
+func openAIToolParameters(tool ToolDefinition) any {
+	if tool.Schema != nil {
+		return tool.Schema
+	}
+	return openAIParameters{Parameters: tool.Parameters}
+}
+
+func openAIToolOutput(result *ToolResult) any {
+	if len(result.Images) == 0 {
+		return result.Text
+	}
+	var output []any
+	if result.Text != "" {
+		output = append(output, openAIInputText{Type: openAITypeInputText, Text: result.Text})
+	}
+	for _, image := range result.Images {
+		output = append(output, openAIImage(&image))
+	}
+	return output
+}
+
+func openAIImage(image *Image) openAIInputImage {
+	return openAIInputImage{
+		Type:     openAITypeInputImage,
+		ImageURL: fmt.Sprintf("data:%s;base64,%s", image.MimeType, base64.StdEncoding.EncodeToString(image.Data)),
+	}
+}
+
+func openAIFile(file *File) openAIInputFile {
+	return openAIInputFile{
+		Type:     openAITypeInputFile,
+		Filename: file.Name,
+		FileData: fmt.Sprintf("data:%s;base64,%s", file.MimeType, base64.StdEncoding.EncodeToString(file.Data)),
+	}
+}
@@ -0,0 +1,268 @@
+package llm
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+)
+
+// Chat Completions API: https://platform.openai.com/docs/api-reference/chat
+// Only used for servers that lack the Responses API, files are removed before, see withoutFiles.
+
+const (
+	openAIChatRoleSystem       = "system"
+	openAIChatRoleTool         = "tool"
+	openAIChatTypeText         = "text"
+	openAIChatTypeImageURL     = "image_url"
+	openAIChatFinishLength     = "length"
+	openAIChatToolTypeFunction = "function"
+)
+
+type (
+	openAIChatImageURL struct {
+		URL string `json:"url"`
+	}
+
+	openAIChatContentPart struct {
+		Type     string              `json:"type"`
+		Text     string              `json:"text,omitempty"`
+		ImageURL *openAIChatImageURL `json:"image_url,omitempty"`
+	}
+
+	openAIChatToolCall struct {
+		ID       string `json:"id"`
+		Type     string `json:"type"`
+		Function struct {
+			Name      string `json:"name"`
+			Arguments string `json:"arguments"`
+		} `json:"function"`
+	}
+
+	openAIChatMessage struct {
+		Role       string               `json:"role"`
+		Content    any                  `json:"content"` // string or []openAIChatContentPart
+		ToolCalls  []openAIChatToolCall `json:"tool_calls,omitempty"`
+		ToolCallID string               `json:"tool_call_id,omitempty"`
+	}
+
+	openAIChatTool struct {
+		Type     string `json:"type"`
+		Function struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			Parameters  any    `json:"parameters"`
+			Strict      bool   `json:"strict"`
+		} `json:"function"`
+	}
+
+	openAIChatRequest struct {
+		Model         string                   `json:"model"`
+		Messages      []openAIChatMessage      `json:"messages"`
+		MaxTokens     int                      `json:"max_tokens,omitempty"`
+		Temperature   *float64                 `json:"temperature,omitempty"`
+		Tools         []openAIChatTool         `json:"tools,omitempty"`
+		ToolChoice    string                   `json:"tool_choice,omitempty"`
+		Stream        bool                     `json:"stream,omitempty"`
+		StreamOptions *openAIChatStreamOptions `json:"stream_options,omitempty"`
+	}
+
+	openAIChatStreamOptions struct {
+		IncludeUsage bool `json:"include_usage"`
+	}
+
+	// openAIChatToolCallDelta is a piece of a streamed tool call, the pieces are joined by Index.
+	openAIChatToolCallDelta struct {
+		Index int `json:"index"`
+		openAIChatToolCall
+	}
+
+	// openAIChatChunk - https://platform.openai.com/docs/api-reference/chat-streaming
+	openAIChatChunk struct {
+		Choices []struct {
+			Delta struct {
+				Content   string                    `json:"content"`
+				ToolCalls []openAIChatToolCallDelta `json:"tool_calls"`
+			} `json:"delta"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage *struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+
+	openAIChatChoice struct {
+		Message struct {
+			Content   string               `json:"content"`
+			ToolCalls []openAIChatToolCall `json:"tool_calls"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	}
+
+	openAIChatResponse struct {
+		Choices []openAIChatChoice `json:"choices"`
+		Usage   struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+)
+
+func (o *OpenAI) generateChat(req *Request) (*Response, error) {
+	apiRequest := openAIChatRequest{
+		Model:       o.model,
+		Messages:    openAIChatMessages(req.Instructions, req.Messages),
+		MaxTokens:   req.MaxOutputTokens,
+		Temperature: req.Temperature,
+	}
+	for _, tool := range req.Tools {
+		chatTool := openAIChatTool{Type: openAIChatToolTypeFunction}
+		chatTool.Function.Name = tool.Name
+		chatTool.Function.Description = tool.Description
+		chatTool.Function.Parameters = openAIToolParameters(tool)
+		chatTool.Function.Strict = tool.Strict
+		apiRequest.Tools = append(apiRequest.Tools, chatTool)
+	}
+	if req.ForceText && len(apiRequest.Tools) > 0 {
+		apiRequest.ToolChoice = "none"
+	}
+
+	var apiResponse openAIChatResponse
+	var apiErr openAIErrorResponse
+	var err error
+	if req.OnText != nil {
+		apiRequest.Stream = true
+		apiRequest.StreamOptions = &openAIChatStreamOptions{IncludeUsage: true}
+		err = postStream(o.baseURL+"/chat/completions", o.headers, &apiRequest, &apiErr, func(data []byte) error {
+			return handleChatChunk(data, req.OnText, &apiResponse)
+		})
+	} else {
+		err = post(o.baseURL+"/chat/completions", o.headers, &apiRequest, &apiResponse, &apiErr)
+	}
+	if err != nil {
+		if apiErr.Error.Message != "" {
+			log.Err(err).
+				Str("api_error_type", apiErr.Error.Type).
+				Str("api_error_code", string(apiErr.Error.Code)).
+				Str("api_error_message", apiErr.Error.Message).
+				Msg("Chat Completions API error")
+		}
+		return nil, err
+	}
+
+	resp := &Response{
+		Provider: o.Name(),
+		Usage: Usage{
+			InputTokens:  apiResponse.Usage.PromptTokens,
+			OutputTokens: apiResponse.Usage.CompletionTokens,
+		},
+	}
+	if len(apiResponse.Choices) == 0 {
+		return resp, nil
+	}
+
+	choice := apiResponse.Choices[0]
+	resp.Text = choice.Message.Content
+	resp.Incomplete = choice.FinishReason == openAIChatFinishLength
+	for i, call := range choice.Message.ToolCalls {
+		resp.ToolCalls = append(resp.ToolCalls, ToolCall{
+			ID:        cmp.Or(call.ID, fmt.Sprintf("call_%d", i)),
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		})
+	}
+
+	return resp, nil
+}
+
+// handleChatChunk passes text deltas to onText and assembles the streamed chunks in apiResponse.
+func handleChatChunk(data []byte, onText func(string), apiResponse *openAIChatResponse) error {
+	var chunk openAIChatChunk
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		return err
+	}
+
+	if chunk.Usage != nil {
+		apiResponse.Usage.PromptTokens = chunk.Usage.PromptTokens
+		apiResponse.Usage.CompletionTokens = chunk.Usage.CompletionTokens
+	}
+	if len(chunk.Choices) == 0 {
+		return nil
+	}
+	if len(apiResponse.Choices) == 0 {
+		apiResponse.Choices = []openAIChatChoice{{}}
+	}
+
+	delta := chunk.Choices[0].Delta
+	choice := &apiResponse.Choices[0]
+	if delta.Content != "" {
+		choice.Message.Content += delta.Content
+		onText(delta.Content)
+	}
+	for _, callDelta := range delta.ToolCalls {
+		for len(choice.Message.ToolCalls) <= callDelta.Index {
+			choice.Message.ToolCalls = append(choice.Message.ToolCalls, openAIChatToolCall{Type: openAIChatToolTypeFunction})
+		}
+		call := &choice.Message.ToolCalls[callDelta.Index]
+		call.ID = cmp.Or(callDelta.ID, call.ID)
+		call.Function.Name += callDelta.Function.Name
+		call.Function.Arguments += callDelta.Function.Arguments
+	}
+	if chunk.Choices[0].FinishReason != "" {
+		choice.FinishReason = chunk.Choices[0].FinishReason
+	}
+	return nil
+}
+
+func openAIChatMessages(instructions string, messages []Message) []openAIChatMessage {
+	chatMessages := []openAIChatMessage{{Role: openAIChatRoleSystem, Content: instructions}}
+
+	for _, message := range messages {
+		if message.Role == RoleAssistant {
+			chatMessage := openAIChatMessage{Role: string(RoleAssistant), Content: message.Text()}
+			for _, part := range message.Parts {
+				if part.ToolCall == nil {
+					continue
+				}
+				call := openAIChatToolCall{ID: part.ToolCall.ID, Type: openAIChatToolTypeFunction}
+				call.Function.Name = part.ToolCall.Name
+				call.Function.Arguments = part.ToolCall.Arguments
+				chatMessage.ToolCalls = append(chatMessage.ToolCalls, call)
+			}
+			chatMessages = append(chatMessages, chatMessage)
+			continue
+		}
+
+		var content []openAIChatContentPart
+		for _, part := range message.Parts {
+			switch {
+			case part.ToolResult != nil:
+				chatMessages = append(chatMessages, openAIChatMessage{
+					Role:       openAIChatRoleTool,
+					Content:    part.ToolResult.Text,
+					ToolCallID: part.ToolResult.CallID,
+				})
+				// Tool messages can only contain text, images follow in a user message
+				for _, image := range part.ToolResult.Images {
+					content = append(content, openAIChatImage(&image))
+				}
+			case part.Image != nil:
+				content = append(content, openAIChatImage(part.Image))
+			case part.Text != "":
+				content = append(content, openAIChatContentPart{Type: openAIChatTypeText, Text: part.Text})
+			}
+		}
+		if len(content) > 0 {
+			chatMessages = append(chatMessages, openAIChatMessage{Role: string(RoleUser), Content: content})
+		}
+	}
+
+	return chatMessages
+}
+
+func openAIChatImage(image *Image) openAIChatContentPart {
+	return openAIChatContentPart{
+		Type:     openAIChatTypeImageURL,
+		ImageURL: &openAIChatImageURL{URL: openAIImage(image).ImageURL},
+	}
+}
@@ -0,0 +1,272 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestOpenAI(t *testing.T, handler http.HandlerFunc) *OpenAI {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	o, err := NewOpenAI(OpenAIConfig{APIKey: "secret", BaseURL: server.URL + "/v1/", Model: "test-model"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+func TestOpenAIGenerate(t *testing.T) {
+	o := newTestOpenAI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/responses" {
+			t.Errorf("got path %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("got authorization %q", r.Header.Get("Authorization"))
+		}
+
+		var req openAIRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		if req.Model != "test-model" || req.Instructions != "Sei nett" || req.Stream || req.Reasoning != nil {
+			t.Errorf("got request %+v", req)
+		}
+		if len(req.Tools) != 1 || req.Tools[0].Name != "weather" || req.ToolChoice != "" {
+			t.Errorf("got tools %+v", req.Tools)
+		}
+
+		_, _ = fmt.Fprint(w, `{
+			"status": "completed",
+			"output": [
+				{"type": "message", "content": [{"type": "output_text", "text": "Hallo"}]},
+				{"type": "function_call", "call_id": "c1", "name": "weather", "arguments": "{}"}
+			],
+			"usage": {"input_tokens": 5, "output_tokens": 3}
+		}`)
+	})
+
+	resp, err := o.Generate(&Request{
+		Instructions: "Sei nett",
+		Messages:     []Message{TextMessage(RoleUser, "Hi")},
+		Tools:        []ToolDefinition{{Name: "weather"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Provider != "test-model" || resp.Text != "Hallo" || resp.Incomplete {
+		t.Errorf("got response %+v", resp)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0] != (ToolCall{ID: "c1", Name: "weather", Arguments: "{}"}) {
+		t.Errorf("got tool calls %+v", resp.ToolCalls)
+	}
+	if resp.Usage != (Usage{InputTokens: 5, OutputTokens: 3}) {
+		t.Errorf("got usage %+v", resp.Usage)
+	}
+}
+
+func TestOpenAIGenerateStream(t *testing.T) {
+	o := newTestOpenAI(t, func(w http.ResponseWriter, r *http.Request) {
+		var req openAIRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		if !req.Stream {
+			t.Error("expected a streamed request")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, `event: response.output_text.delta
+data: {"type":"response.output_text.delta","delta":"Hal"}
+
+event: response.output_text.delta
+data: {"type":"response.output_text.delta","delta":"lo"}
+
+event: response.incomplete
+data: {"type":"response.incomplete","response":{"status":"incomplete","output":[{"type":"message","content":[{"type":"output_text","text":"Hallo"}]}],"usage":{"input_tokens":5,"output_tokens":2}}}
+
+`)
+	})
+
+	var streamed strings.Builder
+	resp, err := o.Generate(&Request{OnText: func(delta string) { streamed.WriteString(delta) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if streamed.String() != "Hallo" {
+		t.Errorf("streamed %q", streamed.String())
+	}
+	if resp.Text != "Hallo" || !resp.Incomplete || resp.Usage.OutputTokens != 2 {
+		t.Errorf("got response %+v", resp)
+	}
+}
+
+func TestOpenAIGenerateStreamError(t *testing.T) {
+	o := newTestOpenAI(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "data: {\"type\":\"response.failed\",\"response\":{\"error\":{\"code\":\"server_error\",\"message\":\"kaputt\"}}}\n\n")
+	})
+
+	_, err := o.Generate(&Request{OnText: func(string) {}})
+
+	if err == nil || !strings.Contains(err.Error(), "kaputt") {
+		t.Errorf("expected the failure of the response, got %v", err)
+	}
+}
+
+func TestOpenAIChatCompletionsStream(t *testing.T) {
+	o := newTestOpenAI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+
+		var req openAIChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		if req.Messages[0].Role != openAIChatRoleSystem || req.StreamOptions == nil {
+			t.Errorf("got request %+v", req)
+		}
+
+		_, _ = fmt.Fprint(w, `data: {"choices":[{"delta":{"content":"Moment"}}]}
+
+data: {"choices":[{"delta":{"tool_calls":[{"index":0,"id":"c1","function":{"name":"weather","arguments":"{\"ci"}}]}}]}
+
+data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"ty\":1}"}}]},"finish_reason":"tool_calls"}]}
+
+data: {"choices":[],"usage":{"prompt_tokens":7,"completion_tokens":4}}
+
+data: [DONE]
+
+`)
+	})
+
+	var streamed strings.Builder
+	resp, err := o.Generate(&Request{
+		Instructions: "Sei nett",
+		OnText:       func(delta string) { streamed.WriteString(delta) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if streamed.String() != "Moment" || resp.Text != "Moment" {
+		t.Errorf("streamed %q, got text %q", streamed.String(), resp.Text)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0] != (ToolCall{ID: "c1", Name: "weather", Arguments: `{"city":1}`}) {
+		t.Errorf("tool call pieces must be joined, got %+v", resp.ToolCalls)
+	}
+	if resp.Usage != (Usage{InputTokens: 7, OutputTokens: 4}) {
+		t.Errorf("got usage %+v", resp.Usage)
+	}
+}
+
+func TestAuthHeaders(t *testing.T) {
+	tests := []struct {
+		header string
+		apiKey string
+		want   map[string]string
+	}{
+		{"", "", map[string]string{}},
+		{"", "key", map[string]string{"Authorization": "Bearer key"}},
+		{"authorization", "key", map[string]string{"Authorization": "Bearer key"}},
+		{"api-key", "key", map[string]string{"api-key": "key"}},
+	}
+
+	for _, tc := range tests {
+		got := AuthHeaders(tc.header, tc.apiKey)
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("AuthHeaders(%q, %q) = %v, want %v", tc.header, tc.apiKey, got, tc.want)
+		}
+	}
+}
+
+func TestOpenAIChatCompletionsOnlyExpires(t *testing.T) {
+	var paths []string
+	o := newTestOpenAI(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/v1/responses" {
+			_, _ = fmt.Fprint(w, `{"status":"completed","output":[]}`)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"choices":[]}`)
+	})
+
+	chatCompletionsOnly.Store(o.baseURL, time.Now())
+	if _, err := o.Generate(&Request{}); err != nil {
+		t.Fatal(err)
+	}
+	chatCompletionsOnly.Store(o.baseURL, time.Now().Add(-chatCompletionsOnlyTTL))
+	if _, err := o.Generate(&Request{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(paths, []string{"/v1/chat/completions", "/v1/responses"}) {
+		t.Errorf("the Responses API must be tried again after %s, got requests to %v", chatCompletionsOnlyTTL, paths)
+	}
+	if _, ok := chatCompletionsOnly.Load(o.baseURL); ok {
+		t.Error("the server must not be marked anymore")
+	}
+}
+
+func TestOpenAICustomServerGetsNoFiles(t *testing.T) {
+	o := newTestOpenAI(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input []struct {
+				Content []map[string]any `json:"content"`
+			} `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		content := req.Input[0].Content
+		if len(content) != 2 || content[1]["type"] != openAITypeInputText || !strings.Contains(content[1]["text"].(string), "brief.pdf") {
+			t.Errorf("the file must be replaced by a note, got %v", content)
+		}
+		_, _ = fmt.Fprint(w, `{"status":"completed","output":[]}`)
+	})
+
+	file := &File{Name: "brief.pdf", MimeType: "application/pdf", Data: []byte("%PDF")}
+	message := Message{Role: RoleUser, Parts: []Part{{Text: "Lies das"}, {File: file}}}
+	req := &Request{Messages: []Message{message}}
+	if _, err := o.Generate(req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.Messages[0].Parts[1].File != file {
+		t.Error("the request of the caller must not be changed, it's used again on fallback")
+	}
+}
+
+func TestOpenAICustomServerGetsExtractedText(t *testing.T) {
+	o := newTestOpenAI(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input []struct {
+				Content []map[string]any `json:"content"`
+			} `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		content := req.Input[0].Content
+		if len(content) != 2 || content[1]["type"] != openAITypeInputText || !strings.Contains(content[1]["text"].(string), "Hallo Welt") {
+			t.Errorf("the file must be replaced by its text, got %v", content)
+		}
+		_, _ = fmt.Fprint(w, `{"status":"completed","output":[]}`)
+	})
+
+	file := &File{Name: "brief.pdf", MimeType: "application/pdf", Data: []byte("%PDF"), Text: "Hallo Welt"}
+	message := Message{Role: RoleUser, Parts: []Part{{Text: "Lies das"}, {File: file}}}
+	if _, err := o.Generate(&Request{Messages: []Message{message}}); err != nil {
+		t.Fatal(err)
+	}
+}
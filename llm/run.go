@@ -0,0 +1,119 @@
+package llm
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+const MaxToolCallRounds = 3
+
+type Result struct {
+	Provider   string // Name of the provider that gave the answer
+	Text       string
+	Incomplete bool
+	Usage      Usage  // Summed up over all rounds
+	UsedTools  []Tool // In the order they were passed to Run
+}
+
+// Run sends the request and executes the tool calls of the model until it answers with text.
+// onToolCalls is called before the tools of a round are executed and may be nil.
+func Run(provider Provider, req Request, tools []Tool, onToolCalls func(calls []ToolCall)) (*Result, error) {
+	toolMap := make(map[string]Tool, len(tools))
+	req.Tools = make([]ToolDefinition, len(tools))
+	for i, tool := range tools {
+		definition := tool.Definition()
+		req.Tools[i] = definition
+		toolMap[definition.Name] = tool
+	}
+	req.Messages = slices.Clone(req.Messages)
+
+	var result Result
+	usedToolNames := make(map[string]struct{})
+
+	for round := 0; ; round++ {
+		// In the final round, the model is forced to produce a text answer
+		// instead of requesting more tool calls we can't satisfy.
+		req.ForceText = round == MaxToolCallRounds
+
+		resp, err := provider.Generate(&req)
+		if err != nil {
+			return nil, err
+		}
+		result.Provider = resp.Provider
+		result.Usage.add(resp.Usage)
+
+		if len(resp.ToolCalls) == 0 || req.ForceText {
+			result.Text = resp.Text
+			result.Incomplete = resp.Incomplete
+			break
+		}
+
+		if onToolCalls != nil {
+			onToolCalls(resp.ToolCalls)
+		}
+
+		callMessage := Message{Role: RoleAssistant}
+		if resp.Text != "" {
+			callMessage.Parts = append(callMessage.Parts, Part{Text: resp.Text})
+		}
+		for _, call := range resp.ToolCalls {
+			callMessage.Parts = append(callMessage.Parts, Part{ToolCall: &call})
+		}
+
+		results := executeTools(toolMap, resp.ToolCalls)
+		resultMessage := Message{Role: RoleUser, Parts: make([]Part, len(results))}
+		for i, toolResult := range results {
+			resultMessage.Parts[i] = Part{ToolResult: &toolResult}
+			if _, ok := toolMap[toolResult.Name]; ok {
+				usedToolNames[toolResult.Name] = struct{}{}
+			}
+		}
+
+		req.Messages = append(req.Messages, callMessage, resultMessage)
+	}
+
+	for _, tool := range tools {
+		if _, used := usedToolNames[tool.Definition().Name]; used {
+			result.UsedTools = append(result.UsedTools, tool)
+		}
+	}
+
+	return &result, nil
+}
+
+// executeTools runs all calls in parallel.
+func executeTools(toolMap map[string]Tool, calls []ToolCall) []ToolResult {
+	results := make([]ToolResult, len(calls))
+	var wg sync.WaitGroup
+
+	for i, call := range calls {
+		results[i] = ToolResult{CallID: call.ID, Name: call.Name}
+		tool, ok := toolMap[call.Name]
+		if !ok {
+			results[i].Text = fmt.Sprintf("Unknown tool: %s", call.Name)
+			continue
+		}
+
+		wg.Go(func() {
+			// A panic here would kill the whole process; the handler-level
+			// recover in bot/pipeline.go does not cover this goroutine.
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error().Interface("panic", r).Str("tool", call.Name).Msg("Tool panicked")
+					results[i].Text = fmt.Sprintf("Error: tool panicked: %v", r)
+				}
+			}()
+			output, err := tool.Execute(call.Arguments)
+			if err != nil {
+				results[i].Text = fmt.Sprintf("Error: %v", err)
+				return
+			}
+			results[i].Text = output.Text
+			results[i].Images = output.Images
+		})
+	}
+	wg.Wait()
+
+	return results
+}
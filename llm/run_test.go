@@ -0,0 +1,243 @@
+package llm
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Brawl345/gobot/utils/httpUtils"
+)
+
+type (
+	// fakeProvider returns the scripted responses in order and remembers the requests.
+	fakeProvider struct {
+		name      string
+		responses []*Response
+		err       error
+		requests  []Request
+	}
+
+	fakeTool struct {
+		name   string
+		output string
+		panics bool
+		calls  []string
+	}
+)
+
+func (p *fakeProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeProvider) Generate(req *Request) (*Response, error) {
+	req.Messages = slices.Clone(req.Messages)
+	p.requests = append(p.requests, *req)
+	if p.err != nil {
+		return nil, p.err
+	}
+	if len(p.responses) == 0 {
+		return &Response{Provider: p.name, Text: "fertig"}, nil
+	}
+	resp := p.responses[0]
+	p.responses = p.responses[1:]
+	resp.Provider = p.name
+	return resp, nil
+}
+
+func (t *fakeTool) Definition() ToolDefinition {
+	return ToolDefinition{Name: t.name, Description: "Test"}
+}
+
+func (t *fakeTool) Execute(arguments string) (ToolOutput, error) {
+	t.calls = append(t.calls, arguments)
+	if t.panics {
+		panic("kaputt")
+	}
+	return ToolOutput{Text: t.output}, nil
+}
+
+func (t *fakeTool) Emoji() string {
+	return "🔧"
+}
+
+func TestRunExecutesToolCalls(t *testing.T) {
+	weather := &fakeTool{name: "weather", output: "sonnig"}
+	unused := &fakeTool{name: "unused"}
+	provider := &fakeProvider{name: "fake", responses: []*Response{
+		{Text: "Moment", ToolCalls: []ToolCall{{ID: "1", Name: "weather", Arguments: `{"city":"Berlin"}`}}, Usage: Usage{InputTokens: 10, OutputTokens: 2}},
+		{Text: "Es ist sonnig.", Usage: Usage{InputTokens: 20, OutputTokens: 5}},
+	}}
+
+	var announced []ToolCall
+	result, err := Run(provider, Request{Messages: []Message{TextMessage(RoleUser, "Wetter?")}}, []Tool{unused, weather}, func(calls []ToolCall) {
+		announced = append(announced, calls...)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Text != "Es ist sonnig." || result.Provider != "fake" {
+		t.Errorf("got result %+v", result)
+	}
+	if result.Usage != (Usage{InputTokens: 30, OutputTokens: 7}) {
+		t.Errorf("usage must be summed up, got %+v", result.Usage)
+	}
+	if len(result.UsedTools) != 1 || result.UsedTools[0] != weather {
+		t.Errorf("got used tools %v", result.UsedTools)
+	}
+	if len(announced) != 1 || announced[0].Name != "weather" {
+		t.Errorf("onToolCalls got %v", announced)
+	}
+	if !slices.Equal(weather.calls, []string{`{"city":"Berlin"}`}) || len(unused.calls) != 0 {
+		t.Errorf("got tool calls %v and %v", weather.calls, unused.calls)
+	}
+
+	second := provider.requests[1]
+	if len(second.Messages) != 3 {
+		t.Fatalf("second request must contain the call and its result, got %d messages", len(second.Messages))
+	}
+	call, toolResult := second.Messages[1], second.Messages[2]
+	if call.Role != RoleAssistant || call.Parts[0].Text != "Moment" || call.Parts[1].ToolCall == nil {
+		t.Errorf("got call message %+v", call)
+	}
+	if toolResult.Role != RoleUser || toolResult.Parts[0].ToolResult == nil || toolResult.Parts[0].ToolResult.Text != "sonnig" {
+		t.Errorf("got result message %+v", toolResult)
+	}
+}
+
+func TestRunDoesNotModifyRequestMessages(t *testing.T) {
+	tool := &fakeTool{name: "tool"}
+	provider := &fakeProvider{name: "fake", responses: []*Response{
+		{ToolCalls: []ToolCall{{ID: "1", Name: "tool"}}},
+	}}
+	messages := make([]Message, 1, 10)
+	messages[0] = TextMessage(RoleUser, "Hallo")
+
+	if _, err := Run(provider, Request{Messages: messages}, []Tool{tool}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if extended := messages[:2]; extended[1].Role != "" {
+		t.Errorf("Run must not write into the backing array of the request messages, got %+v", extended[1])
+	}
+}
+
+func TestRunForcesTextAfterMaxRounds(t *testing.T) {
+	tool := &fakeTool{name: "loop"}
+	var responses []*Response
+	for range MaxToolCallRounds + 1 {
+		responses = append(responses, &Response{Text: "nochmal", ToolCalls: []ToolCall{{ID: "1", Name: "loop"}}})
+	}
+	provider := &fakeProvider{name: "fake", responses: responses}
+
+	result, err := Run(provider, Request{}, []Tool{tool}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(provider.requests) != MaxToolCallRounds+1 {
+		t.Fatalf("got %d requests, want %d", len(provider.requests), MaxToolCallRounds+1)
+	}
+	for i, req := range provider.requests {
+		if want := i == MaxToolCallRounds; req.ForceText != want {
+			t.Errorf("request %d: ForceText = %v, want %v", i, req.ForceText, want)
+		}
+	}
+	if len(tool.calls) != MaxToolCallRounds {
+		t.Errorf("tool calls of the forced round must not be executed, got %d calls", len(tool.calls))
+	}
+	if result.Text != "nochmal" {
+		t.Errorf("got text %q", result.Text)
+	}
+}
+
+func TestRunToolErrors(t *testing.T) {
+	panicking := &fakeTool{name: "panics", panics: true}
+	provider := &fakeProvider{name: "fake", responses: []*Response{
+		{ToolCalls: []ToolCall{{ID: "1", Name: "panics"}, {ID: "2", Name: "missing"}}},
+	}}
+
+	if _, err := Run(provider, Request{}, []Tool{panicking}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	results := provider.requests[1].Messages[1].Parts
+	if got := results[0].ToolResult.Text; !strings.HasPrefix(got, "Error: tool panicked") {
+		t.Errorf("panicking tool returned %q", got)
+	}
+	if got := results[1].ToolResult.Text; got != "Unknown tool: missing" {
+		t.Errorf("unknown tool returned %q", got)
+	}
+}
+
+func TestRunReturnsProviderError(t *testing.T) {
+	provider := &fakeProvider{name: "fake", err: errors.New("kaputt")}
+
+	if _, err := Run(provider, Request{}, nil, nil); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestFallback(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		wantProvider string
+		wantErr      bool
+	}{
+		{"server error", &httpUtils.HttpError{StatusCode: http.StatusServiceUnavailable}, "second", false},
+		{"rate limit", &httpUtils.HttpError{StatusCode: http.StatusTooManyRequests}, "second", false},
+		{"bad request", &httpUtils.HttpError{StatusCode: http.StatusBadRequest}, "", true},
+		{"other error", errors.New("kaputt"), "", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			first := &fakeProvider{name: "first", err: tc.err}
+			second := &fakeProvider{name: "second"}
+			provider := Fallback(first, second)
+
+			resp, err := provider.Generate(&Request{})
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				if len(second.requests) != 0 {
+					t.Error("must not fall back")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Provider != tc.wantProvider {
+				t.Errorf("got provider %q, want %q", resp.Provider, tc.wantProvider)
+			}
+
+			// Stays with the provider that worked
+			if _, err := provider.Generate(&Request{}); err != nil {
+				t.Fatal(err)
+			}
+			if len(first.requests) != 1 || len(second.requests) != 2 {
+				t.Errorf("got %d requests to first and %d to second", len(first.requests), len(second.requests))
+			}
+			if provider.Name() != "second" {
+				t.Errorf("got name %q", provider.Name())
+			}
+		})
+	}
+}
+
+func TestFallbackLastError(t *testing.T) {
+	first := &fakeProvider{name: "first", err: &httpUtils.HttpError{StatusCode: http.StatusServiceUnavailable}}
+	second := &fakeProvider{name: "second", err: &httpUtils.HttpError{StatusCode: http.StatusTooManyRequests}}
+
+	_, err := Fallback(first, second).Generate(&Request{})
+
+	httpError, ok := errors.AsType[*httpUtils.HttpError](err)
+	if !ok || httpError.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected the error of the last provider, got %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"os"
 	"os/signal"
 	"strconv"
@@ -12,6 +13,7 @@ import (
 	"github.com/Brawl345/gobot/bot"
 	"github.com/Brawl345/gobot/logger"
 	"github.com/Brawl345/gobot/model/sql"
+	"github.com/jmoiron/sqlx"
 )
 
 var log = logger.New("main")
@@ -38,6 +40,11 @@ func main() {
 		log.Fatal().Err(err).Send()
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		replay(db, os.Args[2:])
+		return
+	}
+
 	b, err := bot.New(db)
 	if err != nil {
 		log.Fatal().Err(err).Send()
@@ -57,3 +64,19 @@ func main() {
 
 	b.Start()
 }
+
+// replay processes updates recorded with RECORD_UPDATES without connecting to Telegram.
+// Usage: gobot replay [-username name] <file>
+func replay(db *sqlx.DB, args []string) {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	username := flags.String("username", "gobot", "username of the bot that received the updates")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		log.Fatal().Msg("Usage: gobot replay [-username name] <file>")
+	}
+
+	if err := bot.Replay(db, flags.Arg(0), *username); err != nil {
+		log.Fatal().Err(err).Send()
+	}
+}
@@ -0,0 +1,215 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Brawl345/gobot/utils/httpUtils"
+)
+
+const (
+	headerSessionID       = "Mcp-Session-Id"
+	headerProtocolVersion = "MCP-Protocol-Version"
+)
+
+// httpTransport is the streamable HTTP transport. Every message is POSTed, the server answers
+// either with JSON or with an event stream that ends with the response.
+type httpTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+
+	mu              sync.Mutex
+	sessionID       string
+	protocolVersion string
+}
+
+// NewHTTPTransport connects to the endpoint, headers are sent with every request (e.g. for authorization).
+func NewHTTPTransport(url string, headers map[string]string) Transport {
+	return &httpTransport{
+		url:     url,
+		headers: headers,
+		// No timeout of its own, the context decides how long a tool call may take
+		client: httpUtils.NewHTTPClientWithTimeout(0),
+	}
+}
+
+func (t *httpTransport) newRequest(ctx context.Context, method string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, t.url, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sessionID != "" {
+		req.Header.Set(headerSessionID, t.sessionID)
+	}
+	if t.protocolVersion != "" {
+		req.Header.Set(headerProtocolVersion, t.protocolVersion)
+	}
+	return req, nil
+}
+
+// post sends the message and remembers the session the server assigns. The caller has to close the body.
+func (t *httpTransport) post(ctx context.Context, msg *message) (*http.Response, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := t.newRequest(ctx, http.MethodPost, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, &httpUtils.HttpError{StatusCode: resp.StatusCode}
+	}
+	if sessionID := resp.Header.Get(headerSessionID); sessionID != "" {
+		t.mu.Lock()
+		t.sessionID = sessionID
+		t.mu.Unlock()
+	}
+	return resp, nil
+}
+
+// send posts a notification or a response, the server only acknowledges it with 202 Accepted.
+func (t *httpTransport) send(ctx context.Context, msg *message) error {
+	resp, err := t.post(ctx, msg)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (t *httpTransport) roundTrip(ctx context.Context, msg *message) (*message, error) {
+	if msg.ID == nil {
+		return nil, t.send(ctx, msg)
+	}
+
+	resp, err := t.post(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var response *message
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		response = &message{}
+		err = json.NewDecoder(io.LimitReader(resp.Body, httpUtils.MaxResponseBodySize)).Decode(response)
+	case "text/event-stream":
+		response, err = t.readEventStream(ctx, resp.Body, msg.ID)
+	default:
+		err = fmt.Errorf("unexpected content type: %s", mediaType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if msg.Method == methodInitialize && response.Error == nil {
+		var result initializeResult
+		if err := json.Unmarshal(response.Result, &result); err == nil {
+			t.mu.Lock()
+			t.protocolVersion = result.ProtocolVersion
+			t.mu.Unlock()
+		}
+	}
+	return response, nil
+}
+
+// readEventStream reads server-sent events until the response with the id arrives.
+// Requests of the server in between are answered with a separate POST.
+func (t *httpTransport) readEventStream(ctx context.Context, body io.Reader, id json.RawMessage) (*message, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), httpUtils.MaxResponseBodySize)
+
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			data.WriteString(strings.TrimPrefix(value, " "))
+			continue
+		}
+		if line != "" || data.Len() == 0 {
+			continue
+		}
+
+		// An empty line ends the event
+		var msg message
+		err := json.Unmarshal([]byte(data.String()), &msg)
+		data.Reset()
+		if err != nil {
+			log.Warn().Err(err).Msg("Got invalid event from MCP server")
+			continue
+		}
+
+		if msg.Method != "" {
+			if msg.ID != nil {
+				if err := t.send(ctx, serverRequestResponse(&msg)); err != nil {
+					log.Err(err).Str("method", msg.Method).Msg("Failed to answer request of MCP server")
+				}
+			}
+			continue
+		}
+		if bytes.Equal(msg.ID, id) {
+			return &msg, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("event stream ended without a response")
+}
+
+// close ends the session if the server created one.
+func (t *httpTransport) close() error {
+	t.mu.Lock()
+	hasSession := t.sessionID != ""
+	t.mu.Unlock()
+	if !hasSession {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := t.newRequest(ctx, http.MethodDelete, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Servers may not allow clients to end sessions
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusMethodNotAllowed {
+		return &httpUtils.HttpError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHTTPTransport(t *testing.T) {
+	pong := make(chan message, 1)
+	var mu sync.Mutex
+	var sessionIDs []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg message
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("got invalid message: %v", err)
+			return
+		}
+		mu.Lock()
+		sessionIDs = append(sessionIDs, r.Header.Get(headerSessionID))
+		mu.Unlock()
+
+		switch {
+		case msg.Method == methodInitialize:
+			w.Header().Set(headerSessionID, "session-1")
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"protocolVersion":"2025-06-18","serverInfo":{"name":"fake"}}}`, msg.ID)
+		case msg.Method == "":
+			// The response to the ping, a conforming server only acknowledges it
+			pong <- msg
+			w.WriteHeader(http.StatusAccepted)
+		case msg.ID == nil:
+			w.WriteHeader(http.StatusAccepted)
+		case msg.Method == methodToolsCall:
+			if r.Header.Get(headerProtocolVersion) != ProtocolVersion {
+				t.Errorf("got protocol version %q", r.Header.Get(headerProtocolVersion))
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = fmt.Fprint(w, "data: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"}\n\n")
+			_, _ = fmt.Fprint(w, "data: {\"jsonrpc\":\"2.0\",\"id\":\"ping-1\",\"method\":\"ping\"}\n\n")
+			w.(http.Flusher).Flush()
+
+			select {
+			case <-pong:
+			case <-time.After(5 * time.Second):
+				t.Error("the ping must be answered while the stream is open")
+			}
+			_, _ = fmt.Fprintf(w, "data: {\"jsonrpc\":\"2.0\",\"id\":%s,\n", msg.ID)
+			_, _ = fmt.Fprint(w, "data: \"result\":{\"content\":[{\"type\":\"text\",\"text\":\"Hallo\"}]}}\n\n")
+		default:
+			http.Error(w, "unexpected", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := Connect(ctx, NewHTTPTransport(server.URL, nil), Implementation{Name: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := client.CallTool(ctx, "echo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Content) != 1 || result.Content[0].Text != "Hallo" {
+		t.Errorf("got result %+v", result)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for i, sessionID := range sessionIDs[1:] {
+		if sessionID != "session-1" {
+			t.Errorf("request %d was sent without the session, got %q", i+1, sessionID)
+		}
+	}
+}
+
+func TestHTTPTransportError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := Connect(ctx, NewHTTPTransport(server.URL, nil), Implementation{Name: "test"}); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,225 @@
+// Package mcp is a minimal Model Context Protocol client that can list and call the tools of a server.
+// https://modelcontextprotocol.io/specification/2025-06-18
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/Brawl345/gobot/logger"
+)
+
+const (
+	ProtocolVersion = "2025-06-18"
+	jsonRPCVersion  = "2.0"
+
+	methodInitialize  = "initialize"
+	methodInitialized = "notifications/initialized"
+	methodPing        = "ping"
+	methodToolsList   = "tools/list"
+	methodToolsCall   = "tools/call"
+
+	codeMethodNotFound = -32601
+	maxToolPages       = 10
+)
+
+var log = logger.New("mcp")
+
+type (
+	// Transport sends JSON-RPC messages to a server, see NewStdioTransport and NewHTTPTransport.
+	Transport interface {
+		// roundTrip sends the message and waits for the response. Notifications return nil.
+		roundTrip(ctx context.Context, msg *message) (*message, error)
+		close() error
+	}
+
+	Client struct {
+		transport  Transport
+		nextID     atomic.Int64
+		ServerInfo Implementation
+	}
+
+	Implementation struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+
+	Tool struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description"`
+		InputSchema json.RawMessage `json:"inputSchema"`
+	}
+
+	CallToolResult struct {
+		Content []Content `json:"content"`
+		IsError bool      `json:"isError"`
+	}
+
+	// Content of a tool result, Type is "text", "image", "audio", "resource" or "resource_link".
+	Content struct {
+		Type     string    `json:"type"`
+		Text     string    `json:"text,omitempty"`
+		Data     string    `json:"data,omitempty"` // Base64 for images and audio
+		MimeType string    `json:"mimeType,omitempty"`
+		URI      string    `json:"uri,omitempty"` // Resource links
+		Resource *Resource `json:"resource,omitempty"`
+	}
+
+	// Resource is embedded in a tool result, only text resources are read.
+	Resource struct {
+		URI      string `json:"uri"`
+		MimeType string `json:"mimeType,omitempty"`
+		Text     string `json:"text,omitempty"`
+	}
+
+	// Error is returned by the server.
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+
+	message struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id,omitempty"`
+		Method  string          `json:"method,omitempty"`
+		Params  json.RawMessage `json:"params,omitempty"`
+		Result  json.RawMessage `json:"result,omitempty"`
+		Error   *Error          `json:"error,omitempty"`
+	}
+
+	initializeParams struct {
+		ProtocolVersion string         `json:"protocolVersion"`
+		Capabilities    struct{}       `json:"capabilities"`
+		ClientInfo      Implementation `json:"clientInfo"`
+	}
+
+	initializeResult struct {
+		ProtocolVersion string         `json:"protocolVersion"`
+		ServerInfo      Implementation `json:"serverInfo"`
+	}
+
+	listToolsParams struct {
+		Cursor string `json:"cursor,omitempty"`
+	}
+
+	listToolsResult struct {
+		Tools      []Tool `json:"tools"`
+		NextCursor string `json:"nextCursor"`
+	}
+
+	callToolParams struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+)
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("MCP error %d: %s", e.Code, e.Message)
+}
+
+// Connect initializes the connection. The transport is closed if that fails.
+func Connect(ctx context.Context, transport Transport, clientInfo Implementation) (*Client, error) {
+	client := &Client{transport: transport}
+
+	var result initializeResult
+	err := client.call(ctx, methodInitialize, initializeParams{
+		ProtocolVersion: ProtocolVersion,
+		ClientInfo:      clientInfo,
+	}, &result)
+	if err == nil {
+		err = client.notify(ctx, methodInitialized)
+	}
+	if err != nil {
+		if closeErr := transport.close(); closeErr != nil {
+			log.Err(closeErr).Msg("Failed to close transport")
+		}
+		return nil, fmt.Errorf("failed to initialize: %w", err)
+	}
+
+	log.Debug().
+		Str("server", result.ServerInfo.Name).
+		Str("version", result.ServerInfo.Version).
+		Str("protocol_version", result.ProtocolVersion).
+		Msg("Connected to MCP server")
+	client.ServerInfo = result.ServerInfo
+	return client, nil
+}
+
+// ListTools returns all tools of the server.
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	var tools []Tool
+	cursor := ""
+	for range maxToolPages {
+		var result listToolsResult
+		if err := c.call(ctx, methodToolsList, listToolsParams{Cursor: cursor}, &result); err != nil {
+			return nil, err
+		}
+		tools = append(tools, result.Tools...)
+		if result.NextCursor == "" {
+			break
+		}
+		cursor = result.NextCursor
+	}
+	return tools, nil
+}
+
+// CallTool calls the tool, errors of the tool itself are reported with CallToolResult.IsError.
+func (c *Client) CallTool(ctx context.Context, name string, arguments json.RawMessage) (*CallToolResult, error) {
+	if len(arguments) == 0 {
+		arguments = json.RawMessage("{}")
+	}
+	var result CallToolResult
+	if err := c.call(ctx, methodToolsCall, callToolParams{Name: name, Arguments: arguments}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) Close() error {
+	return c.transport.close()
+}
+
+func (c *Client) call(ctx context.Context, method string, params any, result any) error {
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	response, err := c.transport.roundTrip(ctx, &message{
+		JSONRPC: jsonRPCVersion,
+		ID:      json.RawMessage(strconv.FormatInt(c.nextID.Add(1), 10)),
+		Method:  method,
+		Params:  rawParams,
+	})
+	if err != nil {
+		return err
+	}
+	if response == nil {
+		return errors.New("got no response")
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	return json.Unmarshal(response.Result, result)
+}
+
+func (c *Client) notify(ctx context.Context, method string) error {
+	_, err := c.transport.roundTrip(ctx, &message{JSONRPC: jsonRPCVersion, Method: method})
+	return err
+}
+
+// serverRequestResponse answers requests the server sends to the client. Only pings are supported
+// because the client doesn't declare any capabilities.
+func serverRequestResponse(request *message) *message {
+	response := &message{JSONRPC: jsonRPCVersion, ID: request.ID}
+	if request.Method == methodPing {
+		response.Result = json.RawMessage("{}")
+	} else {
+		response.Error = &Error{Code: codeMethodNotFound, Message: "method not found"}
+	}
+	return response
+}
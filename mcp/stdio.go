@@ -0,0 +1,205 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// stdioCloseTimeout is how long the server gets to exit after its input is closed
+const stdioCloseTimeout = 5 * time.Second
+
+var errClosed = errors.New("connection closed")
+
+// stdioTransport runs the server as a subprocess that exchanges newline-delimited JSON on stdin and stdout.
+type stdioTransport struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[string]chan *message
+	done    chan struct{}
+	err     error // Why the connection was closed, set before done is closed
+}
+
+// stdioBaseEnv is passed to servers that don't inherit the whole environment of the bot.
+var stdioBaseEnv = []string{"PATH", "HOME", "LANG"}
+
+// NewStdioTransport starts the command with PATH, HOME and LANG plus env. With inheritEnv, the
+// server gets the whole environment of the bot instead, including its credentials.
+func NewStdioTransport(command []string, env map[string]string, inheritEnv bool) (Transport, error) {
+	if len(command) == 0 {
+		return nil, errors.New("command is empty")
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Env = stdioEnv(env, inheritEnv)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", command[0], err)
+	}
+
+	t := newStdioTransport(stdin, stdout)
+	t.cmd = cmd
+	return t, nil
+}
+
+// newStdioTransport exchanges messages over the pipes, cmd has to be set if they belong to a subprocess.
+func newStdioTransport(stdin io.WriteCloser, stdout io.Reader) *stdioTransport {
+	t := &stdioTransport{
+		stdin:   stdin,
+		pending: make(map[string]chan *message),
+		done:    make(chan struct{}),
+	}
+	go t.read(stdout)
+	return t
+}
+
+func stdioEnv(env map[string]string, inheritEnv bool) []string {
+	var result []string
+	if inheritEnv {
+		result = os.Environ()
+	} else {
+		for _, key := range stdioBaseEnv {
+			if value, ok := os.LookupEnv(key); ok {
+				result = append(result, fmt.Sprintf("%s=%s", key, value))
+			}
+		}
+	}
+	for key, value := range env {
+		result = append(result, fmt.Sprintf("%s=%s", key, value))
+	}
+	return result
+}
+
+func (t *stdioTransport) read(stdout io.Reader) {
+	reader := bufio.NewReader(stdout)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			t.handle(line)
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = errClosed
+			}
+			t.mu.Lock()
+			t.err = err
+			t.mu.Unlock()
+			close(t.done)
+			return
+		}
+	}
+}
+
+func (t *stdioTransport) handle(line []byte) {
+	var msg message
+	if err := json.Unmarshal(line, &msg); err != nil {
+		log.Warn().Err(err).Bytes("line", line).Msg("Got invalid message from MCP server")
+		return
+	}
+
+	if msg.Method != "" {
+		// Notifications need no answer
+		if msg.ID != nil {
+			if err := t.write(serverRequestResponse(&msg)); err != nil {
+				log.Err(err).Str("method", msg.Method).Msg("Failed to answer request of MCP server")
+			}
+		}
+		return
+	}
+
+	t.mu.Lock()
+	ch, ok := t.pending[string(msg.ID)]
+	delete(t.pending, string(msg.ID))
+	t.mu.Unlock()
+	if ok {
+		ch <- &msg
+	}
+}
+
+func (t *stdioTransport) write(msg *message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	_, err = t.stdin.Write(append(data, '\n'))
+	return err
+}
+
+func (t *stdioTransport) roundTrip(ctx context.Context, msg *message) (*message, error) {
+	if msg.ID == nil {
+		return nil, t.write(msg)
+	}
+
+	ch := make(chan *message, 1)
+	t.mu.Lock()
+	t.pending[string(msg.ID)] = ch
+	t.mu.Unlock()
+
+	defer func() {
+		t.mu.Lock()
+		delete(t.pending, string(msg.ID))
+		t.mu.Unlock()
+	}()
+
+	if err := t.write(msg); err != nil {
+		return nil, err
+	}
+
+	select {
+	case response := <-ch:
+		return response, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-t.done:
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return nil, t.err
+	}
+}
+
+// close closes the input of the server, which should make it exit, and kills it if it doesn't.
+func (t *stdioTransport) close() error {
+	if err := t.stdin.Close(); err != nil {
+		log.Err(err).Msg("Failed to close stdin of MCP server")
+	}
+	if t.cmd == nil {
+		return nil
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- t.cmd.Wait()
+	}()
+
+	select {
+	case err := <-exited:
+		return err
+	case <-time.After(stdioCloseTimeout):
+		if err := t.cmd.Process.Kill(); err != nil {
+			return err
+		}
+		return <-exited
+	}
+}
@@ -0,0 +1,143 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+	"time"
+)
+
+// fakeStdioServer answers the requests of the client in-process, handler returns the result of a request.
+// Before every answer to tools/call, the server pings the client and expects a response.
+func fakeStdioServer(t *testing.T, handler func(msg *message) json.RawMessage) *stdioTransport {
+	t.Helper()
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+	transport := newStdioTransport(clientWriter, clientReader)
+	t.Cleanup(func() {
+		_ = transport.close()
+		_ = serverWriter.Close()
+	})
+
+	go func() {
+		encoder := json.NewEncoder(serverWriter)
+		scanner := bufio.NewScanner(serverReader)
+		for scanner.Scan() {
+			var msg message
+			if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+				t.Errorf("got invalid message %s", scanner.Bytes())
+				return
+			}
+			if msg.ID == nil {
+				continue
+			}
+
+			if msg.Method == methodToolsCall {
+				_ = encoder.Encode(&message{JSONRPC: jsonRPCVersion, ID: json.RawMessage(`"ping-1"`), Method: methodPing})
+				if !scanner.Scan() {
+					return
+				}
+				var pong message
+				if err := json.Unmarshal(scanner.Bytes(), &pong); err != nil || string(pong.ID) != `"ping-1"` || pong.Error != nil {
+					t.Errorf("the ping must be answered, got %s", scanner.Bytes())
+				}
+			}
+			_ = encoder.Encode(&message{JSONRPC: jsonRPCVersion, ID: msg.ID, Result: handler(&msg)})
+		}
+	}()
+	return transport
+}
+
+func TestStdioTransport(t *testing.T) {
+	transport := fakeStdioServer(t, func(msg *message) json.RawMessage {
+		switch msg.Method {
+		case methodInitialize:
+			return json.RawMessage(`{"protocolVersion":"2025-06-18","serverInfo":{"name":"fake","version":"1"}}`)
+		case methodToolsList:
+			return json.RawMessage(`{"tools":[{"name":"echo"}]}`)
+		default:
+			var params callToolParams
+			_ = json.Unmarshal(msg.Params, &params)
+			return json.RawMessage(`{"content":[{"type":"text","text":` + string(params.Arguments) + `}]}`)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := Connect(ctx, transport, Implementation{Name: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.ServerInfo.Name != "fake" {
+		t.Errorf("got server info %+v", client.ServerInfo)
+	}
+
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tools) != 1 || tools[0].Name != "echo" {
+		t.Errorf("got tools %+v", tools)
+	}
+
+	result, err := client.CallTool(ctx, "echo", json.RawMessage(`"Hallo"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Content) != 1 || result.Content[0].Text != "Hallo" {
+		t.Errorf("got result %+v", result)
+	}
+}
+
+func TestStdioTransportClosed(t *testing.T) {
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+	transport := newStdioTransport(clientWriter, clientReader)
+
+	// The server exits after reading the request
+	go func() {
+		_, _ = bufio.NewReader(serverReader).ReadBytes('\n')
+		_ = serverWriter.Close()
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := transport.roundTrip(context.Background(), &message{JSONRPC: jsonRPCVersion, ID: json.RawMessage("1"), Method: methodPing})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, errClosed) {
+			t.Errorf("expected errClosed, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pending requests must fail when the server exits")
+	}
+}
+
+func TestStdioEnv(t *testing.T) {
+	t.Setenv("PATH", "/bin")
+	t.Setenv("HOME", "/home/bot")
+	t.Setenv("GOBOT_SECRET", "geheim")
+
+	env := stdioEnv(map[string]string{"TOKEN": "abc"}, false)
+	if slices.Contains(env, "GOBOT_SECRET=geheim") {
+		t.Error("the environment of the bot must not be passed by default")
+	}
+	for _, want := range []string{"PATH=/bin", "HOME=/home/bot", "TOKEN=abc"} {
+		if !slices.Contains(env, want) {
+			t.Errorf("%s is missing in %v", want, env)
+		}
+	}
+
+	env = stdioEnv(map[string]string{"TOKEN": "abc"}, true)
+	if !slices.Contains(env, "GOBOT_SECRET=geheim") || !slices.Contains(env, "TOKEN=abc") {
+		t.Errorf("the environment of the bot must be passed with inheritEnv, got %v", env)
+	}
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+type (
+	// AuditService records privileged operations like enabling plugins or allowing users.
+	AuditService interface {
+		// Record stores who did what. Errors are only logged since a failing audit log must not block administration.
+		Record(actor *gotgbot.User, chat *gotgbot.Chat, action string, target string)
+		// Entries returns the newest entries first. The filter is matched against action, target and actor,
+		// an empty filter returns everything. A limit of 0 means no limit.
+		Entries(filter string, limit int) ([]AuditEntry, error)
+	}
+
+	AuditEntry struct {
+		ID        int64         `db:"id"`
+		CreatedAt time.Time     `db:"created_at"`
+		ActorID   int64         `db:"actor_id"`
+		ActorName string        `db:"actor_name"`
+		ChatID    sql.NullInt64 `db:"chat_id"`
+		Action    string        `db:"action"`
+		Target    string        `db:"target"`
+	}
+)
+
+// Actions recorded in the audit log
+const (
+	AuditPluginEnable       = "plugin.enable"
+	AuditPluginDisable      = "plugin.disable"
+	AuditPluginEnableChat   = "plugin.enable_chat"
+	AuditPluginDisableChat  = "plugin.disable_chat"
+	AuditPluginEnableTopic  = "plugin.enable_topic"
+	AuditPluginDisableTopic = "plugin.disable_topic"
+	AuditUserAllow          = "user.allow"
+	AuditUserDeny           = "user.deny"
+	AuditChatAllow          = "chat.allow"
+	AuditChatDeny           = "chat.deny"
+	AuditCredentialSet      = "credential.set"
+	AuditCredentialDelete   = "credential.delete"
+	AuditRandomAdd          = "random.add"
+	AuditRandomDelete       = "random.delete"
+	AuditMessageDelete      = "message.delete"
+	AuditSettingSet         = "setting.set"
+	AuditSettingReset       = "setting.reset"
+	AuditPersonaSet         = "persona.set"
+	AuditPersonaReset       = "persona.reset"
+	AuditPersonaRollback    = "persona.rollback"
+	AuditMemoryAdd          = "memory.add"
+	AuditMemoryEdit         = "memory.edit"
+	AuditMemoryDelete       = "memory.delete"
+	AuditMemoryClear        = "memory.clear"
+)
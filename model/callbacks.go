@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+// CallbackService stores payloads of inline keyboard buttons, since callback data is limited to 64 bytes.
+// Buttons only carry a short token that is resolved here.
+type CallbackService interface {
+	// Create stores the payload as JSON. If owner is not nil, only they may press the button.
+	Create(payload any, owner *gotgbot.User, ttl time.Duration) (string, error)
+	// Get decodes the payload into v. Returns ErrNotFound if the token doesn't exist or is expired
+	// and ErrNotOwner if the button belongs to someone else.
+	Get(token string, user *gotgbot.User, v any) error
+	Delete(token string) error
+	Cleanup() error
+}
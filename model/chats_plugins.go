@@ -4,8 +4,10 @@ import (
 	"github.com/PaulSonOfLars/gotgbot/v2"
 )
 
+// ChatsPluginsService stores plugins that are disabled for chats or single forum topics.
+// A threadID of 0 stands for the whole chat.
 type ChatsPluginsService interface {
-	Disable(chat *gotgbot.Chat, pluginName string) error
-	Enable(chat *gotgbot.Chat, pluginName string) error
-	GetAllDisabled() (map[int64][]string, error)
+	Disable(chat *gotgbot.Chat, threadID int64, pluginName string) error
+	Enable(chat *gotgbot.Chat, threadID int64, pluginName string) error
+	GetAllDisabled() (map[Topic][]string, error)
 }
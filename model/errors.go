@@ -4,6 +4,8 @@ import "errors"
 
 var (
 	ErrAlreadyExists = errors.New("record already exists")
+	ErrLimitReached  = errors.New("limit reached")
 	ErrNotFound      = errors.New("record not found")
+	ErrNotOwner      = errors.New("user is not the owner")
 	ErrQueryNotFound = errors.New("query not found")
 )
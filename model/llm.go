@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// LLMConversation is a conversation with the AI, it is continued by replying to one of the bot's answers.
+type LLMConversation struct {
+	ID            int64     `db:"id"`
+	ChatID        int64     `db:"chat_id"`
+	History       string    `db:"history"`
+	HistoryLength int       `db:"history_length"` // Number of messages in the history up to the answer that was replied to
+	ExpiresOn     time.Time `db:"expires_on"`
+}
+
+// LLMMemory is a fact the AI remembers about a chat beyond single conversations.
+type LLMMemory struct {
+	ID        int64     `db:"id"`
+	Content   string    `db:"content"`
+	CreatedAt time.Time `db:"created_at"`
+}
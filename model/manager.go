@@ -13,4 +13,8 @@ type ManagerService interface {
 	DisablePluginForChat(chat *gotgbot.Chat, name string) error
 	IsPluginEnabled(name string) bool
 	IsPluginDisabledForChat(chat *gotgbot.Chat, name string) bool
+	EnablePluginForTopic(chat *gotgbot.Chat, threadID int64, name string) error
+	DisablePluginForTopic(chat *gotgbot.Chat, threadID int64, name string) error
+	IsPluginDisabledForTopic(chat *gotgbot.Chat, threadID int64, name string) bool
+	MissingCredentials(plg plugin.Plugin) (required []string, optional []string)
 }
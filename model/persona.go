@@ -0,0 +1,18 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Persona configures the AI chat for a group. Every change is stored as a new version.
+type Persona struct {
+	Version       int             `db:"version"`
+	CreatedAt     time.Time       `db:"created_at"`
+	CreatedBy     sql.NullInt64   `db:"created_by"`
+	CreatedByName sql.NullString  `db:"created_by_name"`
+	SystemPrompt  sql.NullString  `db:"system_prompt"`
+	Temperature   sql.NullFloat64 `db:"temperature"`
+	Language      sql.NullString  `db:"language"`
+	Tools         sql.NullString  `db:"tools"` // Comma-separated tool names, NULL means all
+}
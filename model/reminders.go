@@ -8,6 +8,7 @@ import (
 type Reminder struct {
 	ID       int64         `db:"id"`
 	ChatID   sql.NullInt64 `db:"chat_id"`
+	ThreadID int64         `db:"thread_id"`
 	UserID   int64         `db:"user_id"`
 	Username string        `db:"username"`
 	Time     time.Time     `db:"time"`
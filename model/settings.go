@@ -0,0 +1,43 @@
+package model
+
+import (
+	"github.com/Brawl345/gobot/plugin"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+type (
+	// SettingsService stores the values of the settings plugins declare via plugin.SettingsProvider.
+	// Getters return the default if the setting was never changed.
+	SettingsService interface {
+		Get(scope SettingsScope, pluginName string, setting plugin.Setting) string
+		GetBool(scope SettingsScope, pluginName string, setting plugin.Setting) bool
+		GetInt(scope SettingsScope, pluginName string, setting plugin.Setting) int64
+		// Set validates the value with plugin.Setting.Normalize before storing it
+		Set(scope SettingsScope, pluginName string, setting plugin.Setting, value string) error
+		Reset(scope SettingsScope, pluginName string, key string) error
+	}
+
+	// SettingsScope is the chat or the user a setting belongs to, the other ID is 0.
+	SettingsScope struct {
+		ChatID int64 `db:"chat_id"`
+		UserID int64 `db:"user_id"`
+	}
+
+	Setting struct {
+		SettingsScope
+		PluginName string `db:"plugin_name"`
+		Key        string `db:"key"`
+		Value      string `db:"value"`
+	}
+)
+
+// ScopeOf returns where the setting is stored for the chat and user, depending on plugin.Setting.PerUser.
+func ScopeOf(setting plugin.Setting, chat *gotgbot.Chat, user *gotgbot.User) SettingsScope {
+	if setting.PerUser {
+		if user == nil {
+			return SettingsScope{}
+		}
+		return SettingsScope{UserID: user.Id}
+	}
+	return SettingsScope{ChatID: chat.Id}
+}
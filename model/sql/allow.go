@@ -39,6 +39,10 @@ func NewAllowService(chatService model.ChatService, userService model.UserServic
 }
 
 func (service *allowService) IsUserAllowed(user *gotgbot.User) bool {
+	if user == nil {
+		return false
+	}
+
 	if tgUtils.IsAdmin(user) {
 		return true
 	}
@@ -0,0 +1,87 @@
+package sql
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Brawl345/gobot/logger"
+	"github.com/Brawl345/gobot/model"
+	"github.com/Brawl345/gobot/utils"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type auditService struct {
+	*sqlx.DB
+	log *logger.Logger
+}
+
+func NewAuditService(db *sqlx.DB) *auditService {
+	return &auditService{
+		DB:  db,
+		log: logger.New("auditService"),
+	}
+}
+
+func (db *auditService) Record(actor *gotgbot.User, chat *gotgbot.Chat, action string, target string) {
+	var (
+		actorID   int64
+		actorName string
+		chatID    *int64
+	)
+	if actor != nil {
+		actorID = actor.Id
+		actorName = strings.TrimSpace(actor.FirstName + " " + actor.LastName)
+	}
+	if chat != nil {
+		chatID = &chat.Id
+	}
+	target = utils.TruncateText(target, 255)
+
+	db.log.Info().
+		Int64("actor_id", actorID).
+		Str("action", action).
+		Str("target", target).
+		Msg("Audit")
+
+	const query = `INSERT INTO audit_log (actor_id, actor_name, chat_id, action, target) VALUES (?, ?, ?, ?, ?)`
+	_, err := db.Exec(query, actorID, actorName, chatID, action, target)
+	if err != nil {
+		db.log.Err(err).
+			Int64("actor_id", actorID).
+			Str("action", action).
+			Str("target", target).
+			Msg("Failed to write audit log")
+	}
+}
+
+func (db *auditService) Entries(filter string, limit int) ([]model.AuditEntry, error) {
+	query := `SELECT id, created_at, actor_id, actor_name, chat_id, action, target FROM audit_log`
+	var args []any
+
+	if filter != "" {
+		query += ` WHERE action LIKE ? OR target LIKE ? OR actor_name LIKE ?`
+		pattern := "%" + escapeLike(filter) + "%"
+		args = append(args, pattern, pattern, pattern)
+		if actorID, err := strconv.ParseInt(filter, 10, 64); err == nil {
+			query += ` OR actor_id = ? OR chat_id = ?`
+			args = append(args, actorID, actorID)
+		}
+	}
+
+	query += ` ORDER BY id DESC`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	var entries []model.AuditEntry
+	err := db.Select(&entries, query, args...)
+	return entries, err
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -21,35 +21,11 @@ func NewBirthdayService(db *sqlx.DB) *birthdayService {
 	}
 }
 
-func (db *birthdayService) BirthdayNotificationsEnabled(chat *gotgbot.Chat) (bool, error) {
-	const query = `SELECT birthday_notifications_enabled FROM chats WHERE id = ?`
-	var enabled bool
-	err := db.Get(&enabled, query, chat.Id)
-	return enabled, err
-}
-
-func (db *birthdayService) EnableBirthdayNotifications(chat *gotgbot.Chat) error {
-	const query = `UPDATE chats SET birthday_notifications_enabled = true
-	WHERE id = ? AND birthday_notifications_enabled = false`
-	res, err := db.Exec(query, chat.Id)
-	if err != nil {
-		return err
-	}
-
-	rows, err := res.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rows == 0 {
-		return model.ErrAlreadyExists
-	}
-	return nil
-}
-
-func (db *birthdayService) DisableBirthdayNotifications(chat *gotgbot.Chat) error {
-	const query = `UPDATE chats SET birthday_notifications_enabled = false
-	WHERE id = ? AND birthday_notifications_enabled = true`
-	res, err := db.Exec(query, chat.Id)
+// SetBirthdayThread sets the topic the notifications are sent to.
+// Returns model.ErrAlreadyExists if they are sent there already.
+func (db *birthdayService) SetBirthdayThread(chat *gotgbot.Chat, threadID int64) error {
+	const query = `UPDATE chats SET birthday_thread_id = ? WHERE id = ? AND birthday_thread_id != ?`
+	res, err := db.Exec(query, threadID, chat.Id, threadID)
 	if err != nil {
 		return err
 	}
@@ -88,15 +64,14 @@ func (db *birthdayService) Birthdays(chat *gotgbot.Chat) ([]model.User, error) {
 	return users, err
 }
 
-func (db *birthdayService) TodaysBirthdays() (map[int64][]model.User, error) {
-	const query = `SELECT u.first_name, u.last_name, u.birthday, cu.chat_id FROM chats_users cu
+func (db *birthdayService) TodaysBirthdays() (map[model.Topic][]model.User, error) {
+	const query = `SELECT u.first_name, u.last_name, u.birthday, cu.chat_id, c.birthday_thread_id FROM chats_users cu
 	LEFT JOIN users u ON u.id = cu.user_id
 	LEFT JOIN chats c ON c.id = cu.chat_id
-	WHERE c.birthday_notifications_enabled = true
-  	AND cu.in_group = true
+	WHERE cu.in_group = true
 	AND DAYOFMONTH(u.birthday) = DAYOFMONTH(NOW())
 	AND MONTH(u.birthday) = MONTH(NOW())`
-	birthdayList := make(map[int64][]model.User)
+	birthdayList := make(map[model.Topic][]model.User)
 
 	rows, err := db.Queryx(query)
 	if err != nil {
@@ -111,14 +86,14 @@ func (db *birthdayService) TodaysBirthdays() (map[int64][]model.User, error) {
 	}(rows)
 
 	for rows.Next() {
-		var chatID int64
+		var topic model.Topic
 		var user model.User
-		err := rows.Scan(&user.FirstName, &user.LastName, &user.Birthday, &chatID)
+		err := rows.Scan(&user.FirstName, &user.LastName, &user.Birthday, &topic.ChatID, &topic.ThreadID)
 		if err != nil {
 			return nil, err
 		}
 
-		birthdayList[chatID] = append(birthdayList[chatID], user)
+		birthdayList[topic] = append(birthdayList[topic], user)
 	}
 
 	if err := rows.Err(); err != nil {
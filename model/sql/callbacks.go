@@ -0,0 +1,77 @@
+package sql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Brawl345/gobot/logger"
+	"github.com/Brawl345/gobot/model"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/xid"
+)
+
+type callbackService struct {
+	*sqlx.DB
+	log *logger.Logger
+}
+
+func NewCallbackService(db *sqlx.DB) *callbackService {
+	return &callbackService{
+		DB:  db,
+		log: logger.New("callbackService"),
+	}
+}
+
+func (db *callbackService) Create(payload any, owner *gotgbot.User, ttl time.Duration) (string, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	var ownerID sql.NullInt64
+	if owner != nil {
+		ownerID = sql.NullInt64{Int64: owner.Id, Valid: true}
+	}
+
+	token := xid.New().String()
+	const query = `INSERT INTO callbacks (token, expires_at, owner_id, payload) VALUES (?, NOW() + INTERVAL ? SECOND, ?, ?)`
+	_, err = db.Exec(query, token, int64(ttl.Seconds()), ownerID, string(encoded))
+	return token, err
+}
+
+func (db *callbackService) Get(token string, user *gotgbot.User, v any) error {
+	const query = `SELECT owner_id, payload FROM callbacks WHERE token = ? AND expires_at > NOW()`
+
+	var callback struct {
+		OwnerID sql.NullInt64 `db:"owner_id"`
+		Payload string        `db:"payload"`
+	}
+	err := db.DB.Get(&callback, query, token)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ErrNotFound
+		}
+		return err
+	}
+
+	if callback.OwnerID.Valid && (user == nil || user.Id != callback.OwnerID.Int64) {
+		return model.ErrNotOwner
+	}
+
+	return json.Unmarshal([]byte(callback.Payload), v)
+}
+
+func (db *callbackService) Delete(token string) error {
+	const query = `DELETE FROM callbacks WHERE token = ?`
+	_, err := db.Exec(query, token)
+	return err
+}
+
+func (db *callbackService) Cleanup() error {
+	const query = `DELETE FROM callbacks WHERE expires_at < NOW()`
+	_, err := db.Exec(query)
+	return err
+}
@@ -18,27 +18,31 @@ func NewChatService(db *sqlx.DB) *chatService {
 	}
 }
 
+// Allow also creates the chat since the bot may not have seen a message in it yet, e.g. in channels
 func (db *chatService) Allow(chat *gotgbot.Chat) error {
-	const query = `UPDATE chats SET allowed = true WHERE id = ?`
-	_, err := db.Exec(query, chat.Id)
+	const query = `INSERT INTO 
+    chats (id, title, type, allowed)
+    VALUES (?, ?, ?, true)
+    ON DUPLICATE KEY UPDATE title = ?, type = ?, allowed = true`
+	_, err := db.Exec(query, chat.Id, chat.Title, chat.Type, chat.Title, chat.Type)
 	return err
 }
 
 func (db *chatService) Create(chat *gotgbot.Chat) error {
 	const query = `INSERT INTO 
-    chats (id, title)
-    VALUES (? ,?)
-    ON DUPLICATE KEY UPDATE title = ?`
-	_, err := db.Exec(query, chat.Id, chat.Title, chat.Title)
+    chats (id, title, type)
+    VALUES (?, ?, ?)
+    ON DUPLICATE KEY UPDATE title = ?, type = ?`
+	_, err := db.Exec(query, chat.Id, chat.Title, chat.Type, chat.Title, chat.Type)
 	return err
 }
 
 func (db *chatService) CreateTx(tx *sqlx.Tx, chat *gotgbot.Chat) error {
 	const query = `INSERT INTO 
-    chats (id, title)
-    VALUES (? ,?)
-    ON DUPLICATE KEY UPDATE title = ?`
-	_, err := tx.Exec(query, chat.Id, chat.Title, chat.Title)
+    chats (id, title, type)
+    VALUES (?, ?, ?)
+    ON DUPLICATE KEY UPDATE title = ?, type = ?`
+	_, err := tx.Exec(query, chat.Id, chat.Title, chat.Type, chat.Title, chat.Type)
 	return err
 }
 
@@ -31,7 +31,7 @@ func NewChatsPluginsService(
 	}
 }
 
-func (db *chatsPluginsService) Disable(chat *gotgbot.Chat, pluginName string) error {
+func (db *chatsPluginsService) Disable(chat *gotgbot.Chat, threadID int64, pluginName string) error {
 	tx, err := db.BeginTxx(context.Background(), nil)
 	if err != nil {
 		return err
@@ -54,7 +54,7 @@ func (db *chatsPluginsService) Disable(chat *gotgbot.Chat, pluginName string) er
 		return err
 	}
 
-	err = db.insertRelationship(tx, chat, pluginName, false)
+	err = db.insertRelationship(tx, chat, threadID, pluginName, false)
 	if err != nil {
 		return err
 	}
@@ -66,7 +66,7 @@ func (db *chatsPluginsService) Disable(chat *gotgbot.Chat, pluginName string) er
 	return nil
 }
 
-func (db *chatsPluginsService) Enable(chat *gotgbot.Chat, pluginName string) error {
+func (db *chatsPluginsService) Enable(chat *gotgbot.Chat, threadID int64, pluginName string) error {
 	tx, err := db.BeginTxx(context.Background(), nil)
 	if err != nil {
 		return err
@@ -89,7 +89,7 @@ func (db *chatsPluginsService) Enable(chat *gotgbot.Chat, pluginName string) err
 		return err
 	}
 
-	err = db.insertRelationship(tx, chat, pluginName, true)
+	err = db.insertRelationship(tx, chat, threadID, pluginName, true)
 	if err != nil {
 		return err
 	}
@@ -101,17 +101,17 @@ func (db *chatsPluginsService) Enable(chat *gotgbot.Chat, pluginName string) err
 	return nil
 }
 
-func (db *chatsPluginsService) insertRelationship(tx *sqlx.Tx, chat *gotgbot.Chat, pluginName string, enabled bool) error {
+func (db *chatsPluginsService) insertRelationship(tx *sqlx.Tx, chat *gotgbot.Chat, threadID int64, pluginName string, enabled bool) error {
 	const query = `INSERT INTO 
-    chats_plugins (chat_id, plugin_name, enabled) 
-    VALUES (?, ?, ?)
+    chats_plugins (chat_id, thread_id, plugin_name, enabled) 
+    VALUES (?, ?, ?, ?)
     ON DUPLICATE KEY UPDATE enabled = ?`
-	_, err := tx.Exec(query, chat.Id, pluginName, enabled, enabled)
+	_, err := tx.Exec(query, chat.Id, threadID, pluginName, enabled, enabled)
 	return err
 }
 
-func (db *chatsPluginsService) GetAllDisabled() (map[int64][]string, error) {
-	const query = `SELECT chat_id, plugin_name FROM chats_plugins WHERE enabled = false`
+func (db *chatsPluginsService) GetAllDisabled() (map[model.Topic][]string, error) {
+	const query = `SELECT chat_id, thread_id, plugin_name FROM chats_plugins WHERE enabled = false`
 
 	rows, err := db.Queryx(query)
 	if err != nil {
@@ -125,18 +125,18 @@ func (db *chatsPluginsService) GetAllDisabled() (map[int64][]string, error) {
 		}
 	}(rows)
 
-	disabledPlugins := make(map[int64][]string)
+	disabledPlugins := make(map[model.Topic][]string)
 
 	for rows.Next() {
-		var chatID int64
+		var topic model.Topic
 		var pluginName string
-		err := rows.Scan(&chatID, &pluginName)
+		err := rows.Scan(&topic.ChatID, &topic.ThreadID, &pluginName)
 		if err != nil {
 			db.log.Err(err).Send()
 			return nil, err
 		}
 
-		disabledPlugins[chatID] = append(disabledPlugins[chatID], pluginName)
+		disabledPlugins[topic] = append(disabledPlugins[topic], pluginName)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -0,0 +1,79 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Brawl345/gobot/logger"
+	"github.com/Brawl345/gobot/model"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type imageGenerationService struct {
+	*sqlx.DB
+	log *logger.Logger
+}
+
+func NewImageGenerationService(db *sqlx.DB) *imageGenerationService {
+	return &imageGenerationService{
+		DB:  db,
+		log: logger.New("imageGenerationService"),
+	}
+}
+
+// Reserve counts an image against the quota of the user. model.ErrLimitReached is returned
+// if the user already created limit images within the window.
+func (db *imageGenerationService) Reserve(chat *gotgbot.Chat, user *gotgbot.User, limit int, window time.Duration) (int64, error) {
+	tx, err := db.BeginTxx(context.Background(), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func(tx *sqlx.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			db.log.Err(err).Msg("failed to rollback transaction")
+		}
+	}(tx)
+
+	var count int
+	const countQuery = `SELECT COUNT(*) FROM image_generations
+	WHERE user_id = ? AND created_at > NOW() - INTERVAL ? SECOND
+	FOR UPDATE`
+	err = tx.Get(&count, countQuery, user.Id, int64(window.Seconds()))
+	if err != nil {
+		return 0, err
+	}
+	if count >= limit {
+		return 0, model.ErrLimitReached
+	}
+
+	// Private chats are not stored in chats
+	chatID := sql.NullInt64{Int64: chat.Id, Valid: chat.Type != gotgbot.ChatTypePrivate}
+	res, err := tx.Exec(`INSERT INTO image_generations (user_id, chat_id) VALUES (?, ?)`, user.Id, chatID)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+// Release gives a reserved image back, e.g. because the generation failed.
+func (db *imageGenerationService) Release(id int64) error {
+	const query = `DELETE FROM image_generations WHERE id = ?`
+	_, err := db.Exec(query, id)
+	return err
+}
+
+func (db *imageGenerationService) Cleanup(window time.Duration) error {
+	const query = `DELETE FROM image_generations WHERE created_at < NOW() - INTERVAL ? SECOND`
+	_, err := db.Exec(query, int64(window.Seconds()))
+	return err
+}
@@ -0,0 +1,197 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Brawl345/gobot/logger"
+	"github.com/Brawl345/gobot/model"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type (
+	llmService struct {
+		*sqlx.DB
+		log *logger.Logger
+	}
+)
+
+func NewLLMService(db *sqlx.DB) *llmService {
+	return &llmService{
+		DB:  db,
+		log: logger.New("llmService"),
+	}
+}
+
+// GetConversation returns the conversation the bot's answer belongs to, model.ErrNotFound if it doesn't exist or expired.
+// The history can be longer than HistoryLength if the conversation was continued from a later answer.
+func (db *llmService) GetConversation(chat *gotgbot.Chat, messageID int64) (model.LLMConversation, error) {
+	const query = `SELECT c.id, c.history, m.history_length, c.expires_on
+	FROM llm_conversation_messages m
+	JOIN llm_conversations c ON c.id = m.conversation_id
+	WHERE m.chat_id = ? AND m.message_id = ? AND c.expires_on > NOW()`
+	var conversation model.LLMConversation
+	err := db.Get(&conversation, query, chat.Id, messageID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return conversation, model.ErrNotFound
+	}
+	return conversation, err
+}
+
+// SaveConversation stores the history and links the messages of the bot's answer to the conversation.
+// The conversation is only updated if its history still has previousLength messages, i.e. the answer continues
+// its latest one. Otherwise (e.g. a reply to an older answer or a concurrent reply), an id of 0 or a conversation
+// that expired in the meantime, a new conversation is created. Its id is returned.
+func (db *llmService) SaveConversation(chat *gotgbot.Chat, id int64, previousLength int, history string, historyLength int, messageIDs []int64, ttl time.Duration) (int64, error) {
+	tx, err := db.BeginTxx(context.Background(), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func(tx *sqlx.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			db.log.Err(err).Msg("failed to rollback transaction")
+		}
+	}(tx)
+
+	updated := int64(0)
+	if id != 0 {
+		const updateQuery = `UPDATE llm_conversations
+		SET history = ?, history_length = ?, expires_on = NOW() + INTERVAL ? SECOND
+		WHERE id = ? AND chat_id = ? AND history_length = ?`
+		res, err := tx.Exec(updateQuery, history, historyLength, int64(ttl.Seconds()), id, chat.Id, previousLength)
+		if err != nil {
+			return 0, err
+		}
+		updated, err = res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if updated == 0 {
+		const insertQuery = `INSERT INTO llm_conversations (chat_id, expires_on, history, history_length) VALUES (?, NOW() + INTERVAL ? SECOND, ?, ?)`
+		res, err := tx.Exec(insertQuery, chat.Id, int64(ttl.Seconds()), history, historyLength)
+		if err != nil {
+			return 0, err
+		}
+		id, err = res.LastInsertId()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	const messageQuery = `INSERT INTO llm_conversation_messages (chat_id, message_id, conversation_id, history_length) VALUES (?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE conversation_id = ?, history_length = ?`
+	for _, messageID := range messageIDs {
+		_, err := tx.Exec(messageQuery, chat.Id, messageID, id, historyLength, id, historyLength)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return id, tx.Commit()
+}
+
+func (db *llmService) DeleteConversation(chat *gotgbot.Chat, id int64) error {
+	const query = `DELETE FROM llm_conversations WHERE id = ? AND chat_id = ?`
+	_, err := db.Exec(query, id, chat.Id)
+	return err
+}
+
+func (db *llmService) DeleteConversations(chat *gotgbot.Chat) error {
+	const query = `DELETE FROM llm_conversations WHERE chat_id = ?`
+	_, err := db.Exec(query, chat.Id)
+	return err
+}
+
+// GetExpiredConversations returns the conversations that can't be continued anymore.
+func (db *llmService) GetExpiredConversations() ([]model.LLMConversation, error) {
+	const query = `SELECT id, chat_id, history, expires_on FROM llm_conversations WHERE expires_on < NOW()`
+	var conversations []model.LLMConversation
+	err := db.Select(&conversations, query)
+	return conversations, err
+}
+
+func (db *llmService) GetMemories(chat *gotgbot.Chat) ([]model.LLMMemory, error) {
+	const query = `SELECT id, content, created_at FROM llm_memories WHERE chat_id = ? ORDER BY id`
+	var memories []model.LLMMemory
+	err := db.Select(&memories, query, chat.Id)
+	return memories, err
+}
+
+// AddMemory stores a new memory, model.ErrLimitReached is returned if the chat already has limit memories.
+func (db *llmService) AddMemory(chat *gotgbot.Chat, content string, limit int) (int64, error) {
+	tx, err := db.BeginTxx(context.Background(), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func(tx *sqlx.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			db.log.Err(err).Msg("failed to rollback transaction")
+		}
+	}(tx)
+
+	var count int
+	err = tx.Get(&count, `SELECT COUNT(*) FROM llm_memories WHERE chat_id = ? FOR UPDATE`, chat.Id)
+	if err != nil {
+		return 0, err
+	}
+	if count >= limit {
+		return 0, model.ErrLimitReached
+	}
+
+	res, err := tx.Exec(`INSERT INTO llm_memories (chat_id, content) VALUES (?, ?)`, chat.Id, content)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+func (db *llmService) UpdateMemory(chat *gotgbot.Chat, id int64, content string) error {
+	var exists bool
+	err := db.Get(&exists, `SELECT EXISTS(SELECT 1 FROM llm_memories WHERE id = ? AND chat_id = ?)`, id, chat.Id)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return model.ErrNotFound
+	}
+
+	const query = `UPDATE llm_memories SET content = ? WHERE id = ? AND chat_id = ?`
+	_, err = db.Exec(query, content, id, chat.Id)
+	return err
+}
+
+func (db *llmService) DeleteMemory(chat *gotgbot.Chat, id int64) error {
+	const query = `DELETE FROM llm_memories WHERE id = ? AND chat_id = ?`
+	res, err := db.Exec(query, id, chat.Id)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+func (db *llmService) DeleteMemories(chat *gotgbot.Chat) error {
+	const query = `DELETE FROM llm_memories WHERE chat_id = ?`
+	_, err := db.Exec(query, chat.Id)
+	return err
+}
@@ -0,0 +1,11 @@
+-- +migrate Up
+
+CREATE TABLE `update_offset`
+(
+    `id`             TINYINT(1) PRIMARY KEY NOT NULL DEFAULT 1,
+    `last_update_id` BIGINT(20)             NOT NULL DEFAULT 0
+) COLLATE = 'utf8mb4_general_ci'
+  ENGINE = InnoDB;
+
+INSERT INTO `update_offset` (`id`, `last_update_id`)
+VALUES (1, 0);
@@ -0,0 +1,12 @@
+-- +migrate Up
+
+CREATE TABLE `callbacks`
+(
+    `token`      CHAR(20) PRIMARY KEY NOT NULL,
+    `created_at` DATETIME             NOT NULL DEFAULT current_timestamp(),
+    `expires_at` DATETIME             NOT NULL,
+    `owner_id`   BIGINT(20)           NULL,
+    `payload`    MEDIUMTEXT           NOT NULL, -- Paginated messages store all of their pages
+    INDEX `expires_at` (`expires_at`)
+) COLLATE = 'utf8mb4_general_ci'
+  ENGINE = InnoDB;
@@ -0,0 +1,12 @@
+-- +migrate Up
+
+ALTER TABLE `chats_plugins`
+    ADD `thread_id` BIGINT(20) NOT NULL DEFAULT 0 AFTER `chat_id`,
+    DROP PRIMARY KEY,
+    ADD PRIMARY KEY (`chat_id`, `thread_id`, `plugin_name`);
+
+ALTER TABLE `reminders`
+    ADD `thread_id` BIGINT(20) NOT NULL DEFAULT 0 AFTER `chat_id`;
+
+ALTER TABLE `chats`
+    ADD `birthday_thread_id` BIGINT(20) NOT NULL DEFAULT 0 AFTER `birthday_notifications_enabled`;
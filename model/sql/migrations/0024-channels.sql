@@ -0,0 +1,4 @@
+-- +migrate Up
+
+ALTER TABLE `chats`
+    ADD `type` VARCHAR(20) NULL DEFAULT NULL AFTER `title`;
@@ -0,0 +1,16 @@
+-- +migrate Up
+
+CREATE TABLE `settings`
+(
+    `chat_id`     BIGINT(20)   NOT NULL DEFAULT 0,
+    `user_id`     BIGINT(20)   NOT NULL DEFAULT 0,
+    `plugin_name` VARCHAR(25)  NOT NULL,
+    `key`         VARCHAR(50)  NOT NULL,
+    `created_at`  DATETIME     NOT NULL DEFAULT current_timestamp(),
+    `updated_at`  DATETIME     NULL     DEFAULT NULL ON UPDATE current_timestamp(),
+    `value`       VARCHAR(255) NOT NULL,
+    PRIMARY KEY (`chat_id`, `user_id`, `plugin_name`, `key`),
+    INDEX `FK_settings_plugins` (`plugin_name`),
+    CONSTRAINT `FK_settings_plugins` FOREIGN KEY (`plugin_name`) REFERENCES `plugins` (`name`) ON UPDATE CASCADE ON DELETE CASCADE
+) COLLATE = 'utf8mb4_general_ci'
+  ENGINE = InnoDB;
@@ -0,0 +1,15 @@
+-- +migrate Up
+
+CREATE TABLE `audit_log`
+(
+    `id`         BIGINT(20)   PRIMARY KEY NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME     NOT NULL DEFAULT current_timestamp(),
+    `actor_id`   BIGINT(20)   NOT NULL,
+    `actor_name` VARCHAR(255) NOT NULL,
+    `chat_id`    BIGINT(20)   NULL,
+    `action`     VARCHAR(50)  NOT NULL,
+    `target`     VARCHAR(255) NOT NULL,
+    INDEX `created_at` (`created_at`),
+    INDEX `action` (`action`)
+) COLLATE = 'utf8mb4_general_ci'
+  ENGINE = InnoDB;
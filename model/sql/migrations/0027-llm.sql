@@ -0,0 +1,24 @@
+-- +migrate Up
+
+ALTER TABLE `chats`
+    DROP `gemini_history`,
+    DROP `gemini_history_expires_on`,
+    DROP `gpt_response_id`,
+    DROP `gpt_response_id_expires_on`;
+
+-- The "gpt" and "gemini" plugins were merged into "ai"
+INSERT INTO `plugins` (`name`, `enabled`)
+SELECT 'ai', MAX(`enabled`)
+FROM `plugins`
+WHERE `name` IN ('gpt', 'gemini')
+HAVING COUNT(*) > 0;
+
+INSERT INTO `chats_plugins` (`chat_id`, `thread_id`, `plugin_name`, `enabled`)
+SELECT `chat_id`, `thread_id`, 'ai', MAX(`enabled`)
+FROM `chats_plugins`
+WHERE `plugin_name` IN ('gpt', 'gemini')
+GROUP BY `chat_id`, `thread_id`;
+
+DELETE
+FROM `plugins`
+WHERE `name` IN ('gpt', 'gemini');
@@ -0,0 +1,16 @@
+-- +migrate Up
+
+CREATE TABLE `personas`
+(
+    `chat_id`       BIGINT(20)   NOT NULL,
+    `version`       INT          NOT NULL,
+    `created_at`    DATETIME     NOT NULL DEFAULT current_timestamp(),
+    `created_by`    BIGINT(20)   NULL,
+    `system_prompt` TEXT         NULL,
+    `temperature`   DOUBLE       NULL,
+    `language`      VARCHAR(50)  NULL,
+    `tools`         TEXT         NULL,
+    PRIMARY KEY (`chat_id`, `version`),
+    CONSTRAINT `FK_personas_chats` FOREIGN KEY (`chat_id`) REFERENCES `chats` (`id`) ON UPDATE CASCADE ON DELETE CASCADE
+) COLLATE = 'utf8mb4_general_ci'
+  ENGINE = InnoDB;
@@ -0,0 +1,32 @@
+-- +migrate Up
+
+CREATE TABLE `llm_conversations`
+(
+    `id`             BIGINT(20) NOT NULL AUTO_INCREMENT,
+    `chat_id`        BIGINT(20) NOT NULL,
+    `created_at`     DATETIME   NOT NULL DEFAULT current_timestamp(),
+    `updated_at`     DATETIME   NULL     DEFAULT NULL ON UPDATE current_timestamp(),
+    `expires_on`     DATETIME   NOT NULL,
+    `history`        MEDIUMTEXT NOT NULL,
+    -- Number of messages in the history, replies to older answers fork the conversation
+    `history_length` INT        NOT NULL,
+    PRIMARY KEY (`id`),
+    INDEX `FK_llm_conversations_chats` (`chat_id`),
+    INDEX `expires_on` (`expires_on`),
+    CONSTRAINT `FK_llm_conversations_chats` FOREIGN KEY (`chat_id`) REFERENCES `chats` (`id`) ON UPDATE CASCADE ON DELETE CASCADE
+) COLLATE = 'utf8mb4_general_ci'
+  ENGINE = InnoDB;
+
+-- Answers of the bot, replying to one continues its conversation
+CREATE TABLE `llm_conversation_messages`
+(
+    `chat_id`         BIGINT(20) NOT NULL,
+    `message_id`      BIGINT(20) NOT NULL,
+    `conversation_id` BIGINT(20) NOT NULL,
+    -- Number of messages of the history up to this answer
+    `history_length`  INT        NOT NULL,
+    PRIMARY KEY (`chat_id`, `message_id`),
+    INDEX `FK_llm_conversation_messages_llm_conversations` (`conversation_id`),
+    CONSTRAINT `FK_llm_conversation_messages_llm_conversations` FOREIGN KEY (`conversation_id`) REFERENCES `llm_conversations` (`id`) ON UPDATE CASCADE ON DELETE CASCADE
+) COLLATE = 'utf8mb4_general_ci'
+  ENGINE = InnoDB;
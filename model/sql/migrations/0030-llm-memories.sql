@@ -0,0 +1,14 @@
+-- +migrate Up
+
+CREATE TABLE `llm_memories`
+(
+    `id`         BIGINT(20)   NOT NULL AUTO_INCREMENT,
+    `chat_id`    BIGINT(20)   NOT NULL,
+    `created_at` DATETIME     NOT NULL DEFAULT current_timestamp(),
+    `updated_at` DATETIME     NULL     DEFAULT NULL ON UPDATE current_timestamp(),
+    `content`    VARCHAR(500) NOT NULL,
+    PRIMARY KEY (`id`),
+    INDEX `FK_llm_memories_chats` (`chat_id`),
+    CONSTRAINT `FK_llm_memories_chats` FOREIGN KEY (`chat_id`) REFERENCES `chats` (`id`) ON UPDATE CASCADE ON DELETE CASCADE
+) COLLATE = 'utf8mb4_general_ci'
+  ENGINE = InnoDB;
@@ -0,0 +1,16 @@
+-- +migrate Up
+
+-- Generated images, only used for the quotas
+CREATE TABLE `image_generations`
+(
+    `id`         BIGINT(20) NOT NULL AUTO_INCREMENT,
+    `user_id`    BIGINT(20) NOT NULL,
+    `chat_id`    BIGINT(20) NULL, -- NULL in private chats, they have no row in chats
+    `created_at` DATETIME   NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`id`),
+    INDEX `user_id_created_at` (`user_id`, `created_at`),
+    INDEX `FK_image_generations_chats` (`chat_id`),
+    CONSTRAINT `FK_image_generations_users` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT `FK_image_generations_chats` FOREIGN KEY (`chat_id`) REFERENCES `chats` (`id`) ON UPDATE CASCADE ON DELETE CASCADE
+) COLLATE = 'utf8mb4_general_ci'
+  ENGINE = InnoDB;
@@ -0,0 +1,12 @@
+-- +migrate Up
+
+-- Birthday notifications are a setting of the chat now
+INSERT INTO `settings` (`chat_id`, `plugin_name`, `key`, `value`)
+SELECT `c`.`id`, `p`.`name`, 'notifications', 'true'
+FROM `chats` `c`
+         JOIN `plugins` `p` ON `p`.`name` = 'birthdays'
+WHERE `c`.`birthday_notifications_enabled` = true;
+
+ALTER TABLE `chats`
+    DROP INDEX `birthday_notifications_enabled`,
+    DROP `birthday_notifications_enabled`;
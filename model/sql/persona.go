@@ -0,0 +1,101 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Brawl345/gobot/logger"
+	"github.com/Brawl345/gobot/model"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+const personaColumns = `personas.version, personas.created_at, personas.created_by, users.first_name AS created_by_name,
+	personas.system_prompt, personas.temperature, personas.language, personas.tools`
+
+type personaService struct {
+	*sqlx.DB
+	log *logger.Logger
+}
+
+func NewPersonaService(db *sqlx.DB) *personaService {
+	return &personaService{
+		DB:  db,
+		log: logger.New("personaService"),
+	}
+}
+
+func (db *personaService) GetPersona(chat *gotgbot.Chat) (model.Persona, error) {
+	const query = `SELECT ` + personaColumns + `
+	FROM personas
+	LEFT JOIN users ON users.id = personas.created_by
+	WHERE personas.chat_id = ?
+	ORDER BY personas.version DESC
+	LIMIT 1`
+
+	var persona model.Persona
+	err := db.Get(&persona, query, chat.Id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return persona, nil
+	}
+	return persona, err
+}
+
+func (db *personaService) GetPersonaVersion(chat *gotgbot.Chat, version int) (model.Persona, error) {
+	const query = `SELECT ` + personaColumns + `
+	FROM personas
+	LEFT JOIN users ON users.id = personas.created_by
+	WHERE personas.chat_id = ? AND personas.version = ?`
+
+	var persona model.Persona
+	err := db.Get(&persona, query, chat.Id, version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return persona, model.ErrNotFound
+	}
+	return persona, err
+}
+
+func (db *personaService) GetPersonaVersions(chat *gotgbot.Chat, limit int) ([]model.Persona, error) {
+	const query = `SELECT ` + personaColumns + `
+	FROM personas
+	LEFT JOIN users ON users.id = personas.created_by
+	WHERE personas.chat_id = ?
+	ORDER BY personas.version DESC
+	LIMIT ?`
+
+	var personas []model.Persona
+	err := db.Select(&personas, query, chat.Id, limit)
+	return personas, err
+}
+
+func (db *personaService) SavePersona(chat *gotgbot.Chat, user *gotgbot.User, persona model.Persona) (int, error) {
+	tx, err := db.BeginTxx(context.Background(), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func(tx *sqlx.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			db.log.Err(err).Msg("failed to rollback transaction")
+		}
+	}(tx)
+
+	var version int
+	err = tx.Get(&version, `SELECT COALESCE(MAX(version), 0) + 1 FROM personas WHERE chat_id = ? FOR UPDATE`, chat.Id)
+	if err != nil {
+		return 0, err
+	}
+
+	const query = `INSERT INTO personas
+	(chat_id, version, created_by, system_prompt, temperature, language, tools)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err = tx.Exec(query, chat.Id, version, user.Id,
+		persona.SystemPrompt, persona.Temperature, persona.Language, persona.Tools)
+	if err != nil {
+		return 0, err
+	}
+
+	return version, tx.Commit()
+}
@@ -61,7 +61,7 @@ func (db *reminderService) GetAllReminders() ([]model.Reminder, error) {
 }
 
 func (db *reminderService) GetReminderByID(id int64) (model.Reminder, error) {
-	const query = `SELECT chat_id, user_id, username, text FROM reminders r 
+	const query = `SELECT chat_id, thread_id, user_id, username, text FROM reminders r 
     RIGHT JOIN users u ON r.user_id = u.id
 	WHERE r.id = ?`
 	var reminder model.Reminder
@@ -92,6 +92,7 @@ func (db *reminderService) GetReminders(chat *gotgbot.Chat, user *gotgbot.User)
 
 func (db *reminderService) SaveReminder(
 	chat *gotgbot.Chat,
+	threadID int64,
 	user *gotgbot.User,
 	remindAt time.Time,
 	text string,
@@ -102,8 +103,8 @@ func (db *reminderService) SaveReminder(
 		const query = `INSERT INTO reminders (user_id, time, text) VALUES (?, ?, ?)`
 		res, err = db.Exec(query, user.Id, remindAt, text)
 	} else {
-		const query = `INSERT INTO reminders (chat_id, user_id, time, text) VALUES (?, ?, ?, ?)`
-		res, err = db.Exec(query, chat.Id, user.Id, remindAt, text)
+		const query = `INSERT INTO reminders (chat_id, thread_id, user_id, time, text) VALUES (?, ?, ?, ?, ?)`
+		res, err = db.Exec(query, chat.Id, threadID, user.Id, remindAt, text)
 	}
 	if err != nil {
 		return 0, err
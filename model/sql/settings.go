@@ -0,0 +1,107 @@
+package sql
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/Brawl345/gobot/logger"
+	"github.com/Brawl345/gobot/model"
+	"github.com/Brawl345/gobot/plugin"
+	"github.com/jmoiron/sqlx"
+)
+
+type (
+	settingKey struct {
+		scope      model.SettingsScope
+		pluginName string
+		key        string
+	}
+
+	settingsService struct {
+		*sqlx.DB
+		log      *logger.Logger
+		mu       sync.RWMutex
+		settings map[settingKey]string
+	}
+)
+
+func NewSettingsService(db *sqlx.DB) *settingsService {
+	s := &settingsService{
+		DB:       db,
+		log:      logger.New("settingsService"),
+		settings: make(map[settingKey]string),
+	}
+
+	const query = `SELECT chat_id, user_id, plugin_name, ` + "`key`" + `, value FROM settings`
+	var settings []model.Setting
+	err := db.Select(&settings, query)
+
+	if err != nil {
+		s.log.Err(err).Msg("Failed to load settings")
+	} else {
+		for _, setting := range settings {
+			s.settings[settingKey{setting.SettingsScope, setting.PluginName, setting.Key}] = setting.Value
+		}
+	}
+
+	return s
+}
+
+func (db *settingsService) Get(scope model.SettingsScope, pluginName string, setting plugin.Setting) string {
+	db.mu.RLock()
+	value, ok := db.settings[settingKey{scope, pluginName, setting.Key}]
+	db.mu.RUnlock()
+
+	if !ok {
+		return setting.Default
+	}
+
+	// The schema may have changed since the value was stored
+	value, err := setting.Normalize(value)
+	if err != nil {
+		return setting.Default
+	}
+	return value
+}
+
+func (db *settingsService) GetBool(scope model.SettingsScope, pluginName string, setting plugin.Setting) bool {
+	return db.Get(scope, pluginName, setting) == "true"
+}
+
+func (db *settingsService) GetInt(scope model.SettingsScope, pluginName string, setting plugin.Setting) int64 {
+	value, _ := strconv.ParseInt(db.Get(scope, pluginName, setting), 10, 64)
+	return value
+}
+
+func (db *settingsService) Set(scope model.SettingsScope, pluginName string, setting plugin.Setting, value string) error {
+	value, err := setting.Normalize(value)
+	if err != nil {
+		return err
+	}
+
+	const query = `INSERT INTO settings (chat_id, user_id, plugin_name, ` + "`key`" + `, value)
+	VALUES (?, ?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE value = ?`
+	_, err = db.Exec(query, scope.ChatID, scope.UserID, pluginName, setting.Key, value, value)
+	if err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	db.settings[settingKey{scope, pluginName, setting.Key}] = value
+	db.mu.Unlock()
+	return nil
+}
+
+func (db *settingsService) Reset(scope model.SettingsScope, pluginName string, key string) error {
+	const query = `DELETE FROM settings WHERE chat_id = ? AND user_id = ? AND plugin_name = ? AND ` + "`key`" + ` = ?`
+	_, err := db.Exec(query, scope.ChatID, scope.UserID, pluginName, key)
+	if err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	delete(db.settings, settingKey{scope, pluginName, key})
+	db.mu.Unlock()
+	return nil
+}
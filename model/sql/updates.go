@@ -0,0 +1,33 @@
+package sql
+
+import (
+	"github.com/Brawl345/gobot/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+type updateOffsetService struct {
+	*sqlx.DB
+	log *logger.Logger
+}
+
+func NewUpdateOffsetService(db *sqlx.DB) *updateOffsetService {
+	return &updateOffsetService{
+		DB:  db,
+		log: logger.New("updateOffsetService"),
+	}
+}
+
+func (db *updateOffsetService) GetLastUpdateID() (int64, error) {
+	const query = `SELECT last_update_id FROM update_offset WHERE id = 1`
+	var updateID int64
+	err := db.Get(&updateID, query)
+	return updateID, err
+}
+
+// SetLastUpdateID never moves the offset backwards since the watermarks of concurrently
+// processed updates may be persisted out of order.
+func (db *updateOffsetService) SetLastUpdateID(updateID int64) error {
+	const query = `UPDATE update_offset SET last_update_id = GREATEST(last_update_id, ?) WHERE id = 1`
+	_, err := db.Exec(query, updateID)
+	return err
+}
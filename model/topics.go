@@ -0,0 +1,7 @@
+package model
+
+// Topic is a forum topic of a chat. ThreadID 0 stands for the whole chat.
+type Topic struct {
+	ChatID   int64
+	ThreadID int64
+}
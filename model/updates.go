@@ -0,0 +1,6 @@
+package model
+
+type UpdateOffsetService interface {
+	GetLastUpdateID() (int64, error)
+	SetLastUpdateID(updateID int64) error
+}
@@ -93,6 +93,19 @@ in
       description = "Print all messages the bot receives";
     };
 
+    recordUpdatesDir = mkOption {
+      type = types.nullOr types.str;
+      default = null;
+      example = "/var/lib/gobot/updates";
+      description = "Directory to record all raw updates to for later replaying";
+    };
+
+    resumePendingUpdates = mkOption {
+      type = types.bool;
+      default = false;
+      description = "Process updates that were sent while the bot was offline instead of dropping them";
+    };
+
     prettyPrintLog = mkOption {
       type = types.bool;
       default = false;
@@ -230,6 +243,12 @@ in
         (mkIf cfg.printMsgs {
           PRINT_MSGS = "true";
         })
+        (mkIf (cfg.recordUpdatesDir != null) {
+          RECORD_UPDATES = cfg.recordUpdatesDir;
+        })
+        (mkIf cfg.resumePendingUpdates {
+          RESUME_PENDING_UPDATES = "true";
+        })
         (mkIf cfg.debug {
           DEBUG = "true";
         })
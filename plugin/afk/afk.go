@@ -50,6 +50,17 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	}
 }
 
+func (p *Plugin) Help() plugin.Help {
+	return plugin.Help{
+		Description: "Markiert dich als abwesend. Wer dich erwähnt, bekommt einen Hinweis. Sobald du wieder schreibst, bist du automatisch zurück.",
+		Examples:    []string{"/afk Bin einkaufen"},
+		PassiveTriggers: []string{
+			"Erwähnungen von abwesenden Nutzern",
+			"Nachrichten von abwesenden Nutzern (beendet AFK)",
+		},
+	}
+}
+
 func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
@@ -0,0 +1,598 @@
+package ai
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Brawl345/gobot/llm"
+	"github.com/Brawl345/gobot/logger"
+	"github.com/Brawl345/gobot/model"
+	"github.com/Brawl345/gobot/plugin"
+	"github.com/Brawl345/gobot/utils"
+	"github.com/Brawl345/gobot/utils/httpUtils"
+	"github.com/Brawl345/gobot/utils/tgUtils"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/rs/xid"
+)
+
+const (
+	ProviderOpenAI           = "openai"
+	ProviderGemini           = "gemini"
+	MaxOutputTokens          = 2000
+	MaxInputCharacters       = 250000     // Roughly the context window of the smallest model
+	MaxHistoryBytes          = 16_000_000 // MEDIUMTEXT
+	TokensPerImage           = 258        // https://ai.google.dev/gemini-api/docs/tokens?lang=go#multimodal-tokens
+	CharactersPerPDFPage     = 4000       // Text of a full page plus the image of it that the models get
+	DefaultSystemInstruction = "Du befindest dich in einer Telegram-Gruppenkonversation mit mehreren Nutzern. Nachrichten sind mit dem jeweiligen Nutzernamen vorangestellt."
+	// FormattingInstruction is always added since personas can't change how messages are rendered
+	FormattingInstruction = "Markdown ist AKTIVIERT, nutze es sparsam (fett, kursiv, Listen, Code). Tabellen werden nur als Text dargestellt. HTML ist DEAKTIVIERT. Bilder-Analyse ist AKTIVIERT."
+)
+
+var (
+	log            = logger.New("ai")
+	regexBotPrefix = regexp.MustCompile(`(?i)^Bot, ([\s\S]+)$`)
+)
+
+var settingProvider = plugin.Setting{
+	Key:         "provider",
+	Name:        "Anbieter",
+	Description: "KI-Anbieter für diesen Chat (\"openai\" steht auch für kompatible Server). Ist er nicht erreichbar, wird automatisch der andere genutzt.",
+	Type:        plugin.SettingEnum,
+	Default:     ProviderOpenAI,
+	Options:     []string{ProviderOpenAI, ProviderGemini},
+}
+
+var settingStreaming = plugin.Setting{
+	Key:         "streaming",
+	Name:        "Live-Antworten",
+	Description: "Zeigt die Antwort schon während sie geschrieben wird und aktualisiert sie laufend.",
+	Type:        plugin.SettingBool,
+	Default:     "true",
+}
+
+type (
+	Plugin struct {
+		auditService      model.AuditService
+		credentialService model.CredentialService
+		llmService        Service
+		managerService    model.ManagerService
+		mcpManager        *mcpManager
+		personaService    PersonaService
+		settingsService   model.SettingsService
+		transcriber       Transcriber
+	}
+
+	Service interface {
+		AddMemory(chat *gotgbot.Chat, content string, limit int) (int64, error)
+		DeleteConversation(chat *gotgbot.Chat, id int64) error
+		DeleteConversations(chat *gotgbot.Chat) error
+		DeleteMemories(chat *gotgbot.Chat) error
+		DeleteMemory(chat *gotgbot.Chat, id int64) error
+		GetConversation(chat *gotgbot.Chat, messageID int64) (model.LLMConversation, error)
+		GetExpiredConversations() ([]model.LLMConversation, error)
+		GetMemories(chat *gotgbot.Chat) ([]model.LLMMemory, error)
+		SaveConversation(chat *gotgbot.Chat, id int64, previousLength int, history string, historyLength int, messageIDs []int64, ttl time.Duration) (int64, error)
+		UpdateMemory(chat *gotgbot.Chat, id int64, content string) error
+	}
+)
+
+func New(
+	auditService model.AuditService,
+	credentialService model.CredentialService,
+	llmService Service,
+	managerService model.ManagerService,
+	personaService PersonaService,
+	settingsService model.SettingsService,
+	transcriber Transcriber,
+) *Plugin {
+	p := &Plugin{
+		auditService:      auditService,
+		credentialService: credentialService,
+		llmService:        llmService,
+		managerService:    managerService,
+		mcpManager:        &mcpManager{},
+		personaService:    personaService,
+		settingsService:   settingsService,
+		transcriber:       transcriber,
+	}
+
+	return p
+}
+
+func (p *Plugin) StartJobs(ctx context.Context, _ *gotgbot.Bot) {
+	utils.RunPeriodically(ctx, time.Hour, 24*time.Hour, p.cleanup)
+}
+
+func (p *Plugin) Name() string {
+	return "ai"
+}
+
+func (p *Plugin) Commands() []gotgbot.BotCommand {
+	return []gotgbot.BotCommand{
+		{
+			Command:     "persona",
+			Description: "KI-Persona des Chats anzeigen/ändern",
+		},
+		{
+			Command:     "memory",
+			Description: "KI-Gedächtnis des Chats anzeigen/verwalten",
+		},
+	}
+}
+
+func (p *Plugin) Credentials() plugin.Credentials {
+	return plugin.Credentials{
+		AnyOf: [][]string{
+			{
+				"openai_api_key",
+				// OpenAI-compatible server like Ollama, llama.cpp or vLLM, e.g. http://localhost:11434/v1
+				"openai_base_url",
+				// Get the key from https://aistudio.google.com/app/apikey
+				"google_generative_language_api_key",
+			},
+		},
+		Optional: []string{
+			// Used instead of openai_api_key, which is never sent to other servers
+			"openai_base_url_api_key",
+			"openai_model",
+			"openai_auth_header",
+			"google_gemini_model",
+			"google_gemini_proxy",
+			// Falls back to the keys of the former gpt and gemini plugins
+			"ai_system_instruction",
+			"brave_search_api_key",
+			// JSON array of MCP servers whose tools the AI can use, see README
+			"mcp_servers",
+		},
+	}
+}
+
+func (p *Plugin) Help() plugin.Help {
+	return plugin.Help{
+		Description: "Unterhalte dich mit einer KI, die auch Tools anderer Plugins und von MCP-Servern nutzen kann.",
+		Examples: []string{
+			"Bot, wie wird das Wetter morgen in Berlin?",
+			"Bot, fasse das zusammen (mit Bild, PDF oder Link)",
+			"/botreset",
+			"/botreset Du bist ein Pirat.",
+			"/settings ai",
+			"/persona prompt Du bist ein mürrischer Pirat.",
+			"/persona tools calculator, websearch",
+			"/persona rollback 2",
+			"/memory löschen 3",
+		},
+		PassiveTriggers: []string{
+			"Nachrichten, die mit \"Bot,\" beginnen (startet eine neue Konversation)",
+			"Antworten auf Nachrichten der KI, auch als Sprachnachricht (setzt deren Konversation fort)",
+		},
+	}
+}
+
+func (p *Plugin) Settings() []plugin.Setting {
+	return []plugin.Setting{settingProvider, settingStreaming, settingMemory}
+}
+
+func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
+	return []plugin.Handler{
+		&plugin.CommandHandler{
+			Trigger:     regexBotPrefix,
+			HandlerFunc: p.onBot,
+			GroupOnly:   true,
+		},
+		&plugin.CommandHandler{
+			Trigger:      regexp.MustCompile(`^([^/][\s\S]*)$`),
+			HandlerFunc:  p.onReply,
+			GroupOnly:    true,
+			RepliesToBot: true,
+		},
+		&plugin.CommandHandler{
+			Trigger:      tgUtils.VoiceMsg,
+			HandlerFunc:  p.onReply,
+			GroupOnly:    true,
+			RepliesToBot: true,
+		},
+		&plugin.CommandHandler{
+			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/persona(?:@%s)?(?:\s+(\S+)(?:\s+([\s\S]+))?)?$`, botInfo.Username)),
+			HandlerFunc: p.onPersona,
+			GroupOnly:   true,
+		},
+		&plugin.CommandHandler{
+			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/memory(?:@%s)?(?:\s+(\S+)(?:\s+([\s\S]+))?)?$`, botInfo.Username)),
+			HandlerFunc: p.onMemory,
+			GroupOnly:   true,
+		},
+		&plugin.CommandHandler{
+			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/botreset(?:@%s)?$`, botInfo.Username)),
+			HandlerFunc: p.onReset,
+			GroupOnly:   true,
+		},
+		&plugin.CommandHandler{
+			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/botreset(?:@%s)? ([\s\S]+)$`, botInfo.Username)),
+			HandlerFunc: p.onResetAndRun,
+			GroupOnly:   true,
+		},
+	}
+}
+
+// providers returns the configured providers, the one selected for the chat first.
+func (p *Plugin) providers(chat *gotgbot.Chat) []llm.Provider {
+	var openAI, gemini llm.Provider
+
+	apiKey := p.credentialService.GetKey("openai_api_key")
+	baseURL := p.credentialService.GetKey("openai_base_url")
+	if baseURL != "" {
+		apiKey = p.credentialService.GetKey("openai_base_url_api_key")
+	}
+	if apiKey != "" || baseURL != "" {
+		provider, err := llm.NewOpenAI(llm.OpenAIConfig{
+			APIKey:     apiKey,
+			BaseURL:    baseURL,
+			Model:      p.credentialService.GetKey("openai_model"),
+			AuthHeader: p.credentialService.GetKey("openai_auth_header"),
+		})
+		if err != nil {
+			log.Warn().Err(err).Msg("openai_base_url or openai_model is invalid")
+		} else {
+			openAI = provider
+		}
+	}
+
+	if apiKey := p.credentialService.GetKey("google_generative_language_api_key"); apiKey != "" {
+		provider, err := llm.NewGemini(
+			apiKey,
+			p.credentialService.GetKey("google_gemini_proxy"),
+			p.credentialService.GetKey("google_gemini_model"),
+		)
+		if err != nil {
+			log.Warn().Err(err).Msg("google_gemini_proxy is invalid")
+		} else {
+			gemini = provider
+		}
+	}
+
+	providers := []llm.Provider{openAI, gemini}
+	if p.settingsService.Get(model.ScopeOf(settingProvider, chat, nil), p.Name(), settingProvider) == ProviderGemini {
+		providers = []llm.Provider{gemini, openAI}
+	}
+
+	var configured []llm.Provider
+	for _, provider := range providers {
+		if provider != nil {
+			configured = append(configured, provider)
+		}
+	}
+	return configured
+}
+
+// handleAPIError replies with a fitting error message. The conversation is ended if its history might be the cause,
+// which is not assumed if the message has attachments, since the model might not support them.
+func (p *Plugin) handleAPIError(b *gotgbot.Bot, c plugin.GobotContext, err error, conversationID int64, hasAttachments bool) error {
+	if httpError, ok := errors.AsType[*httpUtils.HttpError](err); ok {
+		if httpError.StatusCode == http.StatusBadRequest && hasAttachments {
+			guid := xid.New().String()
+			log.Err(err).Str("guid", guid).Int64("conversation_id", conversationID).Msg("HTTP 400 for a message with attachments")
+			_, err := c.EffectiveMessage.ReplyMessage(b,
+				fmt.Sprintf("❌ Der Anhang konnte nicht verarbeitet werden.%s", utils.EmbedGUID(guid)),
+				utils.DefaultSendOptions(),
+			)
+			return err
+		}
+		if httpError.StatusCode == http.StatusBadRequest && conversationID != 0 {
+			guid := xid.New().String()
+			log.Err(err).Str("guid", guid).Int64("conversation_id", conversationID).Msg("HTTP 400, ending conversation")
+			p.deleteConversation(c.EffectiveChat, conversationID)
+			_, err := c.EffectiveMessage.ReplyMessage(b,
+				fmt.Sprintf("❌ Es ist ein Fehler aufgetreten, Konversation wird zurückgesetzt.%s", utils.EmbedGUID(guid)),
+				utils.DefaultSendOptions(),
+			)
+			return err
+		}
+		if httpError.StatusCode == http.StatusTooManyRequests {
+			_, err := c.EffectiveMessage.ReplyMessage(b, "❌ Rate-Limit erreicht.", utils.DefaultSendOptions())
+			return err
+		}
+	}
+	if netErr, ok := errors.AsType[net.Error](err); ok && netErr.Timeout() {
+		_, err := c.EffectiveMessage.ReplyMessage(b, "❌ Timeout, bitte erneut versuchen.", utils.DefaultSendOptions())
+		return err
+	}
+	guid := xid.New().String()
+	log.Err(err).Str("guid", guid).Msg("Failed to send POST request")
+	_, err = c.EffectiveMessage.ReplyMessage(b,
+		fmt.Sprintf("❌ Es ist ein Fehler aufgetreten.%s", utils.EmbedGUID(guid)),
+		utils.DefaultSendOptions(),
+	)
+	return err
+}
+
+func (p *Plugin) onBot(b *gotgbot.Bot, c plugin.GobotContext) error {
+	providers := p.providers(c.EffectiveChat)
+	if len(providers) == 0 {
+		log.Warn().Msg("No provider configured")
+		_, err := c.EffectiveMessage.ReplyMessage(b,
+			"❌ <code>openai_api_key</code>, <code>openai_base_url</code> oder <code>google_generative_language_api_key</code> fehlt.",
+			utils.DefaultSendOptions(),
+		)
+		return err
+	}
+
+	persona, err := p.personaService.GetPersona(c.EffectiveChat)
+	if err != nil {
+		log.Err(err).
+			Int64("chat_id", c.EffectiveChat.Id).
+			Msg("error getting persona, using the default")
+	}
+
+	systemInstruction := cmp.Or(
+		persona.SystemPrompt.String,
+		p.credentialService.GetKey("ai_system_instruction"),
+		p.credentialService.GetKey("openai_system_instruction"),
+		p.credentialService.GetKey("google_gemini_system_instruction"),
+		DefaultSystemInstruction,
+	)
+	systemInstruction += fmt.Sprintf("\n\n%s Antworte nur auf %s.", FormattingInstruction, cmp.Or(persona.Language.String, DefaultLanguage))
+	systemInstruction += fmt.Sprintf("\n\nHeute ist %s.", utils.LocalizeDatestring(time.Now().Format("Monday, der 02.01.2006")))
+
+	var tools []llm.Tool
+	for _, tool := range p.tools(b, c) {
+		if allowsTool(persona, tool.Definition().Name) {
+			tools = append(tools, tool)
+		}
+	}
+
+	for _, tool := range tools {
+		switch tool.Definition().Name {
+		case "memory_search":
+			systemInstruction += "\n\n" + MemoryInstruction
+		case "memory_save":
+			systemInstruction += " " + MemorySaveInstruction
+		}
+	}
+
+	var temperature *float64
+	if persona.Temperature.Valid {
+		temperature = &persona.Temperature.Float64
+	}
+
+	conversationID, history := p.conversation(b, c.EffectiveMessage)
+	previousLength := len(history)
+
+	var inputText strings.Builder
+
+	if conversationID != 0 {
+		// The answer that is replied to is already part of the history
+		if c.EffectiveMessage.Quote != nil && c.EffectiveMessage.Quote.Text != "" {
+			inputText.WriteString("-- Beziehe dich nur auf folgenden Teil deiner Antwort: --\n")
+			inputText.WriteString(c.EffectiveMessage.Quote.Text)
+			inputText.WriteString("\n-- ENDE --\n")
+		}
+	} else if tgUtils.IsReply(c.EffectiveMessage) {
+		if c.EffectiveMessage.ReplyToMessage.GetText() != "" {
+			inputText.WriteString("-- ZUSÄTZLICHER KONTEXT --\n")
+			inputText.WriteString("Dies ist zusätzlicher Kontext. Wiederhole diesen nicht wortwörtlich!\n\n")
+			inputText.WriteString("Nachricht")
+			if from := c.EffectiveMessage.ReplyToMessage.From; from != nil {
+				inputText.WriteString(fmt.Sprintf(" von %s", from.FirstName))
+				if from.LastName != "" {
+					inputText.WriteString(fmt.Sprintf(" %s", from.LastName))
+				}
+			}
+			inputText.WriteString(":\n")
+			inputText.WriteString(c.EffectiveMessage.ReplyToMessage.GetText())
+
+			if c.EffectiveMessage.Quote != nil && c.EffectiveMessage.Quote.Text != "" {
+				inputText.WriteString("\n-- Beziehe dich nur auf folgenden Textteil: --\n")
+				inputText.WriteString(c.EffectiveMessage.Quote.Text)
+			}
+
+			inputText.WriteString("\n-- ZUSÄTZLICHER KONTEXT ENDE --\n")
+		}
+	}
+
+	// The answer that is replied to in a conversation has no media of its own
+	allMedia := []media{messageMedia(c.EffectiveMessage)}
+	if conversationID == 0 && tgUtils.IsReply(c.EffectiveMessage) {
+		allMedia = append(allMedia, messageMedia(c.EffectiveMessage.ReplyToMessage))
+	}
+	if c.EffectiveMessage.ExternalReply != nil {
+		allMedia = append(allMedia, externalMedia(c.EffectiveMessage.ExternalReply))
+	}
+
+	var attachments []llm.Part
+	for _, m := range allMedia {
+		parts, err := p.attachmentParts(b, c, m)
+		if err != nil {
+			if attachmentErr, ok := errors.AsType[*attachmentError](err); ok {
+				_, err := c.EffectiveMessage.ReplyMessage(b, "❌ "+attachmentErr.message, utils.DefaultSendOptions())
+				return err
+			}
+			guid := xid.New().String()
+			log.Err(err).Str("guid", guid).Msg("Failed to read attachment")
+			_, err := c.EffectiveMessage.ReplyMessage(b, fmt.Sprintf("❌ Es ist ein Fehler aufgetreten.%s", utils.EmbedGUID(guid)), utils.DefaultSendOptions())
+			return err
+		}
+		attachments = append(attachments, parts...)
+	}
+
+	inputText.WriteString(fmt.Sprintf("%s: %s", c.EffectiveMessage.From.FirstName, prompt(c)))
+
+	userMessage := llm.TextMessage(llm.RoleUser, inputText.String())
+	userMessage.Parts = append(userMessage.Parts, attachments...)
+
+	_, _ = c.EffectiveChat.SendAction(b, gotgbot.ChatActionTyping, nil)
+
+	history = append(history, userMessage)
+
+	req := llm.Request{
+		Instructions:    systemInstruction,
+		Messages:        history,
+		MaxOutputTokens: MaxOutputTokens,
+		Temperature:     temperature,
+	}
+	onToolCalls := func([]llm.ToolCall) {
+		_, _ = c.EffectiveChat.SendAction(b, gotgbot.ChatActionTyping, nil)
+	}
+
+	var stream *streamReply
+	if p.settingsService.Get(model.ScopeOf(settingStreaming, c.EffectiveChat, nil), p.Name(), settingStreaming) == "true" {
+		stream, err = newStreamReply(b, c.EffectiveMessage, tools)
+		if err != nil {
+			log.Err(err).Int64("chat_id", c.EffectiveChat.Id).Msg("Failed to send placeholder, not streaming")
+		} else {
+			req.OnText = stream.onText
+			onToolCalls = stream.onToolCalls
+		}
+	}
+
+	result, err := llm.Run(llm.Fallback(providers...), req, tools, onToolCalls)
+	if err != nil {
+		if stream != nil {
+			stream.delete()
+		}
+		return p.handleAPIError(b, c, err, conversationID, len(attachments) > 0)
+	}
+
+	log.Debug().
+		Str("provider", result.Provider).
+		Int("input_tokens", result.Usage.InputTokens).
+		Int("output_tokens", result.Usage.OutputTokens).
+		Int64("chat_id", c.EffectiveChat.Id).
+		Msg("Got answer")
+
+	output := result.Text
+	if output == "" {
+		log.Error().Str("provider", result.Provider).Msg("Got no answer")
+		if stream != nil {
+			stream.delete()
+		}
+		_, err := c.EffectiveMessage.ReplyMessage(b,
+			fmt.Sprintf("❌ Keine Antwort von %s erhalten (eventuell gefiltert).", result.Provider),
+			utils.DefaultSendOptions(),
+		)
+		return err
+	}
+
+	history = append(history, llm.TextMessage(llm.RoleAssistant, output))
+	historyData, canContinue := encodeHistory(history)
+
+	if result.Incomplete {
+		log.Warn().Str("provider", result.Provider).Msg("Response is incomplete")
+		output += " […]"
+	}
+
+	if len(result.UsedTools) > 0 {
+		var prefix strings.Builder
+		prefix.WriteString("⚒️")
+		for _, tool := range result.UsedTools {
+			prefix.WriteString(tool.Emoji())
+		}
+		output = prefix.String() + " " + output
+	}
+
+	var sources []llm.Source
+	for _, tool := range tools {
+		if sourcesTool, ok := tool.(llm.SourcesTool); ok {
+			sources = append(sources, sourcesTool.Sources()...)
+		}
+	}
+
+	text := utils.MarkdownToHTML(output)
+	if links := sourceLinks(sources); links != "" {
+		text += "\n" + links
+	}
+
+	if result.Provider != providers[0].Name() {
+		text += fmt.Sprintf("\n\n<i>(%s war nicht erreichbar, Antwort von %s)</i>", providers[0].Name(), result.Provider)
+	}
+
+	if !canContinue {
+		// Only this answer can't be replied to, earlier answers of the conversation still can
+		text += "\n\n(Token-Limit fast erreicht, die Konversation kann nicht fortgesetzt werden)"
+	}
+
+	opts := &gotgbot.SendMessageOpts{
+		ReplyParameters: &gotgbot.ReplyParameters{
+			AllowSendingWithoutReply: true,
+		},
+		LinkPreviewOptions: &gotgbot.LinkPreviewOptions{
+			IsDisabled: true,
+		},
+		ParseMode: gotgbot.ParseModeHTML,
+	}
+	var answer []*gotgbot.Message
+	if stream != nil {
+		answer, err = stream.finish(text, opts)
+	} else {
+		answer, err = tgUtils.ReplySplit(b, c.EffectiveMessage, text, opts)
+	}
+	if canContinue && len(answer) > 0 {
+		p.saveConversation(c.EffectiveChat, conversationID, previousLength, historyData, len(history), answer)
+	}
+	return err
+}
+
+// onReply continues a conversation when one of its answers is replied to without "Bot," or with a voice message.
+func (p *Plugin) onReply(b *gotgbot.Bot, c plugin.GobotContext) error {
+	if regexBotPrefix.MatchString(prompt(c)) {
+		return nil
+	}
+	if conversationID, _ := p.conversation(b, c.EffectiveMessage); conversationID == 0 {
+		return nil
+	}
+	return p.onBot(b, c)
+}
+
+// reset ends the conversation of the answer that is replied to, or all conversations of the chat.
+func (p *Plugin) reset(b *gotgbot.Bot, c plugin.GobotContext) error {
+	var err error
+	if conversationID, _ := p.conversation(b, c.EffectiveMessage); conversationID != 0 {
+		err = p.llmService.DeleteConversation(c.EffectiveChat, conversationID)
+	} else {
+		err = p.llmService.DeleteConversations(c.EffectiveChat)
+	}
+	if err != nil {
+		guid := xid.New().String()
+		log.Error().
+			Err(err).
+			Str("guid", guid).
+			Int64("chat_id", c.EffectiveChat.Id).
+			Msg("error resetting conversation")
+		_, err := c.EffectiveMessage.ReplyMessage(b,
+			fmt.Sprintf("❌ Fehler beim Zurücksetzen der Konversation.%s", utils.EmbedGUID(guid)),
+			utils.DefaultSendOptions(),
+		)
+		return err
+	}
+	return nil
+}
+
+func (p *Plugin) onReset(b *gotgbot.Bot, c plugin.GobotContext) error {
+	if err := p.reset(b, c); err != nil {
+		return err
+	}
+	return tgUtils.AddReactionWithFallback(b, c.EffectiveMessage, "👍", &tgUtils.ReactionFallbackOpts{
+		Fallback: "✅",
+	})
+}
+
+func (p *Plugin) onResetAndRun(b *gotgbot.Bot, c plugin.GobotContext) error {
+	if err := p.reset(b, c); err != nil {
+		return err
+	}
+	return p.onBot(b, c)
+}
+
+// prompt returns the text of the message without the "Bot," prefix, it's empty for voice messages.
+func prompt(c plugin.GobotContext) string {
+	if len(c.Matches) < 2 {
+		return ""
+	}
+	return c.Matches[1]
+}
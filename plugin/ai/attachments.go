@@ -0,0 +1,391 @@
+package ai
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Brawl345/gobot/llm"
+	"github.com/Brawl345/gobot/plugin"
+	"github.com/Brawl345/gobot/utils"
+	"github.com/Brawl345/gobot/utils/httpUtils"
+	"github.com/Brawl345/gobot/utils/tgUtils"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/ledongthuc/pdf"
+)
+
+const (
+	MaxPDFBytes           = 10_000_000 // Stays below the request limits of the APIs after base64 encoding
+	MaxTextDocumentBytes  = 1_000_000
+	MaxDocumentCharacters = 50_000
+)
+
+var regexPDFPage = regexp.MustCompile(`/Type\s*/Page\b`)
+
+// textMimeTypes are documents without a text/* MIME type that are read as text.
+var textMimeTypes = map[string]struct{}{
+	"application/json":        {},
+	"application/xml":         {},
+	"application/javascript":  {},
+	"application/x-sh":        {},
+	"application/x-yaml":      {},
+	"application/yaml":        {},
+	"application/toml":        {},
+	"application/sql":         {},
+	"application/x-httpd-php": {},
+	"application/x-python":    {},
+	"application/x-subrip":    {},
+}
+
+// textExtensions are read as text regardless of the MIME type, Telegram clients often send code as application/octet-stream.
+var textExtensions = map[string]struct{}{
+	".c": {}, ".cfg": {}, ".conf": {}, ".cpp": {}, ".cs": {}, ".css": {}, ".csv": {}, ".diff": {}, ".env": {},
+	".go": {}, ".h": {}, ".html": {}, ".ini": {}, ".java": {}, ".js": {}, ".json": {}, ".kt": {}, ".log": {},
+	".lua": {}, ".md": {}, ".patch": {}, ".php": {}, ".py": {}, ".rb": {}, ".rs": {}, ".sh": {}, ".sql": {},
+	".srt": {}, ".swift": {}, ".toml": {}, ".ts": {}, ".tsx": {}, ".txt": {}, ".xml": {}, ".yaml": {}, ".yml": {},
+}
+
+type (
+	// Transcriber converts audio from Telegram to text, implemented by the speech_to_text plugin.
+	Transcriber interface {
+		plugin.Plugin
+		Transcribe(b *gotgbot.Bot, fileID, mimeType string, fileSize, duration int64) (string, error)
+	}
+
+	// media holds the attachments of a message or external reply, which are different types in the Bot API.
+	media struct {
+		photo     []gotgbot.PhotoSize
+		animation *gotgbot.Animation
+		audio     *gotgbot.Audio
+		document  *gotgbot.Document
+		sticker   *gotgbot.Sticker
+		video     *gotgbot.Video
+		videoNote *gotgbot.VideoNote
+		voice     *gotgbot.Voice
+	}
+
+	// attachmentError is shown to the user instead of the generic error message.
+	attachmentError struct {
+		message string
+	}
+)
+
+func (e *attachmentError) Error() string {
+	return e.message
+}
+
+func messageMedia(message *gotgbot.Message) media {
+	return media{
+		photo:     message.Photo,
+		animation: message.Animation,
+		audio:     message.Audio,
+		document:  message.Document,
+		sticker:   message.Sticker,
+		video:     message.Video,
+		videoNote: message.VideoNote,
+		voice:     message.Voice,
+	}
+}
+
+func externalMedia(reply *gotgbot.ExternalReplyInfo) media {
+	return media{
+		photo:     reply.Photo,
+		animation: reply.Animation,
+		audio:     reply.Audio,
+		document:  reply.Document,
+		sticker:   reply.Sticker,
+		video:     reply.Video,
+		videoNote: reply.VideoNote,
+		voice:     reply.Voice,
+	}
+}
+
+func (m media) chatAction() string {
+	switch {
+	case m.photo != nil, m.sticker != nil:
+		return gotgbot.ChatActionUploadPhoto
+	case m.voice != nil, m.audio != nil:
+		return gotgbot.ChatActionUploadVoice
+	case m.video != nil, m.animation != nil:
+		return gotgbot.ChatActionUploadVideo
+	case m.videoNote != nil:
+		return gotgbot.ChatActionUploadVideoNote
+	case m.document != nil:
+		return gotgbot.ChatActionUploadDocument
+	}
+	return ""
+}
+
+// attachmentParts converts the media to parts the model can read. Photos, stickers and PDFs are passed as they are,
+// text documents are inlined, audio is transcribed and videos and GIFs are represented by their first frame.
+func (p *Plugin) attachmentParts(b *gotgbot.Bot, c plugin.GobotContext, m media) ([]llm.Part, error) {
+	action := m.chatAction()
+	if action == "" {
+		return nil, nil
+	}
+	_, _ = c.EffectiveChat.SendAction(b, action, nil)
+
+	switch {
+	case m.photo != nil:
+		image, err := downloadImage(b, tgUtils.GetBestResolution(m.photo))
+		if err != nil {
+			return nil, err
+		}
+		return []llm.Part{image}, nil
+
+	case m.sticker != nil:
+		parts := []llm.Part{{Text: fmt.Sprintf("[Sticker %s]", m.sticker.Emoji)}}
+		if !m.sticker.IsAnimated && !m.sticker.IsVideo {
+			data, err := download(b, m.sticker.FileId, m.sticker.FileSize, tgUtils.MaxFilesizeDownload)
+			if err != nil {
+				return nil, err
+			}
+			return append(parts, llm.Part{Image: &llm.Image{MimeType: "image/webp", Data: data}}), nil
+		}
+		if m.sticker.Thumbnail != nil {
+			image, err := downloadImage(b, m.sticker.Thumbnail)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, image)
+		}
+		return parts, nil
+
+	// Animations are also set as document, so they need to be checked first
+	case m.animation != nil:
+		return p.videoParts(b, c, "GIF", m.animation.Thumbnail, nil)
+
+	case m.video != nil:
+		return p.videoParts(b, c, "Video", m.video.Thumbnail, func() (string, error) {
+			return p.transcribe(b, c, m.video.FileId, m.video.MimeType, m.video.FileSize, m.video.Duration)
+		})
+
+	case m.videoNote != nil:
+		return p.videoParts(b, c, "Videonachricht", m.videoNote.Thumbnail, func() (string, error) {
+			return p.transcribe(b, c, m.videoNote.FileId, "video/mp4", m.videoNote.FileSize, m.videoNote.Duration)
+		})
+
+	case m.voice != nil:
+		transcript, err := p.transcribe(b, c, m.voice.FileId, m.voice.MimeType, m.voice.FileSize, m.voice.Duration)
+		if err != nil {
+			return nil, err
+		}
+		return []llm.Part{{Text: fmt.Sprintf("[Sprachnachricht, Transkript]\n%s", transcript)}}, nil
+
+	case m.audio != nil:
+		transcript, err := p.transcribe(b, c, m.audio.FileId, m.audio.MimeType, m.audio.FileSize, m.audio.Duration)
+		if err != nil {
+			return nil, err
+		}
+		title := strings.TrimSpace(fmt.Sprintf("%s - %s", m.audio.Performer, m.audio.Title))
+		if m.audio.Title == "" {
+			title = m.audio.FileName
+		}
+		return []llm.Part{{Text: fmt.Sprintf("[Audiodatei \"%s\", Transkript]\n%s", title, transcript)}}, nil
+
+	case m.document != nil:
+		return documentParts(b, m.document)
+	}
+
+	return nil, nil
+}
+
+// videoParts returns the first frame and, if transcribe is not nil and works, the transcript of a video.
+func (p *Plugin) videoParts(b *gotgbot.Bot, c plugin.GobotContext, kind string, thumbnail *gotgbot.PhotoSize, transcribe func() (string, error)) ([]llm.Part, error) {
+	description := fmt.Sprintf("[%s", kind)
+
+	var image *llm.Part
+	if thumbnail != nil {
+		part, err := downloadImage(b, thumbnail)
+		if err != nil {
+			return nil, err
+		}
+		image = &part
+		description += ", erstes Bild angehängt"
+	}
+
+	transcript := ""
+	if transcribe != nil {
+		var err error
+		transcript, err = transcribe()
+		if err != nil {
+			// The frame is enough to work with
+			log.Debug().Err(err).Int64("chat_id", c.EffectiveChat.Id).Msg("Video is not transcribed")
+		}
+	}
+	if transcript != "" {
+		description += fmt.Sprintf(", Transkript des Tons]\n%s", transcript)
+	} else {
+		description += "]"
+	}
+
+	parts := []llm.Part{{Text: description}}
+	if image != nil {
+		parts = append(parts, *image)
+	}
+	return parts, nil
+}
+
+// transcribe uses the speech_to_text plugin if it's available in the chat.
+func (p *Plugin) transcribe(b *gotgbot.Bot, c plugin.GobotContext, fileID, mimeType string, fileSize, duration int64) (string, error) {
+	if p.transcriber == nil || !p.isPluginAvailable(c, p.transcriber) {
+		return "", &attachmentError{"Audio kann nicht transkribiert werden, das Plugin \"speech_to_text\" ist nicht verfügbar."}
+	}
+
+	transcript, err := p.transcriber.Transcribe(b, fileID, mimeType, fileSize, duration)
+	if err != nil {
+		log.Err(err).Int64("chat_id", c.EffectiveChat.Id).Msg("Failed to transcribe audio")
+		return "", &attachmentError{"Audio konnte nicht transkribiert werden (zu groß, zu lang oder nicht eingerichtet)."}
+	}
+	if transcript == "" {
+		return "(kein Text erkannt)", nil
+	}
+	return transcript, nil
+}
+
+// documentParts passes PDFs and images on and inlines text documents. The text of PDFs is extracted
+// for models that can't read them.
+func documentParts(b *gotgbot.Bot, document *gotgbot.Document) ([]llm.Part, error) {
+	fileName := document.FileName
+	if fileName == "" {
+		fileName = "Dokument"
+	}
+
+	mimeType := strings.ToLower(document.MimeType)
+	if mimeType == "application/pdf" {
+		data, err := download(b, document.FileId, document.FileSize, MaxPDFBytes)
+		if err != nil {
+			return nil, err
+		}
+		return []llm.Part{
+			{Text: fmt.Sprintf("[PDF \"%s\"]", fileName)},
+			{File: &llm.File{Name: fileName, MimeType: mimeType, Data: data, Text: pdfText(data)}},
+		}, nil
+	}
+
+	if imageType, ok := supportedImageTypes[mimeType]; ok {
+		data, err := download(b, document.FileId, document.FileSize, tgUtils.MaxFilesizeDownload)
+		if err != nil {
+			return nil, err
+		}
+		return []llm.Part{{Image: &llm.Image{MimeType: imageType, Data: data}}}, nil
+	}
+
+	if !isTextDocument(mimeType, fileName) {
+		return nil, &attachmentError{fmt.Sprintf("Dateityp wird nicht unterstützt (%s).", cmp.Or(mimeType, "unbekannt"))}
+	}
+
+	data, err := download(b, document.FileId, document.FileSize, MaxTextDocumentBytes)
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(data) {
+		return nil, &attachmentError{"Die Datei ist keine UTF-8-Textdatei."}
+	}
+
+	text := string(data)
+	if utf8.RuneCountInString(text) > MaxDocumentCharacters {
+		text = utils.TruncateText(text, MaxDocumentCharacters) + "\n[gekürzt]"
+	}
+	return []llm.Part{{Text: fmt.Sprintf("[Datei \"%s\"]\n```\n%s\n```", fileName, text)}}, nil
+}
+
+func isTextDocument(mimeType, fileName string) bool {
+	if strings.HasPrefix(mimeType, "text/") {
+		return true
+	}
+	if _, ok := textMimeTypes[mimeType]; ok {
+		return true
+	}
+	_, ok := textExtensions[strings.ToLower(path.Ext(fileName))]
+	return ok
+}
+
+// downloadImage downloads a photo or thumbnail, which can be JPEG or WebP.
+func downloadImage(b *gotgbot.Bot, photo *gotgbot.PhotoSize) (llm.Part, error) {
+	data, err := download(b, photo.FileId, photo.FileSize, tgUtils.MaxFilesizeDownload)
+	if err != nil {
+		return llm.Part{}, err
+	}
+	mimeType, ok := supportedImageTypes[http.DetectContentType(data)]
+	if !ok {
+		mimeType = "image/jpeg"
+	}
+	return llm.Part{Image: &llm.Image{MimeType: mimeType, Data: data}}, nil
+}
+
+// download reads a file from Telegram, files larger than limit return an attachmentError.
+func download(b *gotgbot.Bot, fileID string, fileSize int64, limit int64) ([]byte, error) {
+	tooLarge := &attachmentError{fmt.Sprintf("Die Datei ist zu groß (max. %s).", utils.HumanizeSize(limit))}
+	if fileSize > limit {
+		return nil, tooLarge
+	}
+
+	file, err := httpUtils.DownloadFile(b, fileID)
+	if err != nil {
+		log.Err(err).Str("file_id", fileID).Msg("Failed to get file from Telegram")
+		return nil, &attachmentError{"Konnte Datei nicht von Telegram herunterladen."}
+	}
+	defer func(file io.ReadCloser) {
+		if closeErr := file.Close(); closeErr != nil {
+			log.Err(closeErr).Msg("Failed to close file")
+		}
+	}(file)
+
+	data, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if int64(len(data)) > limit {
+		return nil, tooLarge
+	}
+	return data, nil
+}
+
+// pdfPageCount estimates the number of pages of a PDF. Page objects can be hidden in compressed
+// object streams, then one page per 50 KB is assumed.
+func pdfPageCount(data []byte) int {
+	if pages := len(regexPDFPage.FindAllIndex(data, -1)); pages > 0 {
+		return pages
+	}
+	return max(len(data)/50_000, 1)
+}
+
+// pdfText extracts the text of a PDF, truncated to MaxDocumentCharacters. It's empty if the PDF
+// has no text layer, like scans, or can't be parsed.
+func pdfText(data []byte) (text string) {
+	defer func() {
+		// The parser panics on some broken files
+		if r := recover(); r != nil {
+			log.Debug().Interface("panic", r).Msg("Failed to extract text from PDF")
+			text = ""
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to read PDF")
+		return ""
+	}
+	plainText, err := reader.GetPlainText()
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to extract text from PDF")
+		return ""
+	}
+	content, err := io.ReadAll(io.LimitReader(plainText, MaxTextDocumentBytes))
+	if err != nil {
+		return ""
+	}
+
+	text = strings.TrimSpace(strings.ToValidUTF8(string(content), ""))
+	if utf8.RuneCountInString(text) > MaxDocumentCharacters {
+		text = utils.TruncateText(text, MaxDocumentCharacters) + "\n[gekürzt]"
+	}
+	return text
+}
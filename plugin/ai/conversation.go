@@ -0,0 +1,102 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Brawl345/gobot/llm"
+	"github.com/Brawl345/gobot/model"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+// ConversationTTL is how long a conversation can be continued after the last answer
+const ConversationTTL = 24 * time.Hour
+
+// conversation returns the id and history of the conversation that the message continues by replying
+// to an answer of the bot. The id is 0 if the message starts a new conversation. For replies to older
+// answers, the history ends with that answer and saving forks the conversation.
+func (p *Plugin) conversation(b *gotgbot.Bot, message *gotgbot.Message) (int64, []llm.Message) {
+	reply := message.ReplyToMessage
+	if reply == nil || reply.From == nil || reply.From.Id != b.Id {
+		return 0, nil
+	}
+
+	conversation, err := p.llmService.GetConversation(&message.Chat, reply.MessageId)
+	if err != nil {
+		if !errors.Is(err, model.ErrNotFound) {
+			log.Err(err).
+				Int64("chat_id", message.Chat.Id).
+				Int64("message_id", reply.MessageId).
+				Msg("error getting conversation")
+		}
+		return 0, nil
+	}
+
+	var history []llm.Message
+	if err := json.Unmarshal([]byte(conversation.History), &history); err != nil {
+		log.Err(err).
+			Int64("chat_id", message.Chat.Id).
+			Int64("conversation_id", conversation.ID).
+			Msg("error unmarshalling history from DB")
+		return 0, nil
+	}
+	if conversation.HistoryLength < len(history) {
+		history = history[:conversation.HistoryLength]
+	}
+	return conversation.ID, history
+}
+
+// encodeHistory returns the history as JSON and false if it got too long to be continued.
+func encodeHistory(history []llm.Message) ([]byte, bool) {
+	inputChars := 0
+	for _, message := range history {
+		for _, part := range message.Parts {
+			inputChars += len(part.Text)
+			if part.Image != nil {
+				inputChars += TokensPerImage
+			}
+			if part.File != nil {
+				inputChars += pdfPageCount(part.File.Data) * CharactersPerPDFPage
+			}
+		}
+	}
+
+	jsonData, err := json.Marshal(&history)
+	if err != nil {
+		log.Err(err).Msg("error marshalling history")
+		return nil, false
+	}
+
+	return jsonData, inputChars <= MaxInputCharacters && len(jsonData) <= MaxHistoryBytes
+}
+
+// saveConversation stores the history and links the sent answer to the conversation, so replies continue it.
+func (p *Plugin) saveConversation(chat *gotgbot.Chat, id int64, previousLength int, history []byte, historyLength int, answer []*gotgbot.Message) {
+	messageIDs := make([]int64, 0, len(answer))
+	for _, message := range answer {
+		if message != nil {
+			messageIDs = append(messageIDs, message.MessageId)
+		}
+	}
+
+	if _, err := p.llmService.SaveConversation(chat, id, previousLength, string(history), historyLength, messageIDs, ConversationTTL); err != nil {
+		log.Err(err).
+			Int64("chat_id", chat.Id).
+			Int64("conversation_id", id).
+			Msg("error saving conversation")
+	}
+}
+
+// deleteConversation ends a conversation, e.g. after an error caused by its history.
+func (p *Plugin) deleteConversation(chat *gotgbot.Chat, id int64) {
+	if id == 0 {
+		return
+	}
+	if err := p.llmService.DeleteConversation(chat, id); err != nil {
+		log.Err(err).
+			Int64("chat_id", chat.Id).
+			Int64("conversation_id", id).
+			Msg("error deleting conversation")
+	}
+}
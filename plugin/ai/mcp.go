@@ -0,0 +1,354 @@
+package ai
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Brawl345/gobot/llm"
+	"github.com/Brawl345/gobot/mcp"
+	"github.com/Brawl345/gobot/utils"
+)
+
+const (
+	MCPConnectTimeout   = 10 * time.Second
+	MCPDefaultTimeout   = 30 * time.Second
+	MCPRetryInterval    = 5 * time.Minute // After a server failed, it is not contacted again before this
+	MaxMCPToolNameChars = 64
+	MaxMCPOutputLength  = 50_000
+)
+
+var regexInvalidToolName = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+type (
+	// mcpServerConfig is one entry of the "mcp_servers" credential. Either Command (stdio) or URL
+	// (streamable HTTP) has to be set. Only the tools in Tools are offered to the model, "*" allows all.
+	mcpServerConfig struct {
+		Name       string            `json:"name"`
+		Command    []string          `json:"command"`
+		Env        map[string]string `json:"env"`
+		InheritEnv bool              `json:"inherit_env"` // Pass the whole environment of the bot instead of PATH, HOME and LANG
+		URL        string            `json:"url"`
+		Headers    map[string]string `json:"headers"`
+		Tools      []string          `json:"tools"`
+		Chats      []int64           `json:"chats"`   // Empty for all chats
+		Timeout    int               `json:"timeout"` // Seconds per tool call
+	}
+
+	// mcpServer connects lazily and keeps the connection until it fails or the config changes.
+	mcpServer struct {
+		config   mcpServerConfig
+		mu       sync.Mutex
+		client   *mcp.Client
+		tools    []mcp.Tool
+		failedAt time.Time
+	}
+
+	mcpManager struct {
+		mu        sync.Mutex
+		rawConfig string
+		servers   []*mcpServer
+	}
+
+	// MCPTool is a tool of an MCP server.
+	MCPTool struct {
+		server *mcpServer
+		tool   mcp.Tool
+		name   string
+		chatID int64
+	}
+)
+
+// tools returns the allowed tools of all servers that are configured for the chat.
+// rawConfig is the "mcp_servers" credential, the connections are renewed when it changes.
+func (m *mcpManager) tools(rawConfig string, chatID int64) []llm.Tool {
+	m.mu.Lock()
+	if rawConfig != m.rawConfig {
+		m.reload(rawConfig)
+	}
+	servers := m.servers
+	m.mu.Unlock()
+
+	var tools []llm.Tool
+	for _, server := range servers {
+		if len(server.config.Chats) > 0 && !slices.Contains(server.config.Chats, chatID) {
+			continue
+		}
+		for _, tool := range server.allowedTools() {
+			tools = append(tools, &MCPTool{
+				server: server,
+				tool:   tool,
+				name:   mcpToolName(server.config.Name, tool.Name),
+				chatID: chatID,
+			})
+		}
+	}
+	return tools
+}
+
+// reload closes the old connections and parses the new config. m.mu must be held.
+func (m *mcpManager) reload(rawConfig string) {
+	for _, server := range m.servers {
+		go server.close()
+	}
+	m.rawConfig = rawConfig
+	m.servers = nil
+
+	if strings.TrimSpace(rawConfig) == "" {
+		return
+	}
+
+	var configs []mcpServerConfig
+	if err := json.Unmarshal([]byte(rawConfig), &configs); err != nil {
+		log.Err(err).Msg("mcp_servers is not a valid JSON array")
+		return
+	}
+
+	names := make(map[string]struct{}, len(configs))
+	for _, config := range configs {
+		if config.Name == "" || (len(config.Command) == 0) == (config.URL == "") {
+			log.Error().Str("server", config.Name).Msg("MCP server needs a name and either a command or a URL, skipping")
+			continue
+		}
+		if _, exists := names[config.Name]; exists {
+			log.Error().Str("server", config.Name).Msg("MCP server name is already taken, skipping")
+			continue
+		}
+		names[config.Name] = struct{}{}
+		m.servers = append(m.servers, &mcpServer{config: config})
+	}
+}
+
+// allowedTools connects to the server if needed and returns the tools on the allow list.
+func (s *mcpServer) allowedTools() []mcp.Tool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client == nil {
+		if time.Since(s.failedAt) < MCPRetryInterval {
+			return nil
+		}
+		if err := s.connect(); err != nil {
+			log.Err(err).Str("server", s.config.Name).Msg("Failed to connect to MCP server")
+			s.failedAt = time.Now()
+			return nil
+		}
+	}
+
+	var tools []mcp.Tool
+	for _, tool := range s.tools {
+		if slices.Contains(s.config.Tools, "*") || slices.Contains(s.config.Tools, tool.Name) {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
+
+// connect initializes the connection and lists the tools. s.mu must be held.
+func (s *mcpServer) connect() error {
+	var transport mcp.Transport
+	if len(s.config.Command) > 0 {
+		var err error
+		transport, err = mcp.NewStdioTransport(s.config.Command, s.config.Env, s.config.InheritEnv)
+		if err != nil {
+			return err
+		}
+	} else {
+		transport = mcp.NewHTTPTransport(s.config.URL, s.config.Headers)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), MCPConnectTimeout)
+	defer cancel()
+
+	client, err := mcp.Connect(ctx, transport, mcp.Implementation{Name: "gobot", Version: "1.0.0"})
+	if err != nil {
+		return err
+	}
+
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		if closeErr := client.Close(); closeErr != nil {
+			log.Err(closeErr).Str("server", s.config.Name).Msg("Failed to close MCP server")
+		}
+		return fmt.Errorf("failed to list tools: %w", err)
+	}
+
+	log.Info().
+		Str("server", s.config.Name).
+		Str("server_name", client.ServerInfo.Name).
+		Int("tools", len(tools)).
+		Msg("Connected to MCP server")
+	s.client = client
+	s.tools = tools
+	return nil
+}
+
+// fail closes the connection after a transport error, it is reopened after MCPRetryInterval.
+func (s *mcpServer) fail(client *mcp.Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Another call might have failed and reconnected already
+	if s.client != client {
+		return
+	}
+	s.failedAt = time.Now()
+	s.closeLocked()
+}
+
+func (s *mcpServer) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeLocked()
+}
+
+func (s *mcpServer) closeLocked() {
+	if s.client == nil {
+		return
+	}
+	if err := s.client.Close(); err != nil {
+		log.Err(err).Str("server", s.config.Name).Msg("Failed to close MCP server")
+	}
+	s.client = nil
+	s.tools = nil
+}
+
+func (s *mcpServer) currentClient() *mcp.Client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.client
+}
+
+func (s *mcpServer) timeout() time.Duration {
+	if s.config.Timeout > 0 {
+		return time.Duration(s.config.Timeout) * time.Second
+	}
+	return MCPDefaultTimeout
+}
+
+// mcpToolName prefixes the tool with the server name so tools of different servers can't collide.
+// Providers only allow some characters and 64 characters at most.
+func mcpToolName(server, tool string) string {
+	name := regexInvalidToolName.ReplaceAllString(server+"_"+tool, "_")
+	if len(name) > MaxMCPToolNameChars {
+		name = name[:MaxMCPToolNameChars]
+	}
+	return name
+}
+
+// mcpSchema makes sure the input schema is an object, which is what all providers expect.
+func mcpSchema(inputSchema json.RawMessage) json.RawMessage {
+	var schema map[string]any
+	if err := json.Unmarshal(inputSchema, &schema); err != nil || schema == nil || schema["type"] != "object" {
+		return json.RawMessage(`{"type":"object","properties":{}}`)
+	}
+
+	delete(schema, "$schema")
+	if _, ok := schema["properties"]; !ok {
+		schema["properties"] = map[string]any{}
+	}
+
+	normalized, err := json.Marshal(schema)
+	if err != nil {
+		return json.RawMessage(`{"type":"object","properties":{}}`)
+	}
+	return normalized
+}
+
+func (t *MCPTool) Definition() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Name:        t.name,
+		Description: cmp.Or(t.tool.Description, t.tool.Name),
+		Schema:      mcpSchema(t.tool.InputSchema),
+		Strict:      false,
+	}
+}
+
+func (t *MCPTool) Execute(arguments string) (llm.ToolOutput, error) {
+	log.Debug().
+		Str("server", t.server.config.Name).
+		Str("tool", t.tool.Name).
+		Str("arguments", arguments).
+		Int64("chat_id", t.chatID).
+		Msg("MCP tool call")
+
+	client := t.server.currentClient()
+	if client == nil {
+		return llm.ToolOutput{Text: "Error: the server is currently unavailable"}, nil
+	}
+
+	timeout := t.server.timeout()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	result, err := client.CallTool(ctx, t.tool.Name, json.RawMessage(arguments))
+	if errors.Is(err, context.DeadlineExceeded) {
+		return llm.ToolOutput{Text: fmt.Sprintf("Error: the tool did not answer within %s", timeout)}, nil
+	}
+	if mcpErr, ok := errors.AsType[*mcp.Error](err); ok {
+		return llm.ToolOutput{Text: fmt.Sprintf("Error: %s", mcpErr.Message)}, nil
+	}
+	if err != nil {
+		t.server.fail(client)
+		return llm.ToolOutput{}, fmt.Errorf("failed to call %s of MCP server %s: %w", t.tool.Name, t.server.config.Name, err)
+	}
+
+	output := mcpToolOutput(result)
+	if result.IsError {
+		output.Text = "Error: " + output.Text
+	}
+	return output, nil
+}
+
+func (t *MCPTool) Emoji() string {
+	return "🔌"
+}
+
+// mcpToolOutput converts the content of the result, only text and images are passed to the model.
+func mcpToolOutput(result *mcp.CallToolResult) llm.ToolOutput {
+	var output llm.ToolOutput
+	var texts []string
+
+	for _, content := range result.Content {
+		switch content.Type {
+		case "text":
+			texts = append(texts, content.Text)
+		case "image":
+			mimeType, ok := supportedImageTypes[content.MimeType]
+			if !ok {
+				texts = append(texts, fmt.Sprintf("[Bild im nicht unterstützten Format %s]", content.MimeType))
+				continue
+			}
+			data, err := base64.StdEncoding.DecodeString(content.Data)
+			if err != nil {
+				log.Warn().Err(err).Msg("Got invalid image from MCP server")
+				continue
+			}
+			output.Images = append(output.Images, llm.Image{MimeType: mimeType, Data: data})
+		case "resource":
+			if content.Resource != nil && content.Resource.Text != "" {
+				texts = append(texts, fmt.Sprintf("%s:\n%s", content.Resource.URI, content.Resource.Text))
+			}
+		case "resource_link":
+			texts = append(texts, content.URI)
+		default:
+			texts = append(texts, fmt.Sprintf("[%s wird nicht unterstützt]", content.Type))
+		}
+	}
+
+	output.Text = strings.Join(texts, "\n\n")
+	if len([]rune(output.Text)) > MaxMCPOutputLength {
+		output.Text = utils.TruncateText(output.Text, MaxMCPOutputLength) + "\n[gekürzt]"
+	}
+	if output.Text == "" && len(output.Images) == 0 {
+		output.Text = "(leer)"
+	}
+	return output
+}
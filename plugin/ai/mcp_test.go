@@ -0,0 +1,50 @@
+package ai
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMCPSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   string
+	}{
+		{"object", `{"type":"object","properties":{"path":{"type":"string"}},"required":["path"]}`, `{"properties":{"path":{"type":"string"}},"required":["path"],"type":"object"}`},
+		{"removes $schema", `{"$schema":"http://json-schema.org/draft-07/schema#","type":"object","properties":{}}`, `{"properties":{},"type":"object"}`},
+		{"adds properties", `{"type":"object"}`, `{"properties":{},"type":"object"}`},
+		{"not an object", `{"type":"string"}`, `{"type":"object","properties":{}}`},
+		{"null", `null`, `{"type":"object","properties":{}}`},
+		{"missing", ``, `{"type":"object","properties":{}}`},
+		{"invalid", `{`, `{"type":"object","properties":{}}`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := mcpSchema(json.RawMessage(tc.schema))
+			if string(got) != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMCPToolName(t *testing.T) {
+	tests := []struct {
+		server string
+		tool   string
+		want   string
+	}{
+		{"files", "read_file", "files_read_file"},
+		{"my docs", "search.v2", "my_docs_search_v2"},
+		{"files", strings.Repeat("a", 100), "files_" + strings.Repeat("a", MaxMCPToolNameChars-len("files_"))},
+	}
+
+	for _, tc := range tests {
+		if got := mcpToolName(tc.server, tc.tool); got != tc.want {
+			t.Errorf("mcpToolName(%q, %q) = %q, want %q", tc.server, tc.tool, got, tc.want)
+		}
+	}
+}
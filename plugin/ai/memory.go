@@ -0,0 +1,300 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Brawl345/gobot/llm"
+	"github.com/Brawl345/gobot/model"
+	"github.com/Brawl345/gobot/plugin"
+	"github.com/Brawl345/gobot/utils"
+	"github.com/Brawl345/gobot/utils/tgUtils"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/rs/xid"
+)
+
+const (
+	MaxMemories               = 50
+	MaxMemoryLength           = 300
+	MaxSummaryInputCharacters = 50_000
+	MemoryInstruction         = "Du hast ein Langzeitgedächtnis für diesen Chat. Suche darin mit memory_search, wenn Wissen über Nutzer oder frühere Absprachen helfen könnte."
+	MemorySaveInstruction     = "Speichere dauerhaft nützliche Fakten mit memory_save. Flüchtiges wird nicht gespeichert."
+	summaryInstruction        = "Du erhältst eine abgelaufene Konversation aus einem Telegram-Gruppenchat. Extrahiere Fakten, die auch in Wochen noch nützlich sind, z.B. Vorlieben, Eigenschaften oder Absprachen der Nutzer. Jeder Fakt ist ein kurzer, eigenständiger Satz mit Namen statt Pronomen und höchstens %d Zeichen lang. Bereits bekannte Fakten werden nicht wiederholt. Antworte nur mit einem JSON-Array aus Strings, z.B. [\"Max ist Vegetarier.\"], oder [] wenn es nichts Neues gibt."
+	summaryMaxOutputTokens    = 1000
+)
+
+var settingMemory = plugin.Setting{
+	Key:         "memory",
+	Name:        "Gedächtnis",
+	Description: "Die KI kann sich dauerhaft Fakten über den Chat merken und fasst abgelaufene Konversationen dafür zusammen. Verwalten mit /memory.",
+	Type:        plugin.SettingBool,
+	Default:     "false",
+}
+
+func (p *Plugin) memoryEnabled(chat *gotgbot.Chat) bool {
+	return p.settingsService.Get(model.ScopeOf(settingMemory, chat, nil), p.Name(), settingMemory) == "true"
+}
+
+// cleanup ends expired conversations. In chats with memory, they are summarized into memories first.
+func (p *Plugin) cleanup() {
+	log.Debug().Msg("starting cleanup")
+
+	conversations, err := p.llmService.GetExpiredConversations()
+	if err != nil {
+		log.Err(err).Msg("error getting expired conversations")
+		return
+	}
+
+	for _, conversation := range conversations {
+		chat := &gotgbot.Chat{Id: conversation.ChatID}
+		if p.memoryEnabled(chat) {
+			if err := p.summarize(chat, conversation); err != nil {
+				log.Err(err).
+					Int64("chat_id", chat.Id).
+					Int64("conversation_id", conversation.ID).
+					Msg("error summarizing conversation")
+			}
+		}
+		p.deleteConversation(chat, conversation.ID)
+	}
+}
+
+// summarize lets the AI extract facts worth remembering from the conversation and stores them as memories.
+func (p *Plugin) summarize(chat *gotgbot.Chat, conversation model.LLMConversation) error {
+	memories, err := p.llmService.GetMemories(chat)
+	if err != nil {
+		return fmt.Errorf("failed to get memories: %w", err)
+	}
+	if len(memories) >= MaxMemories {
+		return nil
+	}
+
+	var history []llm.Message
+	if err := json.Unmarshal([]byte(conversation.History), &history); err != nil {
+		return fmt.Errorf("failed to unmarshal history: %w", err)
+	}
+
+	var transcript strings.Builder
+	for _, message := range history {
+		text := message.Text()
+		if text == "" {
+			continue
+		}
+		if message.Role == llm.RoleAssistant {
+			transcript.WriteString("KI: ")
+		}
+		transcript.WriteString(text)
+		transcript.WriteString("\n\n")
+	}
+	input := transcript.String()
+	if len(input) > MaxSummaryInputCharacters {
+		// The end of the conversation is the most recent state
+		input = strings.ToValidUTF8(input[len(input)-MaxSummaryInputCharacters:], "")
+	}
+
+	var instructions strings.Builder
+	instructions.WriteString(fmt.Sprintf(summaryInstruction, MaxMemoryLength))
+	if len(memories) > 0 {
+		instructions.WriteString("\n\nBereits bekannte Fakten:\n")
+		for _, memory := range memories {
+			instructions.WriteString("- ")
+			instructions.WriteString(memory.Content)
+			instructions.WriteString("\n")
+		}
+	}
+
+	providers := p.providers(chat)
+	if len(providers) == 0 {
+		return errors.New("no provider configured")
+	}
+
+	result, err := llm.Run(llm.Fallback(providers...), llm.Request{
+		Instructions:    instructions.String(),
+		Messages:        []llm.Message{llm.TextMessage(llm.RoleUser, input)},
+		MaxOutputTokens: summaryMaxOutputTokens,
+	}, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	facts, err := parseFacts(result.Text)
+	if err != nil {
+		return fmt.Errorf("failed to parse facts %q: %w", result.Text, err)
+	}
+
+	for _, fact := range facts {
+		_, err := p.llmService.AddMemory(chat, fact, MaxMemories)
+		if errors.Is(err, model.ErrLimitReached) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save memory: %w", err)
+		}
+	}
+
+	log.Debug().
+		Int64("chat_id", chat.Id).
+		Int64("conversation_id", conversation.ID).
+		Int("facts", len(facts)).
+		Msg("Summarized conversation")
+	return nil
+}
+
+// parseFacts parses the JSON array of the summary, models like to wrap it in a code block.
+func parseFacts(text string) ([]string, error) {
+	text = strings.TrimSpace(text)
+	text = strings.TrimPrefix(text, "```json")
+	text = strings.TrimPrefix(text, "```")
+	text = strings.TrimSuffix(text, "```")
+
+	var raw []string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(text)), &raw); err != nil {
+		return nil, err
+	}
+
+	facts := make([]string, 0, len(raw))
+	for _, fact := range raw {
+		fact = strings.TrimSpace(fact)
+		if fact == "" {
+			continue
+		}
+		facts = append(facts, utils.TruncateText(fact, MaxMemoryLength))
+	}
+	return facts, nil
+}
+
+func (p *Plugin) onMemory(b *gotgbot.Bot, c plugin.GobotContext) error {
+	subcommand := strings.ToLower(c.Matches[1])
+	value := strings.TrimSpace(c.Matches[2])
+
+	if subcommand == "" {
+		return p.showMemories(b, c)
+	}
+
+	isAdmin, err := tgUtils.IsChatAdmin(b, c.EffectiveChat, c.EffectiveUser)
+	if err != nil {
+		guid := xid.New().String()
+		log.Err(err).
+			Str("guid", guid).
+			Int64("chat_id", c.EffectiveChat.Id).
+			Msg("Failed to check admin status")
+		_, err := c.EffectiveMessage.ReplyMessage(b, fmt.Sprintf("❌ Es ist ein Fehler aufgetreten.%s", utils.EmbedGUID(guid)),
+			utils.DefaultSendOptions())
+		return err
+	}
+	if !isAdmin {
+		_, err := c.EffectiveMessage.ReplyMessage(b, "❌ Nur Admins können das Gedächtnis bearbeiten.", utils.DefaultSendOptions())
+		return err
+	}
+
+	var action, target, answer string
+	switch subcommand {
+	case "neu":
+		if !validMemory(value) {
+			return p.replyMemoryUsage(b, c)
+		}
+		var id int64
+		id, err = p.llmService.AddMemory(c.EffectiveChat, value, MaxMemories)
+		action, target, answer = model.AuditMemoryAdd, fmt.Sprintf("#%d", id), fmt.Sprintf("✅ Als #%d gespeichert.", id)
+	case "bearbeiten":
+		idText, content, _ := strings.Cut(value, " ")
+		id, parseErr := strconv.ParseInt(strings.TrimPrefix(idText, "#"), 10, 64)
+		content = strings.TrimSpace(content)
+		if parseErr != nil || !validMemory(content) {
+			return p.replyMemoryUsage(b, c)
+		}
+		err = p.llmService.UpdateMemory(c.EffectiveChat, id, content)
+		action, target, answer = model.AuditMemoryEdit, fmt.Sprintf("#%d", id), fmt.Sprintf("✅ #%d geändert.", id)
+	case "löschen":
+		id, parseErr := strconv.ParseInt(strings.TrimPrefix(value, "#"), 10, 64)
+		if parseErr != nil {
+			return p.replyMemoryUsage(b, c)
+		}
+		err = p.llmService.DeleteMemory(c.EffectiveChat, id)
+		action, target, answer = model.AuditMemoryDelete, fmt.Sprintf("#%d", id), fmt.Sprintf("✅ #%d gelöscht.", id)
+	case "leeren":
+		err = p.llmService.DeleteMemories(c.EffectiveChat)
+		action, target, answer = model.AuditMemoryClear, "all", "✅ Gedächtnis geleert."
+	default:
+		return p.replyMemoryUsage(b, c)
+	}
+
+	if errors.Is(err, model.ErrNotFound) {
+		_, err := c.EffectiveMessage.ReplyMessage(b, "❌ Diesen Eintrag gibt es nicht.", utils.DefaultSendOptions())
+		return err
+	}
+	if errors.Is(err, model.ErrLimitReached) {
+		_, err := c.EffectiveMessage.ReplyMessage(b,
+			fmt.Sprintf("❌ Das Gedächtnis ist voll (%d Einträge), bitte erst alte Einträge löschen.", MaxMemories),
+			utils.DefaultSendOptions())
+		return err
+	}
+	if err != nil {
+		guid := xid.New().String()
+		log.Err(err).
+			Str("guid", guid).
+			Int64("chat_id", c.EffectiveChat.Id).
+			Str("subcommand", subcommand).
+			Msg("Failed to change memory")
+		_, err := c.EffectiveMessage.ReplyMessage(b, fmt.Sprintf("❌ Es ist ein Fehler aufgetreten.%s", utils.EmbedGUID(guid)),
+			utils.DefaultSendOptions())
+		return err
+	}
+	p.auditService.Record(c.EffectiveUser, c.EffectiveChat, action, target)
+
+	_, err = c.EffectiveMessage.ReplyMessage(b, answer, utils.DefaultSendOptions())
+	return err
+}
+
+func validMemory(content string) bool {
+	return content != "" && utf8.RuneCountInString(content) <= MaxMemoryLength
+}
+
+func (p *Plugin) replyMemoryUsage(b *gotgbot.Bot, c plugin.GobotContext) error {
+	_, err := c.EffectiveMessage.ReplyMessage(b,
+		"<b>Verwendung:</b>\n"+
+			"<code>/memory</code> - Gedächtnis anzeigen\n"+
+			fmt.Sprintf("<code>/memory neu &lt;Text&gt;</code> - Eintrag hinzufügen (max. %d Zeichen)\n", MaxMemoryLength)+
+			"<code>/memory bearbeiten &lt;ID&gt; &lt;Text&gt;</code> - Eintrag ändern\n"+
+			"<code>/memory löschen &lt;ID&gt;</code> - Eintrag löschen\n"+
+			"<code>/memory leeren</code> - Alle Einträge löschen\n\n"+
+			"<i>Aktivieren mit /settings ai.</i>",
+		utils.DefaultSendOptions())
+	return err
+}
+
+func (p *Plugin) showMemories(b *gotgbot.Bot, c plugin.GobotContext) error {
+	memories, err := p.llmService.GetMemories(c.EffectiveChat)
+	if err != nil {
+		guid := xid.New().String()
+		log.Err(err).
+			Str("guid", guid).
+			Int64("chat_id", c.EffectiveChat.Id).
+			Msg("Failed to get memories")
+		_, err := c.EffectiveMessage.ReplyMessage(b, fmt.Sprintf("❌ Es ist ein Fehler aufgetreten.%s", utils.EmbedGUID(guid)),
+			utils.DefaultSendOptions())
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🧠 <b>Gedächtnis</b> (%d/%d)\n", len(memories), MaxMemories))
+	if !p.memoryEnabled(c.EffectiveChat) {
+		sb.WriteString("<i>Deaktiviert, aktivieren mit /settings ai.</i>\n")
+	}
+	sb.WriteString("\n")
+
+	if len(memories) == 0 {
+		sb.WriteString("<i>Noch keine Einträge.</i>")
+	}
+	for _, memory := range memories {
+		sb.WriteString(fmt.Sprintf("<b>#%d</b> (%s): %s\n", memory.ID,
+			memory.CreatedAt.In(utils.GermanTimezone()).Format("02.01.2006"), utils.Escape(memory.Content)))
+	}
+
+	_, err = tgUtils.ReplySplit(b, c.EffectiveMessage, sb.String(), utils.DefaultSendOptions())
+	return err
+}
@@ -0,0 +1,47 @@
+package ai
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseFacts(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []string
+		wantErr bool
+	}{
+		{"array", `["Mag Katzen", "Wohnt in Berlin"]`, []string{"Mag Katzen", "Wohnt in Berlin"}, false},
+		{"empty", `[]`, []string{}, false},
+		{"code block", "```json\n[\"Mag Katzen\"]\n```", []string{"Mag Katzen"}, false},
+		{"code block without language", "```\n[\"Mag Katzen\"]\n```", []string{"Mag Katzen"}, false},
+		{"skips blank facts", `["  Mag Katzen ", "", "   "]`, []string{"Mag Katzen"}, false},
+		{"no JSON", "Keine neuen Fakten.", nil, true},
+		{"not an array", `{"fact": "Mag Katzen"}`, nil, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseFacts(tc.text)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got error %v", err)
+			}
+			if !tc.wantErr && !slices.Equal(got, tc.want) {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseFactsTruncates(t *testing.T) {
+	facts, err := parseFacts(`["` + strings.Repeat("ä", MaxMemoryLength+10) + `"]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(facts) != 1 || utf8.RuneCountInString(facts[0]) > MaxMemoryLength {
+		t.Errorf("facts must be truncated to %d characters, got %d", MaxMemoryLength, utf8.RuneCountInString(facts[0]))
+	}
+}
@@ -0,0 +1,332 @@
+package ai
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Brawl345/gobot/model"
+	"github.com/Brawl345/gobot/plugin"
+	"github.com/Brawl345/gobot/utils"
+	"github.com/Brawl345/gobot/utils/tgUtils"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/rs/xid"
+)
+
+const (
+	DefaultLanguage      = "Deutsch"
+	MaxPromptLength      = 4000
+	MaxLanguageLength    = 50
+	MaxTemperature       = 2
+	MaxPersonaVersions   = 10
+	personaDefaultOption = "standard"
+)
+
+type PersonaService interface {
+	GetPersona(chat *gotgbot.Chat) (model.Persona, error)
+	GetPersonaVersion(chat *gotgbot.Chat, version int) (model.Persona, error)
+	GetPersonaVersions(chat *gotgbot.Chat, limit int) ([]model.Persona, error)
+	SavePersona(chat *gotgbot.Chat, user *gotgbot.User, persona model.Persona) (int, error)
+}
+
+// toolNames returns the names of all tools the AI can use in the chat.
+func (p *Plugin) toolNames(b *gotgbot.Bot, c plugin.GobotContext) []string {
+	tools := p.tools(b, c)
+	names := make([]string, len(tools))
+	for i, tool := range tools {
+		names[i] = tool.Definition().Name
+	}
+	return names
+}
+
+// allowsTool reports whether the persona lets the AI use the tool.
+func allowsTool(persona model.Persona, name string) bool {
+	if !persona.Tools.Valid {
+		return true
+	}
+	return slices.Contains(strings.Split(persona.Tools.String, ","), name)
+}
+
+func (p *Plugin) onPersona(b *gotgbot.Bot, c plugin.GobotContext) error {
+	subcommand := strings.ToLower(c.Matches[1])
+	value := strings.TrimSpace(c.Matches[2])
+
+	if subcommand == "" {
+		return p.showPersona(b, c)
+	}
+	if subcommand == "versionen" {
+		return p.showPersonaVersions(b, c)
+	}
+
+	isAdmin, err := tgUtils.IsChatAdmin(b, c.EffectiveChat, c.EffectiveUser)
+	if err != nil {
+		guid := xid.New().String()
+		log.Err(err).
+			Str("guid", guid).
+			Int64("chat_id", c.EffectiveChat.Id).
+			Msg("Failed to check admin status")
+		_, err := c.EffectiveMessage.ReplyMessage(b, fmt.Sprintf("❌ Es ist ein Fehler aufgetreten.%s", utils.EmbedGUID(guid)),
+			utils.DefaultSendOptions())
+		return err
+	}
+	if !isAdmin {
+		_, err := c.EffectiveMessage.ReplyMessage(b, "❌ Nur Admins können die Persona ändern.", utils.DefaultSendOptions())
+		return err
+	}
+
+	persona, err := p.personaService.GetPersona(c.EffectiveChat)
+	if err != nil {
+		guid := xid.New().String()
+		log.Err(err).
+			Str("guid", guid).
+			Int64("chat_id", c.EffectiveChat.Id).
+			Msg("Failed to get persona")
+		_, err := c.EffectiveMessage.ReplyMessage(b, fmt.Sprintf("❌ Es ist ein Fehler aufgetreten.%s", utils.EmbedGUID(guid)),
+			utils.DefaultSendOptions())
+		return err
+	}
+
+	action := model.AuditPersonaSet
+	target := subcommand
+	resetToDefault := strings.EqualFold(value, personaDefaultOption)
+
+	switch subcommand {
+	case "prompt":
+		if value == "" {
+			return p.replyUsage(b, c)
+		}
+		if len([]rune(value)) > MaxPromptLength {
+			_, err := c.EffectiveMessage.ReplyMessage(b,
+				fmt.Sprintf("❌ Der Prompt darf höchstens %d Zeichen lang sein.", MaxPromptLength),
+				utils.DefaultSendOptions())
+			return err
+		}
+		persona.SystemPrompt = sql.NullString{String: value, Valid: !resetToDefault}
+	case "temperatur":
+		if resetToDefault {
+			persona.Temperature = sql.NullFloat64{}
+			break
+		}
+		temperature, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+		if err != nil || temperature < 0 || temperature > MaxTemperature {
+			_, err := c.EffectiveMessage.ReplyMessage(b,
+				fmt.Sprintf("❌ Die Temperatur muss eine Zahl zwischen 0 und %d sein.", MaxTemperature),
+				utils.DefaultSendOptions())
+			return err
+		}
+		persona.Temperature = sql.NullFloat64{Float64: temperature, Valid: true}
+	case "sprache":
+		if value == "" {
+			return p.replyUsage(b, c)
+		}
+		if len([]rune(value)) > MaxLanguageLength {
+			_, err := c.EffectiveMessage.ReplyMessage(b,
+				fmt.Sprintf("❌ Die Sprache darf höchstens %d Zeichen lang sein.", MaxLanguageLength),
+				utils.DefaultSendOptions())
+			return err
+		}
+		persona.Language = sql.NullString{String: value, Valid: !resetToDefault}
+	case "tools":
+		available := p.toolNames(b, c)
+		tools, ok := parseTools(value, available)
+		if !ok {
+			_, err := c.EffectiveMessage.ReplyMessage(b,
+				fmt.Sprintf("❌ Erlaubt sind \"alle\", \"keine\" oder eine Liste aus: %s", strings.Join(available, ", ")),
+				utils.DefaultSendOptions())
+			return err
+		}
+		persona.Tools = tools
+	case "reset":
+		persona = model.Persona{}
+		action = model.AuditPersonaReset
+		target = "all"
+	case "rollback":
+		version, err := strconv.Atoi(value)
+		if err != nil {
+			return p.replyUsage(b, c)
+		}
+		persona, err = p.personaService.GetPersonaVersion(c.EffectiveChat, version)
+		if err != nil {
+			if errors.Is(err, model.ErrNotFound) {
+				_, err := c.EffectiveMessage.ReplyMessage(b, "❌ Diese Version existiert nicht.", utils.DefaultSendOptions())
+				return err
+			}
+			guid := xid.New().String()
+			log.Err(err).
+				Str("guid", guid).
+				Int64("chat_id", c.EffectiveChat.Id).
+				Int("version", version).
+				Msg("Failed to get persona version")
+			_, err := c.EffectiveMessage.ReplyMessage(b, fmt.Sprintf("❌ Es ist ein Fehler aufgetreten.%s", utils.EmbedGUID(guid)),
+				utils.DefaultSendOptions())
+			return err
+		}
+		action = model.AuditPersonaRollback
+		target = fmt.Sprintf("v%d", version)
+	default:
+		return p.replyUsage(b, c)
+	}
+
+	version, err := p.personaService.SavePersona(c.EffectiveChat, c.EffectiveUser, persona)
+	if err != nil {
+		guid := xid.New().String()
+		log.Err(err).
+			Str("guid", guid).
+			Int64("chat_id", c.EffectiveChat.Id).
+			Msg("Failed to save persona")
+		_, err := c.EffectiveMessage.ReplyMessage(b, fmt.Sprintf("❌ Es ist ein Fehler aufgetreten.%s", utils.EmbedGUID(guid)),
+			utils.DefaultSendOptions())
+		return err
+	}
+	p.auditService.Record(c.EffectiveUser, c.EffectiveChat, action, target)
+
+	// The old conversations would contradict the new persona
+	if err := p.llmService.DeleteConversations(c.EffectiveChat); err != nil {
+		log.Err(err).
+			Int64("chat_id", c.EffectiveChat.Id).
+			Msg("error resetting conversations")
+	}
+
+	_, err = c.EffectiveMessage.ReplyMessage(b,
+		fmt.Sprintf("✅ Persona gespeichert (Version %d), alle Konversationen wurden zurückgesetzt.", version),
+		utils.DefaultSendOptions())
+	return err
+}
+
+// parseTools parses "alle", "keine" or a list of tool names.
+func parseTools(value string, available []string) (sql.NullString, bool) {
+	switch strings.ToLower(value) {
+	case "alle", personaDefaultOption:
+		return sql.NullString{}, true
+	case "keine":
+		return sql.NullString{String: "", Valid: true}, true
+	}
+
+	var tools []string
+	for _, name := range strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return r == ',' || r == ' '
+	}) {
+		if !slices.Contains(available, name) {
+			return sql.NullString{}, false
+		}
+		if !slices.Contains(tools, name) {
+			tools = append(tools, name)
+		}
+	}
+	if len(tools) == 0 {
+		return sql.NullString{}, false
+	}
+	return sql.NullString{String: strings.Join(tools, ","), Valid: true}, true
+}
+
+func (p *Plugin) replyUsage(b *gotgbot.Bot, c plugin.GobotContext) error {
+	_, err := c.EffectiveMessage.ReplyMessage(b,
+		"<b>Verwendung:</b>\n"+
+			"<code>/persona</code> - Persona anzeigen\n"+
+			"<code>/persona prompt &lt;Text&gt;</code> - System-Prompt setzen\n"+
+			"<code>/persona temperatur &lt;0-2&gt;</code> - Kreativität setzen\n"+
+			"<code>/persona sprache &lt;Sprache&gt;</code> - Antwortsprache setzen\n"+
+			"<code>/persona tools &lt;alle|keine|Liste&gt;</code> - Erlaubte Tools setzen\n"+
+			"<code>/persona reset</code> - Alles zurücksetzen\n"+
+			"<code>/persona versionen</code> - Letzte Versionen anzeigen\n"+
+			"<code>/persona rollback &lt;Version&gt;</code> - Alte Version wiederherstellen\n\n"+
+			"<i>\"standard\" setzt einen einzelnen Wert zurück.</i>",
+		utils.DefaultSendOptions())
+	return err
+}
+
+func (p *Plugin) showPersona(b *gotgbot.Bot, c plugin.GobotContext) error {
+	persona, err := p.personaService.GetPersona(c.EffectiveChat)
+	if err != nil {
+		guid := xid.New().String()
+		log.Err(err).
+			Str("guid", guid).
+			Int64("chat_id", c.EffectiveChat.Id).
+			Msg("Failed to get persona")
+		_, err := c.EffectiveMessage.ReplyMessage(b, fmt.Sprintf("❌ Es ist ein Fehler aufgetreten.%s", utils.EmbedGUID(guid)),
+			utils.DefaultSendOptions())
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🎭 <b>Persona</b>")
+	if persona.Version > 0 {
+		sb.WriteString(fmt.Sprintf(" (Version %d vom %s)", persona.Version,
+			persona.CreatedAt.In(utils.GermanTimezone()).Format("02.01.2006, 15:04")))
+	}
+	sb.WriteString("\n\n")
+
+	sb.WriteString("<b>Prompt:</b> ")
+	if persona.SystemPrompt.Valid {
+		sb.WriteString(fmt.Sprintf("<blockquote expandable>%s</blockquote>", utils.Escape(persona.SystemPrompt.String)))
+	} else {
+		sb.WriteString("<i>Standard</i>\n")
+	}
+
+	sb.WriteString("<b>Temperatur:</b> ")
+	if persona.Temperature.Valid {
+		sb.WriteString(strconv.FormatFloat(persona.Temperature.Float64, 'f', -1, 64))
+	} else {
+		sb.WriteString("<i>Standard</i>")
+	}
+
+	sb.WriteString("\n<b>Sprache:</b> ")
+	if persona.Language.Valid {
+		sb.WriteString(utils.Escape(persona.Language.String))
+	} else {
+		sb.WriteString(fmt.Sprintf("<i>%s</i>", DefaultLanguage))
+	}
+
+	sb.WriteString("\n<b>Tools:</b> ")
+	switch {
+	case !persona.Tools.Valid:
+		sb.WriteString("<i>alle</i>")
+	case persona.Tools.String == "":
+		sb.WriteString("<i>keine</i>")
+	default:
+		sb.WriteString(utils.Escape(strings.ReplaceAll(persona.Tools.String, ",", ", ")))
+	}
+
+	_, err = c.EffectiveMessage.ReplyMessage(b, sb.String(), utils.DefaultSendOptions())
+	return err
+}
+
+func (p *Plugin) showPersonaVersions(b *gotgbot.Bot, c plugin.GobotContext) error {
+	personas, err := p.personaService.GetPersonaVersions(c.EffectiveChat, MaxPersonaVersions)
+	if err != nil {
+		guid := xid.New().String()
+		log.Err(err).
+			Str("guid", guid).
+			Int64("chat_id", c.EffectiveChat.Id).
+			Msg("Failed to get persona versions")
+		_, err := c.EffectiveMessage.ReplyMessage(b, fmt.Sprintf("❌ Es ist ein Fehler aufgetreten.%s", utils.EmbedGUID(guid)),
+			utils.DefaultSendOptions())
+		return err
+	}
+
+	if len(personas) == 0 {
+		_, err := c.EffectiveMessage.ReplyMessage(b, "<i>Die Persona wurde noch nie geändert.</i>", utils.DefaultSendOptions())
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🎭 <b>Persona-Versionen</b>\n\n")
+	for _, persona := range personas {
+		sb.WriteString(fmt.Sprintf("<b>%d</b>: %s", persona.Version,
+			persona.CreatedAt.In(utils.GermanTimezone()).Format("02.01.2006, 15:04")))
+		if persona.CreatedByName.Valid {
+			sb.WriteString(fmt.Sprintf(" von %s", utils.Escape(persona.CreatedByName.String)))
+		}
+		if persona.SystemPrompt.Valid {
+			sb.WriteString(fmt.Sprintf("\n<i>%s</i>", utils.Escape(utils.TruncateText(persona.SystemPrompt.String, 80, "…"))))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\nWiederherstellen mit <code>/persona rollback &lt;Version&gt;</code>")
+
+	_, err = c.EffectiveMessage.ReplyMessage(b, sb.String(), utils.DefaultSendOptions())
+	return err
+}
@@ -0,0 +1,32 @@
+package ai
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestParseTools(t *testing.T) {
+	available := []string{"weather", "wikipedia", "reminders"}
+
+	tests := []struct {
+		value  string
+		want   sql.NullString
+		wantOK bool
+	}{
+		{"alle", sql.NullString{}, true},
+		{"Standard", sql.NullString{}, true},
+		{"keine", sql.NullString{String: "", Valid: true}, true},
+		{"weather", sql.NullString{String: "weather", Valid: true}, true},
+		{"Weather, wikipedia", sql.NullString{String: "weather,wikipedia", Valid: true}, true},
+		{"weather weather,reminders", sql.NullString{String: "weather,reminders", Valid: true}, true},
+		{"weather,unknown", sql.NullString{}, false},
+		{",", sql.NullString{}, false},
+	}
+
+	for _, tc := range tests {
+		got, ok := parseTools(tc.value, available)
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("parseTools(%q) = %+v, %v, want %+v, %v", tc.value, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package ai
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Brawl345/gobot/llm"
+	"github.com/Brawl345/gobot/utils"
+	"github.com/Brawl345/gobot/utils/tgUtils"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+const (
+	// StreamEditInterval throttles edits, Telegram allows about 20 messages per minute in groups
+	StreamEditInterval = 3 * time.Second
+	streamPlaceholder  = "💭 …"
+	streamCursor       = " …"
+)
+
+// streamReply shows a streamed answer in a placeholder message that is edited as the text arrives.
+type streamReply struct {
+	b           *gotgbot.Bot
+	placeholder *gotgbot.Message
+	tools       map[string]llm.Tool
+	text        strings.Builder
+	status      string
+	shown       string
+	lastEdit    time.Time
+}
+
+// newStreamReply replies to message with a placeholder.
+func newStreamReply(b *gotgbot.Bot, message *gotgbot.Message, tools []llm.Tool) (*streamReply, error) {
+	placeholder, err := message.Reply(b, streamPlaceholder, &gotgbot.SendMessageOpts{
+		ReplyParameters: &gotgbot.ReplyParameters{
+			AllowSendingWithoutReply: true,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	toolMap := make(map[string]llm.Tool, len(tools))
+	for _, tool := range tools {
+		toolMap[tool.Definition().Name] = tool
+	}
+
+	return &streamReply{
+		b:           b,
+		placeholder: placeholder,
+		tools:       toolMap,
+		status:      streamPlaceholder,
+		shown:       streamPlaceholder,
+		lastEdit:    time.Now(),
+	}, nil
+}
+
+func (s *streamReply) onText(delta string) {
+	s.text.WriteString(delta)
+	s.update(false)
+}
+
+// onToolCalls shows the running tools. Text streamed before the tool calls is discarded
+// because the model answers again after them.
+func (s *streamReply) onToolCalls(calls []llm.ToolCall) {
+	var status strings.Builder
+	status.WriteString("⚒️")
+	for _, call := range calls {
+		if tool, ok := s.tools[call.Name]; ok {
+			status.WriteString(tool.Emoji())
+		}
+	}
+	status.WriteString(" …")
+
+	s.text.Reset()
+	s.status = status.String()
+	s.update(true)
+}
+
+// update edits the placeholder, unless the last edit was less than StreamEditInterval ago and force is false.
+// The text is shown without formatting because incomplete Markdown can't be converted reliably.
+func (s *streamReply) update(force bool) {
+	if !force && time.Since(s.lastEdit) < StreamEditInterval {
+		return
+	}
+
+	content := s.status
+	if s.text.Len() > 0 {
+		content = utils.TruncateText(s.text.String(), tgUtils.MaxMessageLength-len([]rune(streamCursor))) + streamCursor
+	}
+	if content == s.shown {
+		return
+	}
+
+	_, _, err := s.placeholder.EditText(s.b, content, nil)
+	if err != nil {
+		if !tgUtils.IsNotModified(err) {
+			log.Warn().Err(err).Int64("chat_id", s.placeholder.Chat.Id).Msg("Failed to update streamed answer")
+		}
+		// Retrying immediately would likely run into the rate limit
+	}
+	s.shown = content
+	s.lastEdit = time.Now()
+}
+
+// finish replaces the placeholder with the formatted answer and returns the messages it consists of.
+func (s *streamReply) finish(text string, opts *gotgbot.SendMessageOpts) ([]*gotgbot.Message, error) {
+	answer, err := tgUtils.EditSplit(s.b, s.placeholder, text, opts)
+	if tgUtils.IsNotModified(err) {
+		return []*gotgbot.Message{s.placeholder}, nil
+	}
+	return answer, err
+}
+
+// delete removes the placeholder, e.g. before replying with an error.
+func (s *streamReply) delete() {
+	if _, err := s.placeholder.Delete(s.b, nil); err != nil {
+		log.Warn().Err(err).Int64("chat_id", s.placeholder.Chat.Id).Msg("Failed to delete placeholder")
+	}
+}
@@ -1,4 +1,4 @@
-package gpt
+package ai
 
 import (
 	"encoding/json"
@@ -7,6 +7,8 @@ import (
 	"strconv"
 	"strings"
 	"unicode"
+
+	"github.com/Brawl345/gobot/llm"
 )
 
 type CalculatorTool struct{}
@@ -15,51 +17,49 @@ func NewCalculatorTool() *CalculatorTool {
 	return &CalculatorTool{}
 }
 
-func (t *CalculatorTool) Definition() FunctionTool {
-	return FunctionTool{
-		Type:        "function",
+func (t *CalculatorTool) Definition() llm.ToolDefinition {
+	return llm.ToolDefinition{
 		Name:        "calculator",
 		Description: "Führt präzise mathematische Berechnungen durch. Unterstützt +, -, *, /, ^ (Potenz), Klammern und Funktionen: sqrt, abs, floor, ceil, round, log (Basis 10), ln, sin, cos, tan, asin, acos, atan, pi, e.",
-		Parameters: FunctionParameters{
+		Parameters: llm.Parameters{
 			Type: "object",
-			Properties: map[string]Property{
+			Properties: map[string]llm.Property{
 				"expression": {
 					Type:        "string",
 					Description: "Der mathematische Ausdruck, z.B. \"2^10\", \"sqrt(144)\", \"sin(pi/2)\"",
 				},
 			},
-			Required:             []string{"expression"},
-			AdditionalProperties: false,
+			Required: []string{"expression"},
 		},
 		Strict: true,
 	}
 }
 
-func (t *CalculatorTool) Execute(arguments string) (any, error) {
+func (t *CalculatorTool) Execute(arguments string) (llm.ToolOutput, error) {
 	var args struct {
 		Expression string `json:"expression"`
 	}
 	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
-		return "", fmt.Errorf("invalid arguments: %w", err)
+		return llm.ToolOutput{}, fmt.Errorf("invalid arguments: %w", err)
 	}
 	log.Debug().Str("expression", args.Expression).Msg("calculator tool call")
 
 	result, err := evalExpression(args.Expression)
 	if err != nil {
-		return fmt.Sprintf("Error: %v", err), nil
+		return llm.ToolOutput{Text: fmt.Sprintf("Error: %v", err)}, nil
 	}
 
 	if math.IsInf(result, 0) {
-		return "Error: division by zero or overflow", nil
+		return llm.ToolOutput{Text: "Error: division by zero or overflow"}, nil
 	}
 	if math.IsNaN(result) {
-		return "Error: invalid mathematical operation (NaN)", nil
+		return llm.ToolOutput{Text: "Error: invalid mathematical operation (NaN)"}, nil
 	}
 
 	if result == math.Trunc(result) && math.Abs(result) < 1e15 {
-		return fmt.Sprintf("%g", result), nil
+		return llm.ToolOutput{Text: fmt.Sprintf("%g", result)}, nil
 	}
-	return strconv.FormatFloat(result, 'f', -1, 64), nil
+	return llm.ToolOutput{Text: strconv.FormatFloat(result, 'f', -1, 64)}, nil
 }
 
 func (t *CalculatorTool) Emoji() string {
@@ -0,0 +1,207 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Brawl345/gobot/llm"
+	"github.com/Brawl345/gobot/model"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+type (
+	// MemorySaveTool may only be offered to chat admins, like /memory neu.
+	MemorySaveTool struct {
+		auditService model.AuditService
+		llmService   Service
+		chat         *gotgbot.Chat
+		user         *gotgbot.User // Recorded in the audit log
+	}
+
+	MemorySearchTool struct {
+		llmService Service
+		chat       *gotgbot.Chat
+	}
+
+	// MemoryDeleteTool may only be offered to chat admins, like /memory löschen.
+	MemoryDeleteTool struct {
+		auditService model.AuditService
+		llmService   Service
+		chat         *gotgbot.Chat
+		user         *gotgbot.User // Recorded in the audit log
+	}
+)
+
+func NewMemorySaveTool(auditService model.AuditService, llmService Service, chat *gotgbot.Chat, user *gotgbot.User) *MemorySaveTool {
+	return &MemorySaveTool{auditService: auditService, llmService: llmService, chat: chat, user: user}
+}
+
+func (t *MemorySaveTool) Definition() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Name: "memory_save",
+		Description: fmt.Sprintf("Speichert einen Fakt dauerhaft im Gedächtnis dieses Chats, z.B. \"Max ist Vegetarier\". "+
+			"Nur für Dinge, die auch in Wochen noch nützlich sind. Prüfe vorher mit memory_search, ob es den Fakt schon gibt. "+
+			"Höchstens %d Zeichen und %d Einträge.", MaxMemoryLength, MaxMemories),
+		Parameters: llm.Parameters{
+			Type: "object",
+			Properties: map[string]llm.Property{
+				"content": {
+					Type:        "string",
+					Description: "Der Fakt als kurzer, eigenständiger Satz mit Namen statt Pronomen",
+				},
+			},
+			Required: []string{"content"},
+		},
+		Strict: true,
+	}
+}
+
+func (t *MemorySaveTool) Execute(arguments string) (llm.ToolOutput, error) {
+	var args struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return llm.ToolOutput{}, fmt.Errorf("invalid arguments: %w", err)
+	}
+	log.Debug().Str("content", args.Content).Int64("chat_id", t.chat.Id).Msg("memory_save tool call")
+
+	content := strings.TrimSpace(args.Content)
+	if content == "" {
+		return llm.ToolOutput{Text: "Error: content is empty"}, nil
+	}
+	if utf8.RuneCountInString(content) > MaxMemoryLength {
+		return llm.ToolOutput{Text: fmt.Sprintf("Error: content is longer than %d characters", MaxMemoryLength)}, nil
+	}
+
+	id, err := t.llmService.AddMemory(t.chat, content, MaxMemories)
+	if errors.Is(err, model.ErrLimitReached) {
+		return llm.ToolOutput{Text: fmt.Sprintf("Error: memory is full (%d entries), delete outdated entries first", MaxMemories)}, nil
+	}
+	if err != nil {
+		return llm.ToolOutput{}, fmt.Errorf("failed to save memory: %w", err)
+	}
+	t.auditService.Record(t.user, t.chat, model.AuditMemoryAdd, fmt.Sprintf("#%d (AI)", id))
+	return llm.ToolOutput{Text: fmt.Sprintf("Saved as #%d", id)}, nil
+}
+
+func (t *MemorySaveTool) Emoji() string {
+	return "🧠"
+}
+
+func NewMemorySearchTool(llmService Service, chat *gotgbot.Chat) *MemorySearchTool {
+	return &MemorySearchTool{llmService: llmService, chat: chat}
+}
+
+func (t *MemorySearchTool) Definition() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Name: "memory_search",
+		Description: "Durchsucht das Gedächtnis dieses Chats nach gespeicherten Fakten über Nutzer und den Chat. " +
+			"Nutze es, wenn Vorlieben, Eigenschaften oder frühere Absprachen für die Antwort wichtig sein könnten.",
+		Parameters: llm.Parameters{
+			Type: "object",
+			Properties: map[string]llm.Property{
+				"query": {
+					Type:        "string",
+					Description: "Suchbegriffe, z.B. ein Name oder Thema. Leer für alle Einträge.",
+				},
+			},
+			Required: []string{"query"},
+		},
+		Strict: true,
+	}
+}
+
+func (t *MemorySearchTool) Execute(arguments string) (llm.ToolOutput, error) {
+	var args struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return llm.ToolOutput{}, fmt.Errorf("invalid arguments: %w", err)
+	}
+	log.Debug().Str("query", args.Query).Int64("chat_id", t.chat.Id).Msg("memory_search tool call")
+
+	memories, err := t.llmService.GetMemories(t.chat)
+	if err != nil {
+		return llm.ToolOutput{}, fmt.Errorf("failed to get memories: %w", err)
+	}
+
+	words := strings.Fields(strings.ToLower(args.Query))
+	var sb strings.Builder
+	for _, memory := range memories {
+		if !matchesAny(strings.ToLower(memory.Content), words) {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("#%d (%s): %s\n", memory.ID, memory.CreatedAt.Format("2006-01-02"), memory.Content))
+	}
+
+	if sb.Len() == 0 {
+		return llm.ToolOutput{Text: "No memories found"}, nil
+	}
+	return llm.ToolOutput{Text: sb.String()}, nil
+}
+
+func (t *MemorySearchTool) Emoji() string {
+	return "🧠"
+}
+
+func NewMemoryDeleteTool(auditService model.AuditService, llmService Service, chat *gotgbot.Chat, user *gotgbot.User) *MemoryDeleteTool {
+	return &MemoryDeleteTool{auditService: auditService, llmService: llmService, chat: chat, user: user}
+}
+
+func (t *MemoryDeleteTool) Definition() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Name:        "memory_delete",
+		Description: "Löscht einen veralteten oder falschen Eintrag aus dem Gedächtnis dieses Chats, die ID steht in den Ergebnissen von memory_search.",
+		Parameters: llm.Parameters{
+			Type: "object",
+			Properties: map[string]llm.Property{
+				"id": {
+					Type:        "integer",
+					Description: "Die ID des Eintrags, ohne #",
+				},
+			},
+			Required: []string{"id"},
+		},
+		Strict: true,
+	}
+}
+
+func (t *MemoryDeleteTool) Execute(arguments string) (llm.ToolOutput, error) {
+	var args struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return llm.ToolOutput{}, fmt.Errorf("invalid arguments: %w", err)
+	}
+	log.Debug().Int64("id", args.ID).Int64("chat_id", t.chat.Id).Msg("memory_delete tool call")
+
+	err := t.llmService.DeleteMemory(t.chat, args.ID)
+	if errors.Is(err, model.ErrNotFound) {
+		return llm.ToolOutput{Text: fmt.Sprintf("Error: memory #%d does not exist", args.ID)}, nil
+	}
+	if err != nil {
+		return llm.ToolOutput{}, fmt.Errorf("failed to delete memory: %w", err)
+	}
+	t.auditService.Record(t.user, t.chat, model.AuditMemoryDelete, fmt.Sprintf("#%d (AI)", args.ID))
+	return llm.ToolOutput{Text: fmt.Sprintf("Deleted #%d", args.ID)}, nil
+}
+
+func (t *MemoryDeleteTool) Emoji() string {
+	return "🗑️"
+}
+
+// matchesAny reports whether text contains one of the words, no words match everything.
+func matchesAny(text string, words []string) bool {
+	if len(words) == 0 {
+		return true
+	}
+	for _, word := range words {
+		if strings.Contains(text, word) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,8 +1,7 @@
-package gpt
+package ai
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,6 +11,7 @@ import (
 	"unicode/utf8"
 
 	"codeberg.org/readeck/go-readability/v2"
+	"github.com/Brawl345/gobot/llm"
 	"github.com/Brawl345/gobot/utils"
 	"github.com/Brawl345/gobot/utils/httpUtils"
 )
@@ -23,8 +23,8 @@ const (
 	FetchTimeout            = 30 * time.Second
 )
 
-// supportedImageTypes maps image content types accepted by the OpenAI vision
-// API to the MIME type used in the data URL.
+// supportedImageTypes maps image content types accepted by the vision
+// APIs to the MIME type that is sent to them.
 var supportedImageTypes = map[string]string{
 	"image/png":  "image/png",
 	"image/jpeg": "image/jpeg",
@@ -40,14 +40,13 @@ func NewWebfetchTool(chatID int64) *WebfetchTool {
 	return &WebfetchTool{chatID: chatID}
 }
 
-func (t *WebfetchTool) Definition() FunctionTool {
-	return FunctionTool{
-		Type:        "function",
+func (t *WebfetchTool) Definition() llm.ToolDefinition {
+	return llm.ToolDefinition{
 		Name:        "webfetch",
 		Description: "Ruft den Inhalt einer URL ab. Nutze dieses Tool wenn du eine Website lesen musst, um eine Frage zu beantworten. Zeigt auf die URL auf ein Bild (PNG, JPEG, WebP, GIF), wird das Bild abgerufen und kann direkt analysiert werden.",
-		Parameters: FunctionParameters{
+		Parameters: llm.Parameters{
 			Type: "object",
-			Properties: map[string]Property{
+			Properties: map[string]llm.Property{
 				"url": {
 					Type:        "string",
 					Description: "Die vollständige HTTP/HTTPS-URL",
@@ -58,20 +57,19 @@ func (t *WebfetchTool) Definition() FunctionTool {
 					Enum:        []string{"text", "html"},
 				},
 			},
-			Required:             []string{"url"},
-			AdditionalProperties: false,
+			Required: []string{"url"},
 		},
 		Strict: false,
 	}
 }
 
-func (t *WebfetchTool) Execute(arguments string) (any, error) {
+func (t *WebfetchTool) Execute(arguments string) (llm.ToolOutput, error) {
 	var args struct {
 		URL    string `json:"url"`
 		Format string `json:"format"`
 	}
 	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
-		return "", fmt.Errorf("invalid arguments: %w", err)
+		return llm.ToolOutput{}, fmt.Errorf("invalid arguments: %w", err)
 	}
 	log.Debug().
 		Str("url", args.URL).
@@ -85,13 +83,13 @@ func (t *WebfetchTool) Emoji() string {
 	return "🌐"
 }
 
-func fetchURLContent(rawURL, format string) (any, error) {
+func fetchURLContent(rawURL, format string) (llm.ToolOutput, error) {
 	if !strings.HasPrefix(rawURL, "http://") && !strings.HasPrefix(rawURL, "https://") {
-		return "", fmt.Errorf("invalid URL scheme")
+		return llm.ToolOutput{}, fmt.Errorf("invalid URL scheme")
 	}
 
 	if err := httpUtils.IsPrivateURL(rawURL); err != nil {
-		return "", fmt.Errorf("URL not allowed: %w", err)
+		return llm.ToolOutput{}, fmt.Errorf("URL not allowed: %w", err)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), FetchTimeout)
@@ -99,7 +97,7 @@ func fetchURLContent(rawURL, format string) (any, error) {
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
 	if err != nil {
-		return "", fmt.Errorf("invalid URL: %w", err)
+		return llm.ToolOutput{}, fmt.Errorf("invalid URL: %w", err)
 	}
 	req.Header.Set("User-Agent", utils.UserAgent)
 	if format == "html" {
@@ -110,14 +108,14 @@ func fetchURLContent(rawURL, format string) (any, error) {
 
 	resp, err := httpUtils.SSRFSafeClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("fetch failed: %w", err)
+		return llm.ToolOutput{}, fmt.Errorf("fetch failed: %w", err)
 	}
 	defer func(body io.ReadCloser) {
 		_ = body.Close()
 	}(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+		return llm.ToolOutput{}, fmt.Errorf("HTTP %d", resp.StatusCode)
 	}
 
 	contentType := strings.ToLower(resp.Header.Get("Content-Type"))
@@ -126,16 +124,12 @@ func fetchURLContent(rawURL, format string) (any, error) {
 	if mimeType, ok := supportedImageTypes[mediaType]; ok {
 		imageBytes, err := io.ReadAll(io.LimitReader(resp.Body, MaxFetchedImageBytes+1))
 		if err != nil {
-			return nil, fmt.Errorf("read failed: %w", err)
+			return llm.ToolOutput{}, fmt.Errorf("read failed: %w", err)
 		}
 		if len(imageBytes) > MaxFetchedImageBytes {
-			return nil, fmt.Errorf("image too large (max %d bytes)", MaxFetchedImageBytes)
+			return llm.ToolOutput{}, fmt.Errorf("image too large (max %d bytes)", MaxFetchedImageBytes)
 		}
-		encoded := base64.StdEncoding.EncodeToString(imageBytes)
-		return []InputImage{{
-			Type:     TypeInputImage,
-			ImageURL: fmt.Sprintf("data:%s;base64,%s", mimeType, encoded),
-		}}, nil
+		return llm.ToolOutput{Images: []llm.Image{{MimeType: mimeType, Data: imageBytes}}}, nil
 	}
 
 	isHTML := strings.Contains(contentType, "text/html")
@@ -143,24 +137,24 @@ func fetchURLContent(rawURL, format string) (any, error) {
 	if isHTML && format != "html" {
 		article, err := readability.FromReader(io.LimitReader(resp.Body, MaxFetchedBodyBytes), req.URL)
 		if err != nil {
-			return "", fmt.Errorf("readability failed: %w", err)
+			return llm.ToolOutput{}, fmt.Errorf("readability failed: %w", err)
 		}
 		var sb strings.Builder
 		if err := article.RenderText(&sb); err != nil {
-			return "", fmt.Errorf("text rendering failed: %w", err)
+			return llm.ToolOutput{}, fmt.Errorf("text rendering failed: %w", err)
 		}
-		return wrapUntrusted(truncateFetched(sb.String()), rawURL), nil
+		return llm.ToolOutput{Text: wrapUntrusted(truncateFetched(sb.String()), rawURL)}, nil
 	}
 
 	if isHTML || strings.Contains(contentType, "text/") {
 		bodyBytes, err := io.ReadAll(io.LimitReader(resp.Body, MaxFetchedContentLength+1))
 		if err != nil {
-			return "", fmt.Errorf("read failed: %w", err)
+			return llm.ToolOutput{}, fmt.Errorf("read failed: %w", err)
 		}
-		return wrapUntrusted(truncateFetched(string(bodyBytes)), rawURL), nil
+		return llm.ToolOutput{Text: wrapUntrusted(truncateFetched(string(bodyBytes)), rawURL)}, nil
 	}
 
-	return "", fmt.Errorf("unsupported content type: %s", contentType)
+	return llm.ToolOutput{}, fmt.Errorf("unsupported content type: %s", contentType)
 }
 
 func truncateFetched(content string) string {
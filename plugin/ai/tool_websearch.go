@@ -1,4 +1,4 @@
-package gpt
+package ai
 
 import (
 	"encoding/json"
@@ -11,18 +11,16 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Brawl345/gobot/llm"
 	"github.com/Brawl345/gobot/utils"
 	"github.com/Brawl345/gobot/utils/httpUtils"
 )
 
 const (
-	BraveSearchURL = "https://api.search.brave.com/res/v1/web/search"
-	MaxSourceLinks = 10
-	// HTML length budget for the links section so the escaped GPT output
-	// always keeps a positive share of tgUtils.MaxMessageLength
-	MaxSourceLinksLength = 2000
-	BraveDefaultCount    = 5
-	BraveMaxCount        = 20
+	BraveSearchURL    = "https://api.search.brave.com/res/v1/web/search"
+	MaxSourceLinks    = 10
+	BraveDefaultCount = 5
+	BraveMaxCount     = 20
 )
 
 type (
@@ -40,11 +38,6 @@ type (
 		} `json:"web"`
 	}
 
-	SearchResultsTool interface {
-		Tool
-		SearchResults() []BraveWebResult // this kinda sucks but we need the links at the end and the parse mode
-	}
-
 	WebsearchTool struct {
 		apiKey  string
 		chatID  int64
@@ -57,14 +50,13 @@ func NewWebsearchTool(apiKey string, chatID int64) *WebsearchTool {
 	return &WebsearchTool{apiKey: apiKey, chatID: chatID}
 }
 
-func (t *WebsearchTool) Definition() FunctionTool {
-	return FunctionTool{
-		Type:        "function",
+func (t *WebsearchTool) Definition() llm.ToolDefinition {
+	return llm.ToolDefinition{
 		Name:        "websearch",
 		Description: "Sucht im Web. Gibt Titel, URLs, Snippets und Alter der Ergebnisse zurück. Nutze dieses Tool, wenn du aktuelle Informationen benötigst oder eine Frage beantworten musst, die wahrscheinlich durch eine Websuche beantwortet werden kann. Nutze webfetch um den vollständigen Inhalt einer Ergebnis-URL abzurufen.",
-		Parameters: FunctionParameters{
+		Parameters: llm.Parameters{
 			Type: "object",
-			Properties: map[string]Property{
+			Properties: map[string]llm.Property{
 				"query": {
 					Type:        "string",
 					Description: "Suchanfrage",
@@ -83,14 +75,13 @@ func (t *WebsearchTool) Definition() FunctionTool {
 					Enum:        []string{"pd", "pw", "pm", "py"},
 				},
 			},
-			Required:             []string{"query"},
-			AdditionalProperties: false,
+			Required: []string{"query"},
 		},
 		Strict: false,
 	}
 }
 
-func (t *WebsearchTool) Execute(arguments string) (any, error) {
+func (t *WebsearchTool) Execute(arguments string) (llm.ToolOutput, error) {
 	var args struct {
 		Query     string `json:"query"`
 		Count     int    `json:"count"`
@@ -98,7 +89,7 @@ func (t *WebsearchTool) Execute(arguments string) (any, error) {
 		Freshness string `json:"freshness"`
 	}
 	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
-		return "", fmt.Errorf("invalid arguments: %w", err)
+		return llm.ToolOutput{}, fmt.Errorf("invalid arguments: %w", err)
 	}
 	log.Debug().
 		Str("query", args.Query).
@@ -106,22 +97,26 @@ func (t *WebsearchTool) Execute(arguments string) (any, error) {
 		Msg("websearch tool call")
 	results, output, err := braveSearch(args.Query, t.apiKey, args.Count, args.Country, args.Freshness)
 	if err != nil {
-		return "", err
+		return llm.ToolOutput{}, err
 	}
 	t.mu.Lock()
 	t.results = append(t.results, results...)
 	t.mu.Unlock()
-	return output, nil
+	return llm.ToolOutput{Text: output}, nil
 }
 
 func (t *WebsearchTool) Emoji() string {
 	return "🔎"
 }
 
-func (t *WebsearchTool) SearchResults() []BraveWebResult {
+func (t *WebsearchTool) Sources() []llm.Source {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	return append([]BraveWebResult(nil), t.results...)
+	sources := make([]llm.Source, len(t.results))
+	for i, result := range t.results {
+		sources[i] = llm.Source{Title: result.Title, URL: result.URL}
+	}
+	return sources
 }
 
 const (
@@ -218,7 +213,7 @@ func braveSearch(query, braveKey string, count int, country, freshness string) (
 	return results, strings.TrimRight(sb.String(), "\n"), nil
 }
 
-func searchLinks(results []BraveWebResult) string {
+func sourceLinks(results []llm.Source) string {
 	if len(results) == 0 {
 		return ""
 	}
@@ -245,10 +240,6 @@ func searchLinks(results []BraveWebResult) string {
 		if written > 0 {
 			sep = ", "
 		}
-		if sb.Len()+len(sep)+len(link) > MaxSourceLinksLength {
-			truncated = true
-			break
-		}
 		sb.WriteString(sep)
 		sb.WriteString(link)
 		written++
@@ -0,0 +1,79 @@
+package ai
+
+import (
+	"github.com/Brawl345/gobot/llm"
+	"github.com/Brawl345/gobot/plugin"
+	"github.com/Brawl345/gobot/utils/tgUtils"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+// tools returns the built-in tools, those of the plugins that are enabled for the chat (see plugin.ToolProvider)
+// and the allowed tools of the MCP servers.
+func (p *Plugin) tools(b *gotgbot.Bot, c plugin.GobotContext) []llm.Tool {
+	tools := []llm.Tool{NewWebfetchTool(c.EffectiveChat.Id), NewCalculatorTool()}
+	if braveKey := p.credentialService.GetKey("brave_search_api_key"); braveKey != "" {
+		tools = append(tools, NewWebsearchTool(braveKey, c.EffectiveChat.Id))
+	}
+	if p.memoryEnabled(c.EffectiveChat) {
+		tools = append(tools, NewMemorySearchTool(p.llmService, c.EffectiveChat))
+		// Only admins can change the memory, also through the AI
+		isAdmin, err := tgUtils.IsChatAdmin(b, c.EffectiveChat, c.EffectiveUser)
+		if err != nil {
+			log.Err(err).
+				Int64("chat_id", c.EffectiveChat.Id).
+				Msg("Failed to check admin status, memory is read-only")
+		}
+		if isAdmin {
+			tools = append(tools,
+				NewMemorySaveTool(p.auditService, p.llmService, c.EffectiveChat, c.EffectiveUser),
+				NewMemoryDeleteTool(p.auditService, p.llmService, c.EffectiveChat, c.EffectiveUser),
+			)
+		}
+	}
+
+	names := make(map[string]struct{}, len(tools))
+	for _, tool := range tools {
+		names[tool.Definition().Name] = struct{}{}
+	}
+
+	for _, plg := range p.managerService.Plugins() {
+		provider, ok := plg.(plugin.ToolProvider)
+		if !ok || !p.isPluginAvailable(c, plg) {
+			continue
+		}
+		for _, tool := range provider.Tools(b, c) {
+			name := tool.Definition().Name
+			if _, exists := names[name]; exists {
+				log.Warn().Str("plugin", plg.Name()).Str("tool", name).Msg("Tool name is already taken, skipping")
+				continue
+			}
+			names[name] = struct{}{}
+			tools = append(tools, tool)
+		}
+	}
+
+	for _, tool := range p.mcpManager.tools(p.credentialService.GetKey("mcp_servers"), c.EffectiveChat.Id) {
+		name := tool.Definition().Name
+		if _, exists := names[name]; exists {
+			log.Warn().Str("tool", name).Msg("Tool name of MCP server is already taken, skipping")
+			continue
+		}
+		names[name] = struct{}{}
+		tools = append(tools, tool)
+	}
+
+	return tools
+}
+
+// isPluginAvailable mirrors the checks of the processor, so the AI can't use plugins the user couldn't.
+func (p *Plugin) isPluginAvailable(c plugin.GobotContext, plg plugin.Plugin) bool {
+	name := plg.Name()
+	if !p.managerService.IsPluginEnabled(name) || p.managerService.IsPluginDisabledForChat(c.EffectiveChat, name) {
+		return false
+	}
+	if c.ThreadID != 0 && p.managerService.IsPluginDisabledForTopic(c.EffectiveChat, c.ThreadID, name) {
+		return false
+	}
+	missing, _ := p.managerService.MissingCredentials(plg)
+	return len(missing) == 0
+}
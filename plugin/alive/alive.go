@@ -25,6 +25,13 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	return nil
 }
 
+func (p *Plugin) Help() plugin.Help {
+	return plugin.Help{
+		Description:     "Prüft, ob der Bot noch lebt.",
+		PassiveTriggers: []string{"\"Bot?\""},
+	}
+}
+
 func (p *Plugin) Handlers(*gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
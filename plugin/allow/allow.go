@@ -3,6 +3,7 @@ package allow
 import (
 	"fmt"
 	"regexp"
+	"strconv"
 
 	"github.com/Brawl345/gobot/logger"
 	"github.com/Brawl345/gobot/model"
@@ -18,12 +19,14 @@ var log = logger.New("allow")
 type (
 	Plugin struct {
 		allowService model.AllowService
+		auditService model.AuditService
 	}
 )
 
-func New(service model.AllowService) *Plugin {
+func New(service model.AllowService, auditService model.AuditService) *Plugin {
 	return &Plugin{
 		allowService: service,
+		auditService: auditService,
 	}
 }
 
@@ -49,7 +52,122 @@ func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 			AdminOnly:   true,
 			GroupOnly:   true,
 		},
+		&plugin.CommandHandler{
+			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/allow_chat(?:@%s)? (-?\d+)$`, botInfo.Username)),
+			HandlerFunc: p.onAllowChatById,
+			AdminOnly:   true,
+		},
+		&plugin.CommandHandler{
+			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/deny_chat(?:@%s)? (-?\d+)$`, botInfo.Username)),
+			HandlerFunc: p.onDenyChatById,
+			AdminOnly:   true,
+		},
+	}
+}
+
+// chatById resolves a chat by its ID, so chats like channels can be managed from elsewhere
+func chatById(b *gotgbot.Bot, c plugin.GobotContext) (*gotgbot.Chat, error) {
+	chatId, err := strconv.ParseInt(c.Matches[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	chat, err := b.GetChat(chatId, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gotgbot.Chat{
+		Id:    chat.Id,
+		Type:  chat.Type,
+		Title: chat.Title,
+	}, nil
+}
+
+func (p *Plugin) onAllowChatById(b *gotgbot.Bot, c plugin.GobotContext) error {
+	chat, err := chatById(b, c)
+	if err != nil {
+		log.Err(err).
+			Str("chat_id", c.Matches[1]).
+			Msg("Failed to get chat")
+		_, err = c.EffectiveMessage.ReplyMessage(b, "❌ Chat nicht gefunden. Ist der Bot Mitglied?", utils.DefaultSendOptions())
+		return err
+	}
+
+	if chat.Type == gotgbot.ChatTypePrivate {
+		_, err = c.EffectiveMessage.ReplyMessage(b, "❌ Nutzer werden per Antwort mit /allow erlaubt.", utils.DefaultSendOptions())
+		return err
+	}
+
+	if p.allowService.IsChatAllowed(chat) {
+		return tgUtils.AddReactionWithFallback(b, c.EffectiveMessage, "👍",
+			&tgUtils.ReactionFallbackOpts{
+				Fallback: fmt.Sprintf("✅ <b>%s</b> darf den Bot bereits nutzen.", utils.Escape(chat.Title)),
+			},
+		)
+	}
+
+	err = p.allowService.AllowChat(chat)
+	if err != nil {
+		guid := xid.New().String()
+		log.Err(err).
+			Str("guid", guid).
+			Int64("chat_id", chat.Id).
+			Msg("Failed to allow chat")
+		_, err = c.EffectiveMessage.ReplyMessage(b, fmt.Sprintf("❌ Fehler beim Erlauben des Chats.%s", utils.EmbedGUID(guid)), utils.DefaultSendOptions())
+		return err
+	}
+
+	p.auditService.Record(c.EffectiveUser, c.EffectiveChat, model.AuditChatAllow, fmt.Sprintf("%s (%d)", chat.Title, chat.Id))
+
+	return tgUtils.AddReactionWithFallback(b, c.EffectiveMessage, "👍",
+		&tgUtils.ReactionFallbackOpts{
+			Fallback: fmt.Sprintf("✅ <b>%s</b> darf den Bot jetzt nutzen.", utils.Escape(chat.Title)),
+		},
+	)
+}
+
+func (p *Plugin) onDenyChatById(b *gotgbot.Bot, c plugin.GobotContext) error {
+	chat, err := chatById(b, c)
+	if err != nil {
+		log.Err(err).
+			Str("chat_id", c.Matches[1]).
+			Msg("Failed to get chat")
+		_, err = c.EffectiveMessage.ReplyMessage(b, "❌ Chat nicht gefunden. Ist der Bot Mitglied?", utils.DefaultSendOptions())
+		return err
+	}
+
+	if chat.Type == gotgbot.ChatTypePrivate {
+		_, err = c.EffectiveMessage.ReplyMessage(b, "❌ Nutzer werden per Antwort mit /deny verweigert.", utils.DefaultSendOptions())
+		return err
+	}
+
+	if !p.allowService.IsChatAllowed(chat) {
+		return tgUtils.AddReactionWithFallback(b, c.EffectiveMessage, "👍",
+			&tgUtils.ReactionFallbackOpts{
+				Fallback: fmt.Sprintf("✅ <b>%s</b> darf den Bot nicht nutzen.", utils.Escape(chat.Title)),
+			},
+		)
 	}
+
+	err = p.allowService.DenyChat(chat)
+	if err != nil {
+		guid := xid.New().String()
+		log.Err(err).
+			Str("guid", guid).
+			Int64("chat_id", chat.Id).
+			Msg("Failed to deny chat")
+		_, err = c.EffectiveMessage.ReplyMessage(b, fmt.Sprintf("❌ Fehler beim Verweigern des Chats.%s", utils.EmbedGUID(guid)), utils.DefaultSendOptions())
+		return err
+	}
+
+	p.auditService.Record(c.EffectiveUser, c.EffectiveChat, model.AuditChatDeny, fmt.Sprintf("%s (%d)", chat.Title, chat.Id))
+
+	return tgUtils.AddReactionWithFallback(b, c.EffectiveMessage, "👍",
+		&tgUtils.ReactionFallbackOpts{
+			Fallback: fmt.Sprintf("✅ <b>%s</b> darf den Bot jetzt nicht mehr nutzen.", utils.Escape(chat.Title)),
+		},
+	)
 }
 
 func (p *Plugin) OnAllow(b *gotgbot.Bot, c plugin.GobotContext) error {
@@ -82,6 +200,8 @@ func (p *Plugin) OnAllow(b *gotgbot.Bot, c plugin.GobotContext) error {
 			return err
 		}
 
+		p.auditService.Record(c.EffectiveUser, c.EffectiveChat, model.AuditUserAllow, fmt.Sprintf("%s (%d)", c.EffectiveMessage.ReplyToMessage.From.FirstName, c.EffectiveMessage.ReplyToMessage.From.Id))
+
 		return tgUtils.AddReactionWithFallback(b, c.EffectiveMessage, "👍",
 			&tgUtils.ReactionFallbackOpts{
 				Fallback: fmt.Sprintf("✅ <b>%s</b> darf den Bot jetzt überall benutzen.",
@@ -111,6 +231,8 @@ func (p *Plugin) OnAllow(b *gotgbot.Bot, c plugin.GobotContext) error {
 			return err
 		}
 
+		p.auditService.Record(c.EffectiveUser, c.EffectiveChat, model.AuditChatAllow, fmt.Sprintf("%s (%d)", c.EffectiveChat.Title, c.EffectiveChat.Id))
+
 		return tgUtils.AddReactionWithFallback(b, c.EffectiveMessage, "👍",
 			&tgUtils.ReactionFallbackOpts{
 				Fallback: "✅ Dieser Chat darf den Bot jetzt nutzen.",
@@ -148,6 +270,8 @@ func (p *Plugin) OnDeny(b *gotgbot.Bot, c plugin.GobotContext) error {
 			return err
 		}
 
+		p.auditService.Record(c.EffectiveUser, c.EffectiveChat, model.AuditUserDeny, fmt.Sprintf("%s (%d)", c.EffectiveMessage.ReplyToMessage.From.FirstName, c.EffectiveMessage.ReplyToMessage.From.Id))
+
 		return tgUtils.AddReactionWithFallback(b, c.EffectiveMessage, "👍",
 			&tgUtils.ReactionFallbackOpts{
 				Fallback: fmt.Sprintf("✅ <b>%s</b> darf den Bot jetzt nicht mehr überall benutzen.",
@@ -176,6 +300,8 @@ func (p *Plugin) OnDeny(b *gotgbot.Bot, c plugin.GobotContext) error {
 			return err
 		}
 
+		p.auditService.Record(c.EffectiveUser, c.EffectiveChat, model.AuditChatDeny, fmt.Sprintf("%s (%d)", c.EffectiveChat.Title, c.EffectiveChat.Id))
+
 		return tgUtils.AddReactionWithFallback(b, c.EffectiveMessage, "👍",
 			&tgUtils.ReactionFallbackOpts{
 				Fallback: "✅ Dieser Chat darf den Bot jetzt nicht mehr nutzen..",
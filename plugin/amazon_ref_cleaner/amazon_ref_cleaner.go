@@ -30,11 +30,20 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	return nil
 }
 
+func (p *Plugin) Help() plugin.Help {
+	return plugin.Help{
+		Description:     "Entfernt Referral- und Tracking-Parameter aus Amazon-Links.",
+		PassiveTriggers: []string{"Amazon-Links"},
+	}
+}
+
 func (p *Plugin) Handlers(*gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
-			Trigger:     regexp.MustCompile(`(?i)(?:amzn\.to/[A-Za-z\d]+|amazon\.[\w.]+/\S+)`),
-			HandlerFunc: onAmazonLink,
+			Trigger:            regexp.MustCompile(`(?i)(?:amzn\.to/[A-Za-z\d]+|amazon\.[\w.]+/\S+)`),
+			HandlerFunc:        onAmazonLink,
+			HandleEdits:        true,
+			HandleChannelPosts: true,
 		},
 	}
 }
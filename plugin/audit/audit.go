@@ -0,0 +1,217 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Brawl345/gobot/logger"
+	"github.com/Brawl345/gobot/model"
+	"github.com/Brawl345/gobot/plugin"
+	"github.com/Brawl345/gobot/utils"
+	"github.com/Brawl345/gobot/utils/tgUtils"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/rs/xid"
+)
+
+var log = logger.New("audit")
+
+const (
+	maxEntries      = 25
+	exportButtonTTL = 24 * time.Hour
+)
+
+type (
+	Plugin struct {
+		auditService    model.AuditService
+		callbackService model.CallbackService
+	}
+
+	// exportPayload is stored for the CSV export button
+	exportPayload struct {
+		Filter string `json:"filter"`
+	}
+)
+
+func New(auditService model.AuditService, callbackService model.CallbackService) *Plugin {
+	return &Plugin{
+		auditService:    auditService,
+		callbackService: callbackService,
+	}
+}
+
+func (*Plugin) Name() string {
+	return "audit"
+}
+
+func (p *Plugin) Commands() []gotgbot.BotCommand {
+	return nil // Because it's a superuser command
+}
+
+func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
+	return []plugin.Handler{
+		&plugin.CommandHandler{
+			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/audit(?:@%s)?(?: (.+))?$`, botInfo.Username)),
+			HandlerFunc: p.onAudit,
+			AdminOnly:   true,
+		},
+		&plugin.CallbackHandler{
+			Trigger:     regexp.MustCompile(`^audit:(\w+)$`),
+			HandlerFunc: p.onExport,
+			AdminOnly:   true,
+		},
+	}
+}
+
+func (p *Plugin) onAudit(b *gotgbot.Bot, c plugin.GobotContext) error {
+	filter := strings.TrimSpace(c.Matches[1])
+
+	entries, err := p.auditService.Entries(filter, maxEntries)
+	if err != nil {
+		guid := xid.New().String()
+		log.Err(err).
+			Str("guid", guid).
+			Str("filter", filter).
+			Msg("Failed to get audit log")
+		_, err := c.EffectiveMessage.ReplyMessage(b, fmt.Sprintf("❌ Es ist ein Fehler aufgetreten.%s", utils.EmbedGUID(guid)),
+			utils.DefaultSendOptions())
+		return err
+	}
+
+	if len(entries) == 0 {
+		_, err := c.EffectiveMessage.ReplyMessage(b, "<i>Keine Einträge gefunden.</i>", utils.DefaultSendOptions())
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📜 <b>Audit-Log</b>")
+	if filter != "" {
+		sb.WriteString(fmt.Sprintf(" (Filter: <code>%s</code>)", utils.Escape(filter)))
+	}
+	sb.WriteString("\n\n")
+
+	for _, entry := range entries {
+		sb.WriteString(
+			fmt.Sprintf(
+				"<code>%s</code> <b>%s</b> (<code>%d</code>): %s <code>%s</code>",
+				entry.CreatedAt.In(utils.GermanTimezone()).Format("02.01.2006 15:04"),
+				utils.Escape(entry.ActorName),
+				entry.ActorID,
+				utils.Escape(entry.Action),
+				utils.Escape(entry.Target),
+			),
+		)
+		if entry.ChatID.Valid && entry.ChatID.Int64 != entry.ActorID {
+			sb.WriteString(fmt.Sprintf(" in <code>%d</code>", entry.ChatID.Int64))
+		}
+		sb.WriteString("\n")
+	}
+
+	sendOptions := utils.DefaultSendOptions()
+	button, err := tgUtils.TokenButton(p.callbackService, "📄 Als CSV exportieren", "audit", exportPayload{Filter: filter}, c.EffectiveUser, exportButtonTTL)
+	if err != nil {
+		log.Err(err).Msg("Failed to create export button")
+	} else {
+		sendOptions.ReplyMarkup = tgUtils.InlineKeyboard(1, button)
+	}
+
+	_, err = tgUtils.ReplySplit(b, c.EffectiveMessage, sb.String(), sendOptions)
+	return err
+}
+
+func (p *Plugin) onExport(b *gotgbot.Bot, c plugin.GobotContext) error {
+	var payload exportPayload
+	err := p.callbackService.Get(tgUtils.CallbackToken(c.CallbackQuery.Data), c.EffectiveUser, &payload)
+	if err != nil {
+		var text string
+		switch {
+		case errors.Is(err, model.ErrNotFound):
+			text = "❌ Dieser Button ist abgelaufen."
+		case errors.Is(err, model.ErrNotOwner):
+			text = "❌ Nur wer das Log angefordert hat, kann es exportieren."
+		default:
+			log.Err(err).
+				Str("data", c.CallbackQuery.Data).
+				Msg("Failed to get export payload")
+			text = "❌ Es ist ein Fehler aufgetreten."
+		}
+		_, err := c.CallbackQuery.Answer(b, &gotgbot.AnswerCallbackQueryOpts{
+			Text:      text,
+			ShowAlert: true,
+		})
+		return err
+	}
+
+	if c.EffectiveMessage == nil {
+		_, err := c.CallbackQuery.Answer(b, nil)
+		return err
+	}
+
+	entries, err := p.auditService.Entries(payload.Filter, 0)
+	if err != nil {
+		log.Err(err).
+			Str("filter", payload.Filter).
+			Msg("Failed to get audit log")
+		_, err := c.CallbackQuery.Answer(b, &gotgbot.AnswerCallbackQueryOpts{
+			Text:      "❌ Es ist ein Fehler aufgetreten.",
+			ShowAlert: true,
+		})
+		return err
+	}
+
+	_, err = c.CallbackQuery.Answer(b, nil)
+	if err != nil {
+		return err
+	}
+
+	document, err := toCSV(entries)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.EffectiveMessage.ReplyDocument(b, gotgbot.InputFileByReader("audit.csv", bytes.NewReader(document)), &gotgbot.SendDocumentOpts{
+		Caption: fmt.Sprintf("📜 %d Einträge", len(entries)),
+		ReplyParameters: &gotgbot.ReplyParameters{
+			AllowSendingWithoutReply: true,
+		},
+		DisableNotification: true,
+	})
+	return err
+}
+
+func toCSV(entries []model.AuditEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	err := w.Write([]string{"id", "created_at", "actor_id", "actor_name", "chat_id", "action", "target"})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		var chatID string
+		if entry.ChatID.Valid {
+			chatID = strconv.FormatInt(entry.ChatID.Int64, 10)
+		}
+		err := w.Write([]string{
+			strconv.FormatInt(entry.ID, 10),
+			entry.CreatedAt.UTC().Format(time.RFC3339),
+			strconv.FormatInt(entry.ActorID, 10),
+			entry.ActorName,
+			chatID,
+			entry.Action,
+			entry.Target,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -1,6 +1,7 @@
 package birthdays
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
@@ -18,28 +19,38 @@ import (
 
 var log = logger.New("birthdays")
 
+var settingNotifications = plugin.Setting{
+	Key:         "notifications",
+	Name:        "Benachrichtigungen",
+	Description: "Gratuliert Mitgliedern zum Geburtstag, in Themen dort, wo /bdays_enable gesendet wurde",
+	Type:        plugin.SettingBool,
+	Default:     "false",
+}
+
 type (
 	Plugin struct {
 		birthdayService Service
+		settingsService model.SettingsService
 	}
 
 	Service interface {
-		BirthdayNotificationsEnabled(chat *gotgbot.Chat) (bool, error)
 		DeleteBirthday(user *gotgbot.User) error
 		Birthdays(chat *gotgbot.Chat) ([]model.User, error)
-		DisableBirthdayNotifications(chat *gotgbot.Chat) error
-		EnableBirthdayNotifications(chat *gotgbot.Chat) error
 		SetBirthday(user *gotgbot.User, birthday time.Time) error
-		TodaysBirthdays() (map[int64][]model.User, error)
+		SetBirthdayThread(chat *gotgbot.Chat, threadID int64) error
+		TodaysBirthdays() (map[model.Topic][]model.User, error)
 	}
 )
 
-func New(bot *gotgbot.Bot, birthdayService Service) *Plugin {
-	p := &Plugin{
+func New(birthdayService Service, settingsService model.SettingsService) *Plugin {
+	return &Plugin{
 		birthdayService: birthdayService,
+		settingsService: settingsService,
 	}
-	p.scheduleNewRun(bot)
-	return p
+}
+
+func (p *Plugin) StartJobs(ctx context.Context, bot *gotgbot.Bot) {
+	p.scheduleNewRun(ctx, bot)
 }
 
 func (p *Plugin) Name() string {
@@ -63,6 +74,10 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	}
 }
 
+func (p *Plugin) Settings() []plugin.Setting {
+	return []plugin.Setting{settingNotifications}
+}
+
 func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
@@ -93,21 +108,24 @@ func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 	}
 }
 
-func (p *Plugin) scheduleNewRun(bot *gotgbot.Bot) {
+func (p *Plugin) scheduleNewRun(ctx context.Context, bot *gotgbot.Bot) {
 	now := time.Now()
 	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
 	midnight = midnight.AddDate(0, 0, 1)
 	untilMidnight := time.Until(midnight)
-	time.AfterFunc(untilMidnight, func() {
-		p.onNewDay(bot)
+	timer := time.AfterFunc(untilMidnight, func() {
+		p.onNewDay(ctx, bot)
+	})
+	context.AfterFunc(ctx, func() {
+		timer.Stop()
 	})
 	log.Debug().
 		Msgf("Scheduled new run at %s", time.Now().Add(untilMidnight).Format("2006-01-02 15:04:05"))
 }
 
-func (p *Plugin) onNewDay(bot *gotgbot.Bot) {
+func (p *Plugin) onNewDay(ctx context.Context, bot *gotgbot.Bot) {
 	log.Debug().Msg("Checking for birthdays")
-	defer p.scheduleNewRun(bot)
+	defer p.scheduleNewRun(ctx, bot)
 	defer func() {
 		if r := recover(); r != nil {
 			log.Error().Interface("panic", r).Msg("Recovered from panic in onNewDay")
@@ -120,12 +138,17 @@ func (p *Plugin) onNewDay(bot *gotgbot.Bot) {
 		return
 	}
 
-	for chatID, list := range birthdayList {
+	for topic, list := range birthdayList {
+		if !p.settingsService.GetBool(model.SettingsScope{ChatID: topic.ChatID}, p.Name(), settingNotifications) {
+			continue
+		}
+		sendOptions := utils.DefaultSendOptions()
+		sendOptions.MessageThreadId = topic.ThreadID
 		for _, user := range list {
 			age := time.Now().Year() - user.Birthday.Time.Year()
 			text := fmt.Sprintf("🎂🍰🎈<b>%s hat heute Geburtstag und wird %d!</b>🎉🎁🕯\nAlles Gute!",
 				utils.Escape(user.FirstName), age)
-			_, err := bot.SendMessage(chatID, text, utils.DefaultSendOptions())
+			_, err := bot.SendMessage(topic.ChatID, text, sendOptions)
 			if err != nil {
 				log.Err(err).Msg("Failed to send birthday message")
 			}
@@ -196,11 +219,21 @@ func (p *Plugin) onDeleteBirthday(b *gotgbot.Bot, c plugin.GobotContext) error {
 }
 
 func (p *Plugin) onEnableBirthdayNotifications(b *gotgbot.Bot, c plugin.GobotContext) error {
-	err := p.birthdayService.EnableBirthdayNotifications(c.EffectiveChat)
+	scope := model.ScopeOf(settingNotifications, c.EffectiveChat, c.EffectiveUser)
+	enabled := p.settingsService.GetBool(scope, p.Name(), settingNotifications)
+
+	// Enabling them in another topic moves them there
+	err := p.birthdayService.SetBirthdayThread(c.EffectiveChat, c.ThreadID)
 	if errors.Is(err, model.ErrAlreadyExists) {
-		_, err := c.EffectiveMessage.ReplyMessage(b, "💡 Geburtsagsbenachrichtigungen sind in dieser Gruppe schon aktiv.",
-			utils.DefaultSendOptions())
-		return err
+		if enabled {
+			_, err := c.EffectiveMessage.ReplyMessage(b, "💡 Geburtsagsbenachrichtigungen sind in dieser Gruppe schon aktiv.",
+				utils.DefaultSendOptions())
+			return err
+		}
+		err = nil
+	}
+	if err == nil && !enabled {
+		err = p.settingsService.Set(scope, p.Name(), settingNotifications, "true")
 	}
 	if err != nil {
 		guid := xid.New().String()
@@ -220,12 +253,14 @@ func (p *Plugin) onEnableBirthdayNotifications(b *gotgbot.Bot, c plugin.GobotCon
 }
 
 func (p *Plugin) onDisableBirthdayNotifications(b *gotgbot.Bot, c plugin.GobotContext) error {
-	err := p.birthdayService.DisableBirthdayNotifications(c.EffectiveChat)
-	if errors.Is(err, model.ErrAlreadyExists) {
+	scope := model.ScopeOf(settingNotifications, c.EffectiveChat, c.EffectiveUser)
+	if !p.settingsService.GetBool(scope, p.Name(), settingNotifications) {
 		_, err := c.EffectiveMessage.ReplyMessage(b, "💡 Geburtsagsbenachrichtigungen sind in dieser Gruppe nicht aktiv.",
 			utils.DefaultSendOptions())
 		return err
 	}
+
+	err := p.settingsService.Set(scope, p.Name(), settingNotifications, "false")
 	if err != nil {
 		guid := xid.New().String()
 		log.Err(err).
@@ -244,16 +279,7 @@ func (p *Plugin) onDisableBirthdayNotifications(b *gotgbot.Bot, c plugin.GobotCo
 }
 
 func (p *Plugin) listBirthdays(b *gotgbot.Bot, c plugin.GobotContext) error {
-	enabled, err := p.birthdayService.BirthdayNotificationsEnabled(c.EffectiveChat)
-	if err != nil {
-		guid := xid.New().String()
-		log.Err(err).
-			Int64("chat_id", c.EffectiveChat.Id).
-			Str("guid", guid).
-			Msg("Failed to get birthday notification state")
-		_, err := c.EffectiveMessage.ReplyMessage(b, fmt.Sprintf("❌ Es ist ein Fehler aufgetreten.%s", utils.EmbedGUID(guid)), utils.DefaultSendOptions())
-		return err
-	}
+	enabled := p.settingsService.GetBool(model.ScopeOf(settingNotifications, c.EffectiveChat, c.EffectiveUser), p.Name(), settingNotifications)
 	if !enabled {
 		_, err := c.EffectiveMessage.ReplyMessage(b,
 			"💡 Geburtsagsbenachrichtigungen sind in dieser Gruppe nicht aktiv, daher werden keine Geburtstage gelistet.",
@@ -54,11 +54,20 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	}
 }
 
+func (p *Plugin) Help() plugin.Help {
+	return plugin.Help{
+		Description:    "Rechnet mathematische Ausdrücke aus.",
+		Examples:       []string{"/calc (2+3)*4", "/calc sqrt(144)"},
+		InlineTriggers: []string{"calc 1+1"},
+	}
+}
+
 func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/calc(?:@%s)? (.+)$`, botInfo.Username)),
 			HandlerFunc: onCalc,
+			HandleEdits: true,
 		},
 		&plugin.InlineHandler{
 			Trigger:     regexp.MustCompile(`(?i)^calc (.+)$`),
@@ -52,6 +52,12 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	}
 }
 
+func (p *Plugin) Credentials() plugin.Credentials {
+	return plugin.Credentials{
+		Required: []string{"cleverbot_api_key"},
+	}
+}
+
 func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
@@ -18,11 +18,13 @@ import (
 var log = logger.New("creds")
 
 type Plugin struct {
+	auditService      model.AuditService
 	credentialService model.CredentialService
 }
 
-func New(credentialService model.CredentialService) *Plugin {
+func New(credentialService model.CredentialService, auditService model.AuditService) *Plugin {
 	return &Plugin{
+		auditService:      auditService,
 		credentialService: credentialService,
 	}
 }
@@ -126,6 +128,9 @@ func (p *Plugin) OnAdd(b *gotgbot.Bot, c plugin.GobotContext) error {
 		return err
 	}
 
+	// Only the name, the value is secret
+	p.auditService.Record(c.EffectiveUser, c.EffectiveChat, model.AuditCredentialSet, key)
+
 	_, err = c.EffectiveMessage.ReplyMessage(b, "✅ Schlüssel gespeichert.", utils.DefaultSendOptions())
 	return err
 }
@@ -152,6 +157,8 @@ func (p *Plugin) OnDelete(b *gotgbot.Bot, c plugin.GobotContext) error {
 		return err
 	}
 
+	p.auditService.Record(c.EffectiveUser, c.EffectiveChat, model.AuditCredentialDelete, key)
+
 	_, err = c.EffectiveMessage.ReplyMessage(b, "✅ Schlüssel gelöscht.", utils.DefaultSendOptions())
 	return err
 }
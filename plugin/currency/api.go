@@ -5,6 +5,7 @@ import "errors"
 type Response struct {
 	Amount float64            `json:"amount"`
 	Base   string             `json:"base"`
+	Date   string             `json:"date"`
 	Rates  map[string]float64 `json:"rates"`
 }
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
@@ -43,15 +44,25 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	}
 }
 
+func (p *Plugin) Help() plugin.Help {
+	return plugin.Help{
+		Description:    "Rechnet Währungen um. Ohne Zielwährung wird in Euro umgerechnet.",
+		Examples:       []string{"/cash 10 USD", "/cash 10 USD in JPY"},
+		InlineTriggers: []string{"cash 10 USD EUR"},
+	}
+}
+
 func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/cash(?:@%s)? ([\d,]+) ([A-Za-z]{3}) (?:in )?([A-Za-z]{3})$`, botInfo.Username)),
 			HandlerFunc: onConvertFromTo,
+			HandleEdits: true,
 		},
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/cash(?:@%s)? ([\d,]+) ([A-Za-z]{3})$`, botInfo.Username)),
 			HandlerFunc: onConvertToEUR,
+			HandleEdits: true,
 		},
 		&plugin.InlineHandler{
 			Trigger:     regexp.MustCompile(`(?i)^cash ([\d,]+) ([A-Za-z]{3}) (?:in )?([A-Za-z]{3})$`),
@@ -64,32 +75,41 @@ func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 	}
 }
 
-func convertCurrency(amount, from, to string) (string, error) {
+// fetchConversion converts the amount, which may use a decimal comma, with the ECB reference rates.
+func fetchConversion(amount, from, to string) (Response, error) {
 	amount = strings.ReplaceAll(amount, ",", ".")
 	_, err := strconv.ParseFloat(amount, 64)
 	if err != nil {
-		return "", ErrBadAmount
+		return Response{}, ErrBadAmount
 	}
 
-	from = strings.ToUpper(from)
-	to = strings.ToUpper(to)
-	if from == to {
-		return "", ErrSameCurrency
+	if strings.EqualFold(from, to) {
+		return Response{}, ErrSameCurrency
 	}
 
 	var response Response
 	err = httpUtils.MakeRequest(httpUtils.RequestOptions{
 		Method:   httpUtils.MethodGet,
-		URL:      fmt.Sprintf(ApiUrl, amount, from, to),
+		URL:      fmt.Sprintf(ApiUrl, amount, url.QueryEscape(strings.ToUpper(from)), url.QueryEscape(strings.ToUpper(to))),
 		Response: &response,
 	})
 	if err != nil {
 		if httpError, ok := errors.AsType[*httpUtils.HttpError](err); ok && httpError.StatusCode == http.StatusNotFound {
-			return "", ErrBadCurrency
+			return Response{}, ErrBadCurrency
 		}
+		return Response{}, err
+	}
+	return response, nil
+}
+
+func convertCurrency(amount, from, to string) (string, error) {
+	response, err := fetchConversion(amount, from, to)
+	if err != nil {
 		return "", err
 	}
 
+	from = strings.ToUpper(from)
+	to = strings.ToUpper(to)
 	amountStr := utils.FormatFloat(response.Amount)
 	amountStr = strings.ReplaceAll(amountStr, ",00", "")
 	toStr := utils.FormatFloat(response.Rates[to])
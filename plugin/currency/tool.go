@@ -0,0 +1,74 @@
+package currency
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Brawl345/gobot/llm"
+	"github.com/Brawl345/gobot/plugin"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+type ConvertTool struct{}
+
+func (p *Plugin) Tools(*gotgbot.Bot, plugin.GobotContext) []llm.Tool {
+	return []llm.Tool{&ConvertTool{}}
+}
+
+func (t *ConvertTool) Definition() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Name:        "currency_convert",
+		Description: "Rechnet einen Geldbetrag mit den aktuellen Referenzkursen der EZB in eine andere Währung um.",
+		Parameters: llm.Parameters{
+			Type: "object",
+			Properties: map[string]llm.Property{
+				"amount": {
+					Type:        "number",
+					Description: "Der Betrag, z.B. 10.5",
+				},
+				"from": {
+					Type:        "string",
+					Description: "ISO-4217-Code der Ausgangswährung, z.B. \"USD\"",
+				},
+				"to": {
+					Type:        "string",
+					Description: "ISO-4217-Code der Zielwährung, z.B. \"EUR\"",
+				},
+			},
+			Required: []string{"amount", "from", "to"},
+		},
+		Strict: true,
+	}
+}
+
+func (t *ConvertTool) Execute(arguments string) (llm.ToolOutput, error) {
+	var args struct {
+		Amount float64 `json:"amount"`
+		From   string  `json:"from"`
+		To     string  `json:"to"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return llm.ToolOutput{}, fmt.Errorf("invalid arguments: %w", err)
+	}
+	log.Debug().
+		Float64("amount", args.Amount).
+		Str("from", args.From).
+		Str("to", args.To).
+		Msg("currency_convert tool call")
+
+	response, err := fetchConversion(strconv.FormatFloat(args.Amount, 'f', -1, 64), args.From, args.To)
+	if err != nil {
+		return llm.ToolOutput{}, err
+	}
+
+	to := strings.ToUpper(args.To)
+	return llm.ToolOutput{
+		Text: fmt.Sprintf("%g %s = %g %s (Kurs vom %s)", response.Amount, strings.ToUpper(args.From), response.Rates[to], to, response.Date),
+	}, nil
+}
+
+func (t *ConvertTool) Emoji() string {
+	return "💶"
+}
@@ -28,6 +28,13 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	return nil
 }
 
+func (p *Plugin) Help() plugin.Help {
+	return plugin.Help{
+		Description:     "Entschlüsselt DLC-Container und listet die enthaltenen Links auf.",
+		PassiveTriggers: []string{"DLC-Dateien"},
+	}
+}
+
 func (p *Plugin) Handlers(*gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
@@ -5,6 +5,7 @@ import (
 	"regexp"
 
 	"github.com/Brawl345/gobot/logger"
+	"github.com/Brawl345/gobot/model"
 	"github.com/Brawl345/gobot/plugin"
 	"github.com/Brawl345/gobot/utils/tgUtils"
 	"github.com/PaulSonOfLars/gotgbot/v2"
@@ -12,10 +13,14 @@ import (
 
 var log = logger.New("delmsg")
 
-type Plugin struct{}
+type Plugin struct {
+	auditService model.AuditService
+}
 
-func New() *Plugin {
-	return &Plugin{}
+func New(auditService model.AuditService) *Plugin {
+	return &Plugin{
+		auditService: auditService,
+	}
 }
 
 func (p *Plugin) Name() string {
@@ -30,14 +35,14 @@ func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/del(?:ete)?(?:@%s)?$`, botInfo.Username)),
-			HandlerFunc: deleteMsg,
+			HandlerFunc: p.deleteMsg,
 			AdminOnly:   true,
 			GroupOnly:   true,
 		},
 	}
 }
 
-func deleteMsg(b *gotgbot.Bot, c plugin.GobotContext) error {
+func (p *Plugin) deleteMsg(b *gotgbot.Bot, c plugin.GobotContext) error {
 	if !tgUtils.IsReply(c.EffectiveMessage) {
 		log.Debug().Msg("Message is not a reply")
 		return nil
@@ -55,7 +60,12 @@ func deleteMsg(b *gotgbot.Bot, c plugin.GobotContext) error {
 
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to delete the messages")
+		return nil
 	}
 
+	// The content is not logged, since it was deleted on purpose
+	p.auditService.Record(c.EffectiveUser, c.EffectiveChat, model.AuditMessageDelete,
+		fmt.Sprintf("%d (from %d)", c.EffectiveMessage.ReplyToMessage.MessageId, c.EffectiveMessage.ReplyToMessage.From.Id))
+
 	return nil
 }
@@ -32,6 +32,7 @@ func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/e(?:cho)?(?:@%s)? (.+)$`, botInfo.Username)),
 			HandlerFunc: onEcho,
+			HandleEdits: true,
 		},
 	}
 }
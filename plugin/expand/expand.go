@@ -44,19 +44,30 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	}
 }
 
+func (p *Plugin) Help() plugin.Help {
+	return plugin.Help{
+		Description:     "Zeigt, wohin ein gekürzter Link führt.",
+		Examples:        []string{"/expand https://bit.ly/abc"},
+		PassiveTriggers: []string{"Links von bit.ly, j.mp und tinyurl.com"},
+	}
+}
+
 func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/expand(?:@%s)? .+$`, botInfo.Username)),
 			HandlerFunc: onExpand,
+			HandleEdits: true,
 		},
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/expand(?:@%s)?$`, botInfo.Username)),
 			HandlerFunc: onExpandFromReply,
+			HandleEdits: true,
 		},
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(`(?i)(?:bit\.ly|bitly\.com|j\.mp|tinyurl.com)/.+`),
 			HandlerFunc: onExpand,
+			HandleEdits: true,
 		},
 	}
 }
@@ -1,6 +1,7 @@
 package gelbooru
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -29,6 +30,7 @@ var (
 
 type (
 	Plugin struct {
+		cleanupService    CleanupService
 		credentialService model.CredentialService
 		gelbooruService   model.GelbooruService
 	}
@@ -39,21 +41,21 @@ type (
 )
 
 func New(credentialService model.CredentialService, gelbooruService model.GelbooruService, cleanupService CleanupService) *Plugin {
-	time.AfterFunc(24*time.Hour, func() {
-		cleanup(cleanupService)
-	})
-
 	return &Plugin{
+		cleanupService:    cleanupService,
 		credentialService: credentialService,
 		gelbooruService:   gelbooruService,
 	}
 }
 
+func (p *Plugin) StartJobs(ctx context.Context, _ *gotgbot.Bot) {
+	utils.RunPeriodically(ctx, 24*time.Hour, 24*time.Hour, func() {
+		cleanup(p.cleanupService)
+	})
+}
+
 func cleanup(cleanupService CleanupService) {
 	log.Debug().Msg("starting cleanup")
-	defer time.AfterFunc(24*time.Hour, func() {
-		cleanup(cleanupService)
-	})
 
 	err := cleanupService.Cleanup()
 	if err != nil {
@@ -74,6 +76,20 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	}
 }
 
+func (p *Plugin) Credentials() plugin.Credentials {
+	return plugin.Credentials{
+		Required: []string{"gelbooru_api_key", "gelbooru_user_id"},
+	}
+}
+
+func (p *Plugin) Help() plugin.Help {
+	return plugin.Help{
+		Description:     "Sucht Bilder auf Gelbooru.",
+		Examples:        []string{"/gel cat_ears"},
+		PassiveTriggers: []string{"Links zu Gelbooru-Posts"},
+	}
+}
+
 func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
@@ -45,12 +45,19 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	return nil
 }
 
+func (p *Plugin) Credentials() plugin.Credentials {
+	return plugin.Credentials{
+		Optional: []string{"getfile_dir"},
+	}
+}
+
 func (p *Plugin) Handlers(*gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
-			Trigger:     tgUtils.AnyMedia,
-			HandlerFunc: p.OnMedia,
-			HandleEdits: true,
+			Trigger:            tgUtils.AnyMedia,
+			HandlerFunc:        p.OnMedia,
+			HandleEdits:        true,
+			HandleChannelPosts: true,
 		},
 	}
 }
@@ -1,6 +1,7 @@
 package google_images
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -23,6 +24,7 @@ var log = logger.New("google_images")
 
 type (
 	Plugin struct {
+		cleanupService      CleanupService
 		credentialService   model.CredentialService
 		googleImagesService Service
 	}
@@ -40,21 +42,21 @@ type (
 )
 
 func New(credentialService model.CredentialService, googleImagesService Service, cleanupService CleanupService) *Plugin {
-	time.AfterFunc(24*time.Hour, func() {
-		cleanup(cleanupService)
-	})
-
 	return &Plugin{
+		cleanupService:      cleanupService,
 		credentialService:   credentialService,
 		googleImagesService: googleImagesService,
 	}
 }
 
+func (p *Plugin) StartJobs(ctx context.Context, _ *gotgbot.Bot) {
+	utils.RunPeriodically(ctx, 24*time.Hour, 24*time.Hour, func() {
+		cleanup(p.cleanupService)
+	})
+}
+
 func cleanup(cleanupService CleanupService) {
 	log.Debug().Msg("starting cleanup")
-	defer time.AfterFunc(24*time.Hour, func() {
-		cleanup(cleanupService)
-	})
 
 	err := cleanupService.Cleanup()
 	if err != nil {
@@ -76,6 +78,12 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	}
 }
 
+func (p *Plugin) Credentials() plugin.Credentials {
+	return plugin.Credentials{
+		Required: []string{"google_api_key", "google_search_engine_id"},
+	}
+}
+
 func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
@@ -40,18 +40,27 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	}
 }
 
+func (p *Plugin) Middlewares() []plugin.Middleware {
+	return []plugin.Middleware{plugin.ChatAction(gotgbot.ChatActionTyping)}
+}
+
+func (p *Plugin) Credentials() plugin.Credentials {
+	return plugin.Credentials{
+		Required: []string{"google_api_key", "google_search_engine_id"},
+	}
+}
+
 func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/g(?:@%s)? (.+)$`, botInfo.Username)),
 			HandlerFunc: p.onGoogleSearch,
+			HandleEdits: true,
 		},
 	}
 }
 
 func (p *Plugin) onGoogleSearch(b *gotgbot.Bot, c plugin.GobotContext) error {
-	_, _ = c.EffectiveChat.SendAction(b, gotgbot.ChatActionTyping, nil)
-
 	apiKey := p.credentialService.GetKey("google_api_key")
 	if apiKey == "" {
 		log.Warn().Msg("google_api_key not found")
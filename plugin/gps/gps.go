@@ -47,11 +47,20 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	}
 }
 
+func (p *Plugin) Help() plugin.Help {
+	return plugin.Help{
+		Description:     "Zeigt Orte auf der Karte an und sucht die Adresse zu gesendeten Standorten.",
+		Examples:        []string{"/map Berlin"},
+		PassiveTriggers: []string{"Standorte", "Orte"},
+	}
+}
+
 func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/(?:gps|map)(?:@%s)? (.+)$`, botInfo.Username)),
 			HandlerFunc: p.onGPS,
+			HandleEdits: true,
 		},
 		&plugin.CommandHandler{
 			Trigger:     tgUtils.LocationMsg,
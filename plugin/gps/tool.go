@@ -0,0 +1,64 @@
+package gps
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Brawl345/gobot/llm"
+	"github.com/Brawl345/gobot/model"
+	"github.com/Brawl345/gobot/plugin"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+type GeocodingTool struct {
+	geocodingService model.GeocodingService
+}
+
+func (p *Plugin) Tools(*gotgbot.Bot, plugin.GobotContext) []llm.Tool {
+	return []llm.Tool{&GeocodingTool{geocodingService: p.geocodingService}}
+}
+
+func (t *GeocodingTool) Definition() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Name:        "geocode",
+		Description: "Sucht einen Ort oder eine Adresse und gibt die vollständige Adresse und Koordinaten zurück.",
+		Parameters: llm.Parameters{
+			Type: "object",
+			Properties: map[string]llm.Property{
+				"address": {
+					Type:        "string",
+					Description: "Ort oder Adresse, z.B. \"Brandenburger Tor\"",
+				},
+			},
+			Required: []string{"address"},
+		},
+		Strict: true,
+	}
+}
+
+func (t *GeocodingTool) Execute(arguments string) (llm.ToolOutput, error) {
+	var args struct {
+		Address string `json:"address"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return llm.ToolOutput{}, fmt.Errorf("invalid arguments: %w", err)
+	}
+	log.Debug().Str("address", args.Address).Msg("geocode tool call")
+
+	venue, err := t.geocodingService.Geocode(args.Address)
+	if err != nil {
+		if errors.Is(err, model.ErrAddressNotFound) {
+			return llm.ToolOutput{Text: "Ort nicht gefunden."}, nil
+		}
+		return llm.ToolOutput{}, err
+	}
+
+	return llm.ToolOutput{
+		Text: fmt.Sprintf("%s\nKoordinaten: %f, %f", venue.Address, venue.Location.Latitude, venue.Location.Longitude),
+	}, nil
+}
+
+func (t *GeocodingTool) Emoji() string {
+	return "📍"
+}
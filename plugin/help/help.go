@@ -0,0 +1,236 @@
+package help
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Brawl345/gobot/model"
+	"github.com/Brawl345/gobot/plugin"
+	"github.com/Brawl345/gobot/utils"
+	"github.com/Brawl345/gobot/utils/tgUtils"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+const buttonsPerRow = 3
+
+type Plugin struct {
+	managerService model.ManagerService
+}
+
+func New(managerService model.ManagerService) *Plugin {
+	return &Plugin{
+		managerService: managerService,
+	}
+}
+
+func (*Plugin) Name() string {
+	return "help"
+}
+
+func (p *Plugin) Commands() []gotgbot.BotCommand {
+	return []gotgbot.BotCommand{
+		{
+			Command:     "help",
+			Description: "[Plugin] - Hilfe anzeigen",
+		},
+	}
+}
+
+func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
+	return []plugin.Handler{
+		&plugin.CommandHandler{
+			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/help(?:@%s)?$`, botInfo.Username)),
+			HandlerFunc: p.onHelp,
+		},
+		&plugin.CommandHandler{
+			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/help(?:@%s)? (\S+)$`, botInfo.Username)),
+			HandlerFunc: p.onPluginHelp,
+		},
+		&plugin.CallbackHandler{
+			Trigger:     regexp.MustCompile(`^help(?::(\w+))?$`),
+			HandlerFunc: p.onHelpCallback,
+		},
+	}
+}
+
+// availablePlugins returns the plugins that can be used in the chat or topic and have something to show, sorted by name.
+func (p *Plugin) availablePlugins(c plugin.GobotContext) []plugin.Plugin {
+	chat := c.EffectiveChat
+	inGroup := tgUtils.FromGroup(c.EffectiveMessage)
+
+	var plugins []plugin.Plugin
+	for _, plg := range p.managerService.Plugins() {
+		name := plg.Name()
+		if !p.managerService.IsPluginEnabled(name) {
+			continue
+		}
+		if inGroup && p.managerService.IsPluginDisabledForChat(chat, name) {
+			continue
+		}
+		if c.ThreadID != 0 && p.managerService.IsPluginDisabledForTopic(chat, c.ThreadID, name) {
+			continue
+		}
+		if missing, _ := p.managerService.MissingCredentials(plg); len(missing) > 0 {
+			continue
+		}
+		if _, ok := plg.(plugin.HelpProvider); !ok && len(plg.Commands()) == 0 {
+			continue
+		}
+		plugins = append(plugins, plg)
+	}
+
+	slices.SortFunc(plugins, func(a, b plugin.Plugin) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return plugins
+}
+
+func (p *Plugin) findPlugin(c plugin.GobotContext, name string) plugin.Plugin {
+	for _, plg := range p.availablePlugins(c) {
+		if strings.EqualFold(plg.Name(), name) {
+			return plg
+		}
+	}
+	return nil
+}
+
+func overview(plugins []plugin.Plugin) (string, gotgbot.InlineKeyboardMarkup) {
+	var sb strings.Builder
+	sb.WriteString("<b>Hilfe</b>\n")
+	sb.WriteString("Wähle ein Plugin aus, um mehr über seine Befehle und Funktionen zu erfahren.\n")
+	sb.WriteString("Alternativ: <code>/help Plugin</code>")
+
+	var keyboard [][]gotgbot.InlineKeyboardButton
+	for chunk := range slices.Chunk(plugins, buttonsPerRow) {
+		row := make([]gotgbot.InlineKeyboardButton, 0, len(chunk))
+		for _, plg := range chunk {
+			row = append(row, gotgbot.InlineKeyboardButton{
+				Text:         plg.Name(),
+				CallbackData: fmt.Sprintf("help:%s", plg.Name()),
+			})
+		}
+		keyboard = append(keyboard, row)
+	}
+
+	return sb.String(), gotgbot.InlineKeyboardMarkup{InlineKeyboard: keyboard}
+}
+
+func details(plg plugin.Plugin, botUsername string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<b>%s</b>\n", html.EscapeString(plg.Name())))
+
+	var help plugin.Help
+	if provider, ok := plg.(plugin.HelpProvider); ok {
+		help = provider.Help()
+	}
+
+	if help.Description != "" {
+		sb.WriteString(fmt.Sprintf("%s\n", html.EscapeString(help.Description)))
+	}
+
+	if commands := plg.Commands(); len(commands) > 0 {
+		sb.WriteString("\n<b>Befehle:</b>\n")
+		for _, command := range commands {
+			sb.WriteString(fmt.Sprintf("/%s %s\n", command.Command, html.EscapeString(command.Description)))
+		}
+	}
+
+	if len(help.Examples) > 0 {
+		sb.WriteString("\n<b>Beispiele:</b>\n")
+		for _, example := range help.Examples {
+			sb.WriteString(fmt.Sprintf("<code>%s</code>\n", html.EscapeString(example)))
+		}
+	}
+
+	if len(help.InlineTriggers) > 0 {
+		sb.WriteString("\n<b>Inline:</b>\n")
+		for _, trigger := range help.InlineTriggers {
+			sb.WriteString(fmt.Sprintf("<code>@%s %s</code>\n", botUsername, html.EscapeString(trigger)))
+		}
+	}
+
+	if len(help.PassiveTriggers) > 0 {
+		sb.WriteString("\n<b>Reagiert automatisch auf:</b>\n")
+		for _, trigger := range help.PassiveTriggers {
+			sb.WriteString(fmt.Sprintf("• %s\n", html.EscapeString(trigger)))
+		}
+	}
+
+	return strings.TrimSpace(sb.String())
+}
+
+func backButton() gotgbot.InlineKeyboardMarkup {
+	return gotgbot.InlineKeyboardMarkup{
+		InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
+			{
+				{
+					Text:         "◀ Zurück",
+					CallbackData: "help",
+				},
+			},
+		},
+	}
+}
+
+func (p *Plugin) onHelp(b *gotgbot.Bot, c plugin.GobotContext) error {
+	text, keyboard := overview(p.availablePlugins(c))
+	sendOptions := utils.DefaultSendOptions()
+	sendOptions.ReplyMarkup = keyboard
+	_, err := c.EffectiveMessage.ReplyMessage(b, text, sendOptions)
+	return err
+}
+
+func (p *Plugin) onPluginHelp(b *gotgbot.Bot, c plugin.GobotContext) error {
+	plg := p.findPlugin(c, c.Matches[1])
+	if plg == nil {
+		_, err := c.EffectiveMessage.ReplyMessage(b, "❌ Dieses Plugin existiert nicht oder ist hier nicht verfügbar.", utils.DefaultSendOptions())
+		return err
+	}
+
+	_, err := c.EffectiveMessage.ReplyMessage(b, details(plg, b.Username), utils.DefaultSendOptions())
+	return err
+}
+
+func (p *Plugin) onHelpCallback(b *gotgbot.Bot, c plugin.GobotContext) error {
+	if c.EffectiveMessage == nil {
+		_, err := c.CallbackQuery.Answer(b, nil)
+		return err
+	}
+
+	var (
+		text     string
+		keyboard gotgbot.InlineKeyboardMarkup
+	)
+
+	if name := c.Matches[1]; name != "" {
+		plg := p.findPlugin(c, name)
+		if plg == nil {
+			_, err := c.CallbackQuery.Answer(b, &gotgbot.AnswerCallbackQueryOpts{
+				Text:      "❌ Dieses Plugin ist hier nicht mehr verfügbar.",
+				ShowAlert: true,
+			})
+			return err
+		}
+		text = details(plg, b.Username)
+		keyboard = backButton()
+	} else {
+		text, keyboard = overview(p.availablePlugins(c))
+	}
+
+	_, _, err := c.EffectiveMessage.EditText(b, text, &gotgbot.EditMessageTextOpts{
+		ParseMode: gotgbot.ParseModeHTML,
+		LinkPreviewOptions: &gotgbot.LinkPreviewOptions{
+			IsDisabled: true,
+		},
+		ReplyMarkup: keyboard,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = c.CallbackQuery.Answer(b, nil)
+	return err
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/Brawl345/gobot/model"
 	"github.com/Brawl345/gobot/plugin"
 	"github.com/Brawl345/gobot/utils"
+	"github.com/Brawl345/gobot/utils/tgUtils"
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/rs/xid"
 	"slices"
@@ -18,7 +19,8 @@ var log = logger.New("ids")
 
 type (
 	Plugin struct {
-		idsService Service
+		idsService      Service
+		callbackService model.CallbackService
 	}
 
 	Service interface {
@@ -26,9 +28,10 @@ type (
 	}
 )
 
-func New(idsService Service) *Plugin {
+func New(idsService Service, callbackService model.CallbackService) *Plugin {
 	return &Plugin{
-		idsService: idsService,
+		idsService:      idsService,
+		callbackService: callbackService,
 	}
 }
 
@@ -143,6 +146,6 @@ func (p *Plugin) onIds(b *gotgbot.Bot, c plugin.GobotContext) error {
 
 	sb.WriteString("<i>(Bots sind nicht gelistet)</i>")
 
-	_, err = c.EffectiveMessage.ReplyMessage(b, sb.String(), utils.DefaultSendOptions())
+	_, err = tgUtils.ReplyPaginated(b, c.EffectiveMessage, sb.String(), p.callbackService, nil, utils.DefaultSendOptions())
 	return err
 }
@@ -0,0 +1,49 @@
+package image_generation
+
+type (
+	imagesRequest struct {
+		Model  string `json:"model"`
+		Prompt string `json:"prompt"`
+		N      int    `json:"n"`
+		Size   string `json:"size"`
+	}
+
+	// imagesResponse contains either base64 encoded images or URLs, depending on the server
+	imagesResponse struct {
+		Data []struct {
+			B64JSON       string `json:"b64_json"`
+			URL           string `json:"url"`
+			RevisedPrompt string `json:"revised_prompt"`
+		} `json:"data"`
+	}
+
+	apiErrorResponse struct {
+		Error struct {
+			Message string `json:"message"`
+			Type    string `json:"type"`
+			Code    string `json:"code"`
+		} `json:"error"`
+	}
+
+	moderationRequest struct {
+		Model string            `json:"model"`
+		Input []moderationInput `json:"input"`
+	}
+
+	moderationInput struct {
+		Type     string              `json:"type"`
+		Text     string              `json:"text,omitempty"`
+		ImageURL *moderationImageURL `json:"image_url,omitempty"`
+	}
+
+	moderationImageURL struct {
+		URL string `json:"url"`
+	}
+
+	moderationResponse struct {
+		Results []struct {
+			Flagged    bool            `json:"flagged"`
+			Categories map[string]bool `json:"categories"`
+		} `json:"results"`
+	}
+)
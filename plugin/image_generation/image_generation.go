@@ -0,0 +1,542 @@
+package image_generation
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Brawl345/gobot/llm"
+	"github.com/Brawl345/gobot/logger"
+	"github.com/Brawl345/gobot/model"
+	"github.com/Brawl345/gobot/plugin"
+	"github.com/Brawl345/gobot/utils"
+	"github.com/Brawl345/gobot/utils/httpUtils"
+	"github.com/Brawl345/gobot/utils/tgUtils"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/rs/xid"
+)
+
+var log = logger.New("image_generation")
+
+const (
+	DefaultApiUrl        = "https://api.openai.com/v1"
+	DefaultModel         = "gpt-image-1"
+	DefaultModerationUrl = "https://api.openai.com/v1/moderations"
+	ModerationModel      = "omni-moderation-latest"
+	ImageSize            = "1024x1024"
+	QuotaWindow          = 24 * time.Hour
+	MaxPromptLength      = 1000
+	MaxImageBytes        = 20_000_000
+)
+
+var (
+	ErrNotConfigured = errors.New("image generation is not configured")
+	ErrDisabled      = errors.New("image generation is disabled in the chat")
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	ErrFlagged       = errors.New("flagged by moderation")
+
+	// Generating takes up to a minute
+	httpClient = httpUtils.NewHTTPClientWithTimeout(3 * time.Minute)
+)
+
+var settingQuota = plugin.Setting{
+	Key:         "quota",
+	Name:        "Tageslimit",
+	Description: "Wie viele Bilder jeder Nutzer innerhalb von 24 Stunden erstellen darf, Bilder aus anderen Chats zählen mit. 0 deaktiviert die Bildgenerierung.",
+	Type:        plugin.SettingInt,
+	Default:     "5",
+	Min:         0,
+	Max:         50,
+	LimitsUsers: true,
+}
+
+type (
+	Plugin struct {
+		credentialService model.CredentialService
+		imageService      Service
+		settingsService   model.SettingsService
+	}
+
+	Service interface {
+		Cleanup(window time.Duration) error
+		Release(id int64) error
+		Reserve(chat *gotgbot.Chat, user *gotgbot.User, limit int, window time.Duration) (int64, error)
+	}
+
+	// config of an OpenAI-compatible API, Headers authenticate the requests
+	config struct {
+		URL     string
+		Headers map[string]string
+		Model   string
+	}
+)
+
+func New(credentialService model.CredentialService, imageService Service, settingsService model.SettingsService) *Plugin {
+	return &Plugin{
+		credentialService: credentialService,
+		imageService:      imageService,
+		settingsService:   settingsService,
+	}
+}
+
+func (p *Plugin) Name() string {
+	return "image_generation"
+}
+
+func (p *Plugin) Commands() []gotgbot.BotCommand {
+	return []gotgbot.BotCommand{
+		{
+			Command:     "img",
+			Description: "<Beschreibung> - Bild generieren, als Antwort auf ein Foto: Foto bearbeiten",
+		},
+	}
+}
+
+func (p *Plugin) Credentials() plugin.Credentials {
+	return plugin.Credentials{
+		Optional: []string{
+			"openai_api_key",
+			// OpenAI-compatible server, e.g. a Stable Diffusion server like LocalAI: http://localhost:8080/v1
+			"image_generation_api_url",
+			// Used instead of openai_api_key, which is never sent to other servers
+			"image_generation_api_key",
+			"image_generation_model",
+			"image_generation_auth_header",
+			// OpenAI-compatible moderation endpoint, generation is disabled without any moderation
+			"image_generation_moderation_url",
+			"image_generation_moderation_api_key",
+		},
+	}
+}
+
+func (p *Plugin) Help() plugin.Help {
+	return plugin.Help{
+		Description: "Generiert Bilder aus einer Beschreibung oder bearbeitet ein Foto, auf das geantwortet wird. " +
+			"Auch die KI kann damit Bilder erstellen. Beschreibungen und Bilder werden moderiert, " +
+			"jeder Nutzer hat ein Tageslimit (einstellbar mit /settings image_generation).",
+		Examples: []string{
+			"/img Ein Fuchs, der im Schnee Kaffee trinkt, als Ölgemälde",
+			"/img Mach den Himmel lila",
+		},
+	}
+}
+
+func (p *Plugin) Settings() []plugin.Setting {
+	return []plugin.Setting{settingQuota}
+}
+
+func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
+	return []plugin.Handler{
+		&plugin.CommandHandler{
+			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/img(?:@%s)?(?:\s+([\s\S]+))?$`, botInfo.Username)),
+			HandlerFunc: p.onImage,
+		},
+	}
+}
+
+// generationConfig returns the images API, OpenAI's unless image_generation_api_url is set.
+func (p *Plugin) generationConfig() (config, error) {
+	apiUrl := p.credentialService.GetKey("image_generation_api_url")
+	apiKey := p.credentialService.GetKey("image_generation_api_key")
+	if apiUrl == "" {
+		apiUrl = DefaultApiUrl
+		apiKey = cmp.Or(apiKey, p.credentialService.GetKey("openai_api_key"))
+		if apiKey == "" {
+			return config{}, fmt.Errorf("%w: openai_api_key not found", ErrNotConfigured)
+		}
+	}
+	if !strings.HasPrefix(apiUrl, "http://") && !strings.HasPrefix(apiUrl, "https://") {
+		return config{}, fmt.Errorf("%w: image_generation_api_url is invalid", ErrNotConfigured)
+	}
+
+	return config{
+		URL:     strings.TrimSuffix(apiUrl, "/"),
+		Headers: llm.AuthHeaders(p.credentialService.GetKey("image_generation_auth_header"), apiKey),
+		Model:   cmp.Or(p.credentialService.GetKey("image_generation_model"), DefaultModel),
+	}, nil
+}
+
+// moderationConfig returns the moderation API, OpenAI's unless image_generation_moderation_url is set.
+func (p *Plugin) moderationConfig() (config, error) {
+	apiUrl := p.credentialService.GetKey("image_generation_moderation_url")
+	apiKey := p.credentialService.GetKey("image_generation_moderation_api_key")
+	if apiUrl == "" {
+		apiUrl = DefaultModerationUrl
+		apiKey = cmp.Or(apiKey, p.credentialService.GetKey("openai_api_key"))
+		if apiKey == "" {
+			return config{}, fmt.Errorf("%w: no moderation configured", ErrNotConfigured)
+		}
+	}
+	if !strings.HasPrefix(apiUrl, "http://") && !strings.HasPrefix(apiUrl, "https://") {
+		return config{}, fmt.Errorf("%w: image_generation_moderation_url is invalid", ErrNotConfigured)
+	}
+
+	return config{
+		URL:     apiUrl,
+		Headers: llm.AuthHeaders("", apiKey),
+		Model:   ModerationModel,
+	}, nil
+}
+
+// create generates an image, or edits source if it's not nil. The prompt, source and result are moderated.
+// Every attempt counts against the quota of the user, unless the API fails.
+func (p *Plugin) create(b *gotgbot.Bot, chat *gotgbot.Chat, user *gotgbot.User, prompt string, source *gotgbot.PhotoSize) (image []byte, err error) {
+	generation, err := p.generationConfig()
+	if err != nil {
+		return nil, err
+	}
+	moderation, err := p.moderationConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	limit, _ := strconv.Atoi(p.settingsService.Get(model.ScopeOf(settingQuota, chat, user), p.Name(), settingQuota))
+	if limit == 0 {
+		return nil, ErrDisabled
+	}
+
+	if !tgUtils.IsAdmin(user) {
+		var reservation int64
+		reservation, err = p.imageService.Reserve(chat, user, limit, QuotaWindow)
+		if errors.Is(err, model.ErrLimitReached) {
+			return nil, ErrQuotaExceeded
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to reserve quota: %w", err)
+		}
+		defer func() {
+			if err != nil && !errors.Is(err, ErrFlagged) {
+				if releaseErr := p.imageService.Release(reservation); releaseErr != nil {
+					log.Err(releaseErr).Int64("reservation", reservation).Msg("Failed to release quota")
+				}
+			}
+		}()
+	}
+
+	var sourceData []byte
+	if source != nil {
+		sourceData, err = download(b, source)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = moderate(moderation, prompt, sourceData); err != nil {
+		return nil, err
+	}
+
+	if sourceData != nil {
+		image, err = edit(generation, prompt, sourceData)
+	} else {
+		image, err = generate(generation, prompt)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = moderate(moderation, "", image); err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+func generate(cfg config, prompt string) ([]byte, error) {
+	var response imagesResponse
+	var errorResponse apiErrorResponse
+	err := httpUtils.MakeRequest(httpUtils.RequestOptions{
+		Method:        httpUtils.MethodPost,
+		URL:           cfg.URL + "/images/generations",
+		Headers:       cfg.Headers,
+		Body:          imagesRequest{Model: cfg.Model, Prompt: prompt, N: 1, Size: ImageSize},
+		Response:      &response,
+		ErrorResponse: &errorResponse,
+		Client:        httpClient,
+	})
+	if err != nil {
+		return nil, apiError(err, errorResponse)
+	}
+	return decodeImage(response)
+}
+
+func edit(cfg config, prompt string, source []byte) ([]byte, error) {
+	contentType := http.DetectContentType(source)
+	resp, err := httpUtils.MultiPartFormRequestWithClient(
+		httpClient,
+		cfg.URL+"/images/edits",
+		cfg.Headers,
+		[]httpUtils.MultiPartParam{
+			{Name: "model", Value: cfg.Model},
+			{Name: "prompt", Value: prompt},
+			{Name: "n", Value: "1"},
+			{Name: "size", Value: ImageSize},
+		},
+		[]httpUtils.MultiPartFile{
+			{
+				FieldName:   "image",
+				FileName:    "image." + strings.TrimPrefix(contentType, "image/"),
+				ContentType: contentType,
+				Content:     bytes.NewReader(source),
+			},
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload image: %w", err)
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Err(err).Msg("Failed to close response body")
+		}
+	}(resp.Body)
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, httpUtils.MaxResponseBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errorResponse apiErrorResponse
+		_ = json.Unmarshal(body, &errorResponse)
+		return nil, apiError(&httpUtils.HttpError{StatusCode: resp.StatusCode}, errorResponse)
+	}
+
+	var response imagesResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse body: %w", err)
+	}
+	return decodeImage(response)
+}
+
+// apiError treats the API's own safety system like the moderation.
+func apiError(err error, errorResponse apiErrorResponse) error {
+	if errorResponse.Error.Code == "moderation_blocked" || errorResponse.Error.Code == "content_policy_violation" {
+		return fmt.Errorf("%w: %s", ErrFlagged, errorResponse.Error.Message)
+	}
+	if errorResponse.Error.Message != "" {
+		return fmt.Errorf("%w: %s", err, errorResponse.Error.Message)
+	}
+	return err
+}
+
+func decodeImage(response imagesResponse) ([]byte, error) {
+	if len(response.Data) == 0 {
+		return nil, errors.New("got no image")
+	}
+
+	if response.Data[0].B64JSON != "" {
+		return base64.StdEncoding.DecodeString(response.Data[0].B64JSON)
+	}
+
+	if response.Data[0].URL == "" {
+		return nil, errors.New("got neither image data nor URL")
+	}
+	// The URL comes from the configured server, which can be a local one
+	resp, err := httpClient.Get(response.Data[0].URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download image: %w", err)
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Err(err).Msg("Failed to close response body")
+		}
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &httpUtils.HttpError{StatusCode: resp.StatusCode}
+	}
+
+	image, err := io.ReadAll(io.LimitReader(resp.Body, MaxImageBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if len(image) > MaxImageBytes {
+		return nil, fmt.Errorf("image is larger than %d bytes", MaxImageBytes)
+	}
+	return image, nil
+}
+
+// moderate returns ErrFlagged if the text or image is flagged.
+func moderate(cfg config, text string, image []byte) error {
+	var input []moderationInput
+	if text != "" {
+		input = append(input, moderationInput{Type: "text", Text: text})
+	}
+	if image != nil {
+		input = append(input, moderationInput{
+			Type: "image_url",
+			ImageURL: &moderationImageURL{
+				URL: fmt.Sprintf("data:%s;base64,%s", http.DetectContentType(image), base64.StdEncoding.EncodeToString(image)),
+			},
+		})
+	}
+
+	var response moderationResponse
+	var errorResponse apiErrorResponse
+	err := httpUtils.MakeRequest(httpUtils.RequestOptions{
+		Method:        httpUtils.MethodPost,
+		URL:           cfg.URL,
+		Headers:       cfg.Headers,
+		Body:          moderationRequest{Model: cfg.Model, Input: input},
+		Response:      &response,
+		ErrorResponse: &errorResponse,
+		Client:        httpClient,
+	})
+	if err != nil {
+		return fmt.Errorf("moderation failed: %w", apiError(err, errorResponse))
+	}
+	if len(response.Results) == 0 {
+		return errors.New("moderation returned no result")
+	}
+
+	for _, result := range response.Results {
+		if !result.Flagged {
+			continue
+		}
+		var categories []string
+		for category, flagged := range result.Categories {
+			if flagged {
+				categories = append(categories, category)
+			}
+		}
+		slices.Sort(categories)
+		return fmt.Errorf("%w: %s", ErrFlagged, strings.Join(categories, ", "))
+	}
+	return nil
+}
+
+func download(b *gotgbot.Bot, photo *gotgbot.PhotoSize) ([]byte, error) {
+	if photo.FileSize > tgUtils.MaxFilesizeDownload {
+		return nil, fmt.Errorf("photo is too big: %d", photo.FileSize)
+	}
+
+	file, err := httpUtils.DownloadFile(b, photo.FileId)
+	if err != nil {
+		return nil, err
+	}
+	defer func(file io.ReadCloser) {
+		if err := file.Close(); err != nil {
+			log.Err(err).Msg("Failed to close file")
+		}
+	}(file)
+
+	data, err := io.ReadAll(io.LimitReader(file, tgUtils.MaxFilesizeDownload+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read photo: %w", err)
+	}
+	if len(data) > tgUtils.MaxFilesizeDownload {
+		return nil, fmt.Errorf("photo is larger than %d bytes", tgUtils.MaxFilesizeDownload)
+	}
+	return data, nil
+}
+
+// userMessage returns the message for errors that are caused by the user or the configuration.
+func userMessage(err error) (string, bool) {
+	switch {
+	case errors.Is(err, ErrNotConfigured):
+		return "Die Bildgenerierung ist nicht eingerichtet.", true
+	case errors.Is(err, ErrDisabled):
+		return "Die Bildgenerierung ist in diesem Chat deaktiviert.", true
+	case errors.Is(err, ErrQuotaExceeded):
+		return "Das Tageslimit für Bilder ist erreicht, bitte morgen erneut versuchen.", true
+	case errors.Is(err, ErrFlagged):
+		return "Die Anfrage oder das Bild wurde von der Moderation blockiert.", true
+	}
+	return "", false
+}
+
+// sourcePhoto returns the photo of the message or of the message it replies to.
+func sourcePhoto(message *gotgbot.Message) *gotgbot.PhotoSize {
+	if message.Photo != nil {
+		return tgUtils.GetBestResolution(message.Photo)
+	}
+	if tgUtils.IsReply(message) && message.ReplyToMessage.Photo != nil {
+		return tgUtils.GetBestResolution(message.ReplyToMessage.Photo)
+	}
+	return nil
+}
+
+// replyWithImage sends the image as a photo, or as a document if Telegram doesn't accept it as a photo.
+func replyWithImage(b *gotgbot.Bot, message *gotgbot.Message, image []byte, caption string) error {
+	caption = utils.TruncateText(caption, tgUtils.MaxCaptionLength)
+	if len(image) <= tgUtils.MaxPhotosizeUpload {
+		_, err := message.ReplyPhoto(b, gotgbot.InputFileByReader("image.png", bytes.NewReader(image)), &gotgbot.SendPhotoOpts{
+			Caption:         caption,
+			ReplyParameters: &gotgbot.ReplyParameters{AllowSendingWithoutReply: true},
+		})
+		if err == nil {
+			return nil
+		}
+		log.Warn().Err(err).Msg("Failed to send image as photo, trying as document")
+	}
+
+	_, err := message.ReplyDocument(b, gotgbot.InputFileByReader("image.png", bytes.NewReader(image)), &gotgbot.SendDocumentOpts{
+		Caption:         caption,
+		ReplyParameters: &gotgbot.ReplyParameters{AllowSendingWithoutReply: true},
+	})
+	return err
+}
+
+func (p *Plugin) onImage(b *gotgbot.Bot, c plugin.GobotContext) error {
+	prompt := strings.TrimSpace(c.Matches[1])
+	if prompt == "" {
+		_, err := c.EffectiveMessage.ReplyMessage(b,
+			"<b>Verwendung:</b> <code>/img &lt;Beschreibung&gt;</code>\nAls Antwort auf ein Foto wird dieses bearbeitet.",
+			utils.DefaultSendOptions())
+		return err
+	}
+	if len([]rune(prompt)) > MaxPromptLength {
+		_, err := c.EffectiveMessage.ReplyMessage(b,
+			fmt.Sprintf("❌ Die Beschreibung darf höchstens %d Zeichen lang sein.", MaxPromptLength),
+			utils.DefaultSendOptions())
+		return err
+	}
+
+	_, _ = c.EffectiveChat.SendAction(b, gotgbot.ChatActionUploadPhoto, nil)
+
+	image, err := p.create(b, c.EffectiveChat, c.EffectiveUser, prompt, sourcePhoto(c.EffectiveMessage))
+	if err != nil {
+		if message, ok := userMessage(err); ok {
+			log.Warn().Err(err).Int64("chat_id", c.EffectiveChat.Id).Msg("Image was not created")
+			_, err := c.EffectiveMessage.ReplyMessage(b, "❌ "+message, utils.DefaultSendOptions())
+			return err
+		}
+		guid := xid.New().String()
+		log.Err(err).
+			Str("guid", guid).
+			Int64("chat_id", c.EffectiveChat.Id).
+			Msg("Failed to create image")
+		_, err := c.EffectiveMessage.ReplyMessage(b, fmt.Sprintf("❌ Es ist ein Fehler aufgetreten.%s", utils.EmbedGUID(guid)),
+			utils.DefaultSendOptions())
+		return err
+	}
+
+	return replyWithImage(b, c.EffectiveMessage, image, prompt)
+}
+
+func (p *Plugin) StartJobs(ctx context.Context, _ *gotgbot.Bot) {
+	utils.RunPeriodically(ctx, time.Hour, 24*time.Hour, func() {
+		cleanup(p.imageService)
+	})
+}
+
+func cleanup(imageService Service) {
+	log.Debug().Msg("starting cleanup")
+
+	if err := imageService.Cleanup(QuotaWindow); err != nil {
+		log.Err(err).Msg("error cleaning up old image generations")
+	}
+}
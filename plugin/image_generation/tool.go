@@ -0,0 +1,121 @@
+package image_generation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Brawl345/gobot/llm"
+	"github.com/Brawl345/gobot/plugin"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+const MaxImagesPerPrompt = 1
+
+// ImageTool creates images for the user who asked and sends them as a reply to their message.
+type ImageTool struct {
+	plugin  *Plugin
+	bot     *gotgbot.Bot
+	chat    *gotgbot.Chat
+	message *gotgbot.Message
+	user    *gotgbot.User
+	mu      sync.Mutex
+	created int
+}
+
+func (p *Plugin) Tools(b *gotgbot.Bot, c plugin.GobotContext) []llm.Tool {
+	if c.EffectiveUser == nil {
+		return nil
+	}
+	return []llm.Tool{&ImageTool{
+		plugin:  p,
+		bot:     b,
+		chat:    c.EffectiveChat,
+		message: c.EffectiveMessage,
+		user:    c.EffectiveUser,
+	}}
+}
+
+func (t *ImageTool) Definition() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Name: "generate_image",
+		Description: "Generiert ein Bild und sendet es direkt in den Chat, oder bearbeitet das Foto des Nutzers. " +
+			"Nur nutzen, wenn der Nutzer ausdrücklich ein Bild möchte, jeder Nutzer hat ein Tageslimit.",
+		Parameters: llm.Parameters{
+			Type: "object",
+			Properties: map[string]llm.Property{
+				"prompt": {
+					Type:        "string",
+					Description: fmt.Sprintf("Ausführliche Beschreibung des Bildes oder der Änderung, höchstens %d Zeichen", MaxPromptLength),
+				},
+				"edit_photo": {
+					Type:        "boolean",
+					Description: "true, um das Foto zu bearbeiten, das der Nutzer mitschickt oder auf das er antwortet",
+				},
+			},
+			Required: []string{"prompt", "edit_photo"},
+		},
+		Strict: true,
+	}
+}
+
+func (t *ImageTool) Execute(arguments string) (llm.ToolOutput, error) {
+	var args struct {
+		Prompt    string `json:"prompt"`
+		EditPhoto bool   `json:"edit_photo"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return llm.ToolOutput{}, fmt.Errorf("invalid arguments: %w", err)
+	}
+	log.Debug().
+		Str("prompt", args.Prompt).
+		Bool("edit_photo", args.EditPhoto).
+		Int64("chat_id", t.chat.Id).
+		Int64("user_id", t.user.Id).
+		Msg("generate_image tool call")
+
+	prompt := strings.TrimSpace(args.Prompt)
+	if prompt == "" {
+		return llm.ToolOutput{Text: "Die Beschreibung darf nicht leer sein."}, nil
+	}
+	if len([]rune(prompt)) > MaxPromptLength {
+		return llm.ToolOutput{Text: fmt.Sprintf("Die Beschreibung darf höchstens %d Zeichen lang sein.", MaxPromptLength)}, nil
+	}
+
+	var source *gotgbot.PhotoSize
+	if args.EditPhoto {
+		source = sourcePhoto(t.message)
+		if source == nil {
+			return llm.ToolOutput{Text: "Der Nutzer hat kein Foto mitgeschickt oder auf keines geantwortet."}, nil
+		}
+	}
+
+	t.mu.Lock()
+	if t.created >= MaxImagesPerPrompt {
+		t.mu.Unlock()
+		return llm.ToolOutput{Text: fmt.Sprintf("Pro Nachricht können höchstens %d Bilder erstellt werden.", MaxImagesPerPrompt)}, nil
+	}
+	t.created++
+	t.mu.Unlock()
+
+	_, _ = t.chat.SendAction(t.bot, gotgbot.ChatActionUploadPhoto, nil)
+
+	image, err := t.plugin.create(t.bot, t.chat, t.user, prompt, source)
+	if err != nil {
+		if message, ok := userMessage(err); ok {
+			log.Warn().Err(err).Int64("chat_id", t.chat.Id).Msg("Image was not created")
+			return llm.ToolOutput{Text: message}, nil
+		}
+		return llm.ToolOutput{}, err
+	}
+
+	if err := replyWithImage(t.bot, t.message, image, prompt); err != nil {
+		return llm.ToolOutput{}, fmt.Errorf("failed to send image: %w", err)
+	}
+	return llm.ToolOutput{Text: "Das Bild wurde in den Chat gesendet, beschreibe es nicht noch einmal."}, nil
+}
+
+func (t *ImageTool) Emoji() string {
+	return "🎨"
+}
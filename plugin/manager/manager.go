@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/Brawl345/gobot/utils/tgUtils"
 	"github.com/PaulSonOfLars/gotgbot/v2"
@@ -19,12 +21,14 @@ var log = logger.New("manager")
 
 type (
 	Plugin struct {
+		auditService   model.AuditService
 		managerService model.ManagerService
 	}
 )
 
-func New(service model.ManagerService) *Plugin {
+func New(service model.ManagerService, auditService model.AuditService) *Plugin {
 	return &Plugin{
+		auditService:   auditService,
 		managerService: service,
 	}
 }
@@ -39,6 +43,11 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 
 func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
+		&plugin.CommandHandler{
+			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/plugins(?:@%s)?$`, botInfo.Username)),
+			HandlerFunc: p.OnList,
+			AdminOnly:   true,
+		},
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/enable(?:@%s)? (.+)$`, botInfo.Username)),
 			HandlerFunc: p.OnEnable,
@@ -59,7 +68,65 @@ func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 			HandlerFunc: p.OnDisableInChat,
 			AdminOnly:   true,
 		},
+		&plugin.CommandHandler{
+			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/enable_topic(?:@%s)? (.+)$`, botInfo.Username)),
+			HandlerFunc: p.OnEnableInTopic,
+			AdminOnly:   true,
+			GroupOnly:   true,
+		},
+		&plugin.CommandHandler{
+			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/disable_topic(?:@%s)? (.+)$`, botInfo.Username)),
+			HandlerFunc: p.OnDisableInTopic,
+			AdminOnly:   true,
+			GroupOnly:   true,
+		},
+	}
+}
+
+func (p *Plugin) OnList(b *gotgbot.Bot, c plugin.GobotContext) error {
+	plugins := slices.Clone(p.managerService.Plugins())
+	slices.SortFunc(plugins, func(a, b plugin.Plugin) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	var sb strings.Builder
+	sb.WriteString("<b>Plugins:</b>\n")
+
+	var unusable int
+	for _, plg := range plugins {
+		name := plg.Name()
+		missing, _ := p.managerService.MissingCredentials(plg)
+
+		switch {
+		case !p.managerService.IsPluginEnabled(name):
+			sb.WriteString(fmt.Sprintf("❌ %s <i>(deaktiviert)</i>", name))
+		case len(missing) > 0:
+			unusable++
+			sb.WriteString(fmt.Sprintf("🔑 %s <i>(fehlt: %s)</i>", name, formatCredentials(missing)))
+		case tgUtils.FromGroup(c.EffectiveMessage) && p.managerService.IsPluginDisabledForChat(c.EffectiveChat, name):
+			sb.WriteString(fmt.Sprintf("🚫 %s <i>(in diesem Chat deaktiviert)</i>", name))
+		case c.ThreadID != 0 && p.managerService.IsPluginDisabledForTopic(c.EffectiveChat, c.ThreadID, name):
+			sb.WriteString(fmt.Sprintf("🚫 %s <i>(in diesem Thema deaktiviert)</i>", name))
+		default:
+			sb.WriteString(fmt.Sprintf("✅ %s", name))
+		}
+		sb.WriteString("\n")
+	}
+
+	if unusable > 0 {
+		sb.WriteString(fmt.Sprintf("\n%d Plugin(s) wegen fehlender Schlüssel nicht nutzbar.", unusable))
+	}
+
+	_, err := c.EffectiveMessage.ReplyMessage(b, sb.String(), utils.DefaultSendOptions())
+	return err
+}
+
+func formatCredentials(names []string) string {
+	formatted := make([]string, 0, len(names))
+	for _, name := range names {
+		formatted = append(formatted, fmt.Sprintf("<code>%s</code>", name))
 	}
+	return strings.Join(formatted, ", ")
 }
 
 func (p *Plugin) OnEnable(b *gotgbot.Bot, c plugin.GobotContext) error {
@@ -86,6 +153,8 @@ func (p *Plugin) OnEnable(b *gotgbot.Bot, c plugin.GobotContext) error {
 		return err
 	}
 
+	p.auditService.Record(c.EffectiveUser, c.EffectiveChat, model.AuditPluginEnable, pluginName)
+
 	return tgUtils.AddReactionWithFallback(b, c.EffectiveMessage, "👍", &tgUtils.ReactionFallbackOpts{
 		Fallback: "✅ Plugin wurde aktiviert",
 	})
@@ -116,6 +185,8 @@ func (p *Plugin) OnEnableInChat(b *gotgbot.Bot, c plugin.GobotContext) error {
 		return err
 	}
 
+	p.auditService.Record(c.EffectiveUser, c.EffectiveChat, model.AuditPluginEnableChat, pluginName)
+
 	return tgUtils.AddReactionWithFallback(b, c.EffectiveMessage, "👍", &tgUtils.ReactionFallbackOpts{
 		Fallback: "✅ Plugin wurde für diesen Chat wieder aktiviert",
 	})
@@ -145,6 +216,8 @@ func (p *Plugin) OnDisable(b *gotgbot.Bot, c plugin.GobotContext) error {
 		return err
 	}
 
+	p.auditService.Record(c.EffectiveUser, c.EffectiveChat, model.AuditPluginDisable, pluginName)
+
 	return tgUtils.AddReactionWithFallback(b, c.EffectiveMessage, "👍", &tgUtils.ReactionFallbackOpts{
 		Fallback: "✅ Plugin wurde deaktiviert",
 	})
@@ -180,7 +253,90 @@ func (p *Plugin) OnDisableInChat(b *gotgbot.Bot, c plugin.GobotContext) error {
 		return err
 	}
 
+	p.auditService.Record(c.EffectiveUser, c.EffectiveChat, model.AuditPluginDisableChat, pluginName)
+
 	return tgUtils.AddReactionWithFallback(b, c.EffectiveMessage, "👍", &tgUtils.ReactionFallbackOpts{
 		Fallback: "✅ Plugin wurde für diesen Chat deaktiviert",
 	})
 }
+
+func (p *Plugin) OnEnableInTopic(b *gotgbot.Bot, c plugin.GobotContext) error {
+	pluginName := c.Matches[1]
+
+	if c.ThreadID == 0 {
+		_, err := c.EffectiveMessage.ReplyMessage(b, "❌ Dieser Befehl funktioniert nur in Themen.", utils.DefaultSendOptions())
+		return err
+	}
+
+	if !p.managerService.IsPluginDisabledForTopic(c.EffectiveChat, c.ThreadID, pluginName) {
+		_, err := c.EffectiveMessage.ReplyMessage(b, "💡 Plugin ist für dieses Thema schon aktiv", utils.DefaultSendOptions())
+		return err
+	}
+
+	err := p.managerService.EnablePluginForTopic(c.EffectiveChat, c.ThreadID, pluginName)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			_, err = c.EffectiveMessage.ReplyMessage(b, "❌ Plugin existiert nicht", utils.DefaultSendOptions())
+			return err
+		}
+
+		guid := xid.New().String()
+		log.Err(err).
+			Str("guid", guid).
+			Str("plugin", pluginName).
+			Int64("chat_id", c.EffectiveChat.Id).
+			Int64("thread_id", c.ThreadID).
+			Msg("Failed to enable plugin in topic")
+		_, err := c.EffectiveMessage.ReplyMessage(b, fmt.Sprintf("❌ Es ist ein Fehler aufgetreten.%s", utils.EmbedGUID(guid)), utils.DefaultSendOptions())
+		return err
+	}
+
+	p.auditService.Record(c.EffectiveUser, c.EffectiveChat, model.AuditPluginEnableTopic, fmt.Sprintf("%s (Thema %d)", pluginName, c.ThreadID))
+
+	return tgUtils.AddReactionWithFallback(b, c.EffectiveMessage, "👍", &tgUtils.ReactionFallbackOpts{
+		Fallback: "✅ Plugin wurde für dieses Thema wieder aktiviert",
+	})
+}
+
+func (p *Plugin) OnDisableInTopic(b *gotgbot.Bot, c plugin.GobotContext) error {
+	pluginName := c.Matches[1]
+
+	if c.ThreadID == 0 {
+		_, err := c.EffectiveMessage.ReplyMessage(b, "❌ Dieser Befehl funktioniert nur in Themen.", utils.DefaultSendOptions())
+		return err
+	}
+
+	if pluginName == p.Name() {
+		_, err := c.EffectiveMessage.ReplyMessage(b, "❌ Manager kann nicht deaktiviert werden.", utils.DefaultSendOptions())
+		return err
+	}
+
+	if p.managerService.IsPluginDisabledForTopic(c.EffectiveChat, c.ThreadID, pluginName) {
+		_, err := c.EffectiveMessage.ReplyMessage(b, "💡 Plugin ist für dieses Thema schon deaktiviert", utils.DefaultSendOptions())
+		return err
+	}
+
+	err := p.managerService.DisablePluginForTopic(c.EffectiveChat, c.ThreadID, pluginName)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			_, err = c.EffectiveMessage.ReplyMessage(b, "❌ Plugin existiert nicht", utils.DefaultSendOptions())
+			return err
+		}
+
+		guid := xid.New().String()
+		log.Err(err).
+			Str("guid", guid).
+			Str("plugin", pluginName).
+			Int64("chat_id", c.EffectiveChat.Id).
+			Int64("thread_id", c.ThreadID).
+			Msg("Failed to disable plugin in topic")
+		_, err := c.EffectiveMessage.ReplyMessage(b, fmt.Sprintf("❌ Es ist ein Fehler aufgetreten.%s", utils.EmbedGUID(guid)), utils.DefaultSendOptions())
+		return err
+	}
+
+	p.auditService.Record(c.EffectiveUser, c.EffectiveChat, model.AuditPluginDisableTopic, fmt.Sprintf("%s (Thema %d)", pluginName, c.ThreadID))
+
+	return tgUtils.AddReactionWithFallback(b, c.EffectiveMessage, "👍", &tgUtils.ReactionFallbackOpts{
+		Fallback: "✅ Plugin wurde für dieses Thema deaktiviert",
+	})
+}
@@ -0,0 +1,38 @@
+package plugin
+
+import (
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+type (
+	// Middleware wraps the execution of a handler. It can run code before and after calling next
+	// or stop the execution by not calling next at all.
+	Middleware func(next GobotHandlerFunc) GobotHandlerFunc
+
+	// MiddlewareProvider can be implemented by plugins to wrap all of their handlers.
+	// Plugin middlewares run after the global ones.
+	MiddlewareProvider interface {
+		Middlewares() []Middleware
+	}
+)
+
+// Chain wraps the handler in the given middlewares, the first middleware being the outermost one.
+func Chain(handler GobotHandlerFunc, middlewares ...Middleware) GobotHandlerFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// ChatAction sends a chat action like "typing" before the handler runs. Inline queries are skipped
+// since they don't belong to a chat.
+func ChatAction(action string) Middleware {
+	return func(next GobotHandlerFunc) GobotHandlerFunc {
+		return func(b *gotgbot.Bot, c GobotContext) error {
+			if c.EffectiveChat != nil {
+				_, _ = c.EffectiveChat.SendAction(b, action, nil)
+			}
+			return next(b, c)
+		}
+	}
+}
@@ -46,19 +46,36 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	}
 }
 
+func (p *Plugin) Credentials() plugin.Credentials {
+	return plugin.Credentials{
+		Required: []string{"mal_client_id"},
+	}
+}
+
+func (p *Plugin) Help() plugin.Help {
+	return plugin.Help{
+		Description:     "Sucht Anime auf MyAnimeList.",
+		Examples:        []string{"/mal Cowboy Bebop"},
+		PassiveTriggers: []string{"Links zu Anime auf MyAnimeList"},
+	}
+}
+
 func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/mal(?:@%s)? (.+)$`, botInfo.Username)),
 			HandlerFunc: p.onSearch,
+			HandleEdits: true,
 		},
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/mal_(\d+)(?:@%s)?$`, botInfo.Username)),
 			HandlerFunc: p.onAnime,
+			HandleEdits: true,
 		},
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(`myanimelist\.net/anime/(\d+)`),
 			HandlerFunc: p.onAnime,
+			HandleEdits: true,
 		},
 	}
 }
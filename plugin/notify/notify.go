@@ -54,6 +54,13 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	}
 }
 
+func (p *Plugin) Help() plugin.Help {
+	return plugin.Help{
+		Description:     "Benachrichtigt dich privat, wenn du in einer Gruppe erwähnt wirst.",
+		PassiveTriggers: []string{"Erwähnungen"},
+	}
+}
+
 func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
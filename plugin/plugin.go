@@ -1,10 +1,13 @@
 package plugin
 
 import (
-	"github.com/PaulSonOfLars/gotgbot/v2"
-	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"context"
 	"regexp"
 	"time"
+
+	"github.com/Brawl345/gobot/llm"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
 )
 
 type (
@@ -18,6 +21,44 @@ type (
 		Handlers(botInfo *gotgbot.User) []Handler
 	}
 
+	// CredentialsProvider can be implemented by plugins that need keys from the credential store.
+	// Handlers of plugins with missing required credentials won't run.
+	CredentialsProvider interface {
+		Credentials() Credentials
+	}
+
+	Credentials struct {
+		Required []string
+		AnyOf    [][]string // At least one credential of every group is required
+		Optional []string
+	}
+
+	// HelpProvider can be implemented by plugins to describe themselves in /help.
+	// Commands are listed automatically.
+	HelpProvider interface {
+		Help() Help
+	}
+
+	Help struct {
+		Description     string   // What the plugin does
+		Examples        []string // Example usages like "/ud Kartoffel"
+		InlineTriggers  []string // Inline queries the plugin answers, without the bot's username
+		PassiveTriggers []string // What the plugin reacts to without a command
+	}
+
+	// ToolProvider can be implemented by plugins that offer tools to the AI chat. The tools are created
+	// for every message and may only act on behalf of its sender (c.EffectiveUser) in c.EffectiveChat,
+	// they are not offered where the plugin is disabled.
+	ToolProvider interface {
+		Tools(b *gotgbot.Bot, c GobotContext) []llm.Tool
+	}
+
+	// JobRunner can be implemented by plugins that run jobs in the background, like cleanups or schedulers.
+	// The jobs are started once the bot is running (not when replaying updates) and have to stop when ctx is done.
+	JobRunner interface {
+		StartJobs(ctx context.Context, b *gotgbot.Bot)
+	}
+
 	Handler interface {
 		Command() any
 		Run(b *gotgbot.Bot, c GobotContext) error
@@ -27,6 +68,9 @@ type (
 		*ext.Context
 		Matches      []string          // Regex matches
 		NamedMatches map[string]string // Named Regex matches
+		Plugin       Plugin            // Plugin the handler belongs to
+		Handler      Handler           // Handler that matched the update
+		ThreadID     int64             // Forum topic the update belongs to, 0 outside of topics
 	}
 
 	GobotHandlerFunc func(b *gotgbot.Bot, c GobotContext) error
@@ -36,7 +80,10 @@ type (
 		HandlerFunc GobotHandlerFunc
 		AdminOnly   bool
 		GroupOnly   bool
-		HandleEdits bool
+		HandleEdits bool // Run again when the message is edited, the new answer replaces the previous one. Edits of messages from before the last restart are ignored
+		// Also run for posts in channels. There is no EffectiveUser then, EffectiveSender is the channel.
+		HandleChannelPosts bool
+		RepliesToBot       bool // Only run for replies to messages of the bot
 	}
 
 	CallbackHandler struct {
@@ -53,6 +100,16 @@ type (
 		AdminOnly           bool
 		CanBeUsedByEveryone bool
 	}
+
+	// ReactionHandler runs when a user adds one of the emoji to a message. Matches[0] is the emoji.
+	// EffectiveMessage is the message that got the reaction if the bot has seen it recently, nil otherwise.
+	// Telegram only sends reactions to bots that are administrators of the group.
+	ReactionHandler struct {
+		HandlerFunc GobotHandlerFunc
+		Emoji       []string // Empty for all emoji
+		AdminOnly   bool
+		GroupOnly   bool
+	}
 )
 
 func (h *CommandHandler) Command() any {
@@ -78,3 +135,11 @@ func (h *InlineHandler) Command() any {
 func (h *InlineHandler) Run(b *gotgbot.Bot, c GobotContext) error {
 	return h.HandlerFunc(b, c)
 }
+
+func (h *ReactionHandler) Command() any {
+	return h.Emoji
+}
+
+func (h *ReactionHandler) Run(b *gotgbot.Bot, c GobotContext) error {
+	return h.HandlerFunc(b, c)
+}
@@ -52,6 +52,14 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	}
 }
 
+func (p *Plugin) Help() plugin.Help {
+	return plugin.Help{
+		Description:     "Speichert Zitate der Gruppe und gibt zufällige wieder aus.",
+		Examples:        []string{"/addquote Das war ein Scherz.", "/quote"},
+		PassiveTriggers: []string{"✍-Reaktion auf eine Nachricht speichert sie als Zitat (nur für Textnachrichten der letzten 48 Stunden, die der Bot seit seinem letzten Neustart gesehen hat)"},
+	}
+}
+
 func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
@@ -79,6 +87,11 @@ func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 			HandlerFunc: p.deleteQuote,
 			GroupOnly:   true,
 		},
+		&plugin.ReactionHandler{
+			Emoji:       []string{"✍"},
+			HandlerFunc: p.onReaction,
+			GroupOnly:   true,
+		},
 		&plugin.CallbackHandler{
 			Trigger:      regexp.MustCompile(`^quotes_again$`),
 			HandlerFunc:  p.getQuote,
@@ -162,6 +175,33 @@ func (p *Plugin) addQuote(b *gotgbot.Bot, c plugin.GobotContext) error {
 	})
 }
 
+// onReaction saves the message as a quote. Reactions only contain the message ID, so this only
+// works for messages the bot has seen since it was started, see plugin.ReactionHandler.
+func (p *Plugin) onReaction(b *gotgbot.Bot, c plugin.GobotContext) error {
+	msg := c.EffectiveMessage
+	if msg == nil || msg.From == nil || msg.From.IsBot {
+		return nil
+	}
+
+	text := msg.GetText()
+	if text == "" {
+		return nil
+	}
+
+	quote := fmt.Sprintf("\"%s\" —%s", text, utils.FullName(msg.From.FirstName, msg.From.LastName))
+	err := p.quoteService.SaveQuote(c.EffectiveChat, quote)
+	if err != nil {
+		if errors.Is(err, model.ErrAlreadyExists) {
+			return nil
+		}
+		return fmt.Errorf("failed to save quote from reaction: %w", err)
+	}
+
+	return tgUtils.AddReactionWithFallback(b, msg, "👍", &tgUtils.ReactionFallbackOpts{
+		Fallback: "<b>✅ Zitat gespeichert!</b>",
+	})
+}
+
 func (p *Plugin) deleteQuote(b *gotgbot.Bot, c plugin.GobotContext) error {
 	var quote string
 	if len(c.Matches) > 1 {
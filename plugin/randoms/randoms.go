@@ -19,6 +19,7 @@ var log = logger.New("randoms")
 
 type (
 	Plugin struct {
+		auditService  model.AuditService
 		randomService Service
 	}
 
@@ -29,8 +30,9 @@ type (
 	}
 )
 
-func New(randomService Service) *Plugin {
+func New(randomService Service, auditService model.AuditService) *Plugin {
 	return &Plugin{
+		auditService:  auditService,
 		randomService: randomService,
 	}
 }
@@ -93,6 +95,8 @@ func (p *Plugin) addRandom(b *gotgbot.Bot, c plugin.GobotContext) error {
 		return err
 	}
 
+	p.auditService.Record(c.EffectiveUser, c.EffectiveChat, model.AuditRandomAdd, random)
+
 	example := strings.NewReplacer(
 		"{user}", "<b>"+utils.Escape(c.EffectiveUser.FirstName)+"</b>",
 		"{other_user}", "<b>"+utils.Escape(b.FirstName)+"</b>",
@@ -121,6 +125,8 @@ func (p *Plugin) delRandom(b *gotgbot.Bot, c plugin.GobotContext) error {
 		return err
 	}
 
+	p.auditService.Record(c.EffectiveUser, c.EffectiveChat, model.AuditRandomDelete, random)
+
 	return tgUtils.AddReactionWithFallback(b, c.EffectiveMessage, "👍", &tgUtils.ReactionFallbackOpts{
 		Fallback: "<b>✅ Text gelöscht!</b>",
 	})
@@ -1,6 +1,7 @@
 package reminders
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math"
@@ -31,32 +32,36 @@ type (
 		GetReminderByID(id int64) (model.Reminder, error)
 		GetAllReminders() ([]model.Reminder, error)
 		GetReminders(chat *gotgbot.Chat, user *gotgbot.User) ([]model.Reminder, error)
-		SaveReminder(chat *gotgbot.Chat, user *gotgbot.User, remindAt time.Time, text string) (int64, error)
+		SaveReminder(chat *gotgbot.Chat, threadID int64, user *gotgbot.User, remindAt time.Time, text string) (int64, error)
 	}
 )
 
-func New(bot *gotgbot.Bot, service Service) *Plugin {
-	reminders, err := service.GetAllReminders()
+func New(service Service) *Plugin {
+	return &Plugin{
+		reminderService: service,
+	}
+}
+
+// StartJobs sends reminders that were due while the bot was offline and schedules the others.
+func (p *Plugin) StartJobs(ctx context.Context, bot *gotgbot.Bot) {
+	reminders, err := p.reminderService.GetAllReminders()
 	if err != nil {
 		log.Err(err).
 			Msg("Failed to get all reminders")
 	}
 
-	p := &Plugin{
-		reminderService: service,
-	}
-
 	for _, reminder := range reminders {
 		if time.Now().After(reminder.Time) {
 			p.sendReminder(bot, reminder.ID)
 		} else {
-			time.AfterFunc(time.Until(reminder.Time), func() {
+			timer := time.AfterFunc(time.Until(reminder.Time), func() {
 				p.sendReminder(bot, reminder.ID)
 			})
+			context.AfterFunc(ctx, func() {
+				timer.Stop()
+			})
 		}
 	}
-
-	return p
 }
 
 func (p *Plugin) Name() string {
@@ -76,6 +81,17 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	}
 }
 
+func (p *Plugin) Help() plugin.Help {
+	return plugin.Help{
+		Examples: []string{
+			"/remind 30m Pizza aus dem Ofen holen",
+			"/remind 18:00 Feierabend",
+			"/remind 24.12. 18:00 Bescherung",
+			"/remind_delete 1",
+		},
+	}
+}
+
 func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
@@ -167,7 +183,7 @@ func (p *Plugin) onAddDateTimeReminder(b *gotgbot.Bot, c plugin.GobotContext) er
 		remindTime = remindTime.AddDate(1, 0, 0)
 	}
 
-	id, err := p.reminderService.SaveReminder(c.EffectiveChat, c.EffectiveUser, remindTime, text)
+	id, err := p.reminderService.SaveReminder(c.EffectiveChat, c.ThreadID, c.EffectiveUser, remindTime, text)
 	if err != nil {
 		guid := xid.New().String()
 		log.Err(err).
@@ -235,7 +251,7 @@ func (p *Plugin) onAddTimeReminder(b *gotgbot.Bot, c plugin.GobotContext) error
 		remindTime = remindTime.AddDate(0, 0, 1)
 	}
 
-	id, err := p.reminderService.SaveReminder(c.EffectiveChat, c.EffectiveUser, remindTime, text)
+	id, err := p.reminderService.SaveReminder(c.EffectiveChat, c.ThreadID, c.EffectiveUser, remindTime, text)
 	if err != nil {
 		guid := xid.New().String()
 		log.Err(err).
@@ -299,7 +315,7 @@ func (p *Plugin) onAddDeltaReminder(b *gotgbot.Bot, c plugin.GobotContext) error
 		return err
 	}
 
-	id, err := p.reminderService.SaveReminder(c.EffectiveChat, c.EffectiveUser, remindTime, text)
+	id, err := p.reminderService.SaveReminder(c.EffectiveChat, c.ThreadID, c.EffectiveUser, remindTime, text)
 	if err != nil {
 		guid := xid.New().String()
 		log.Err(err).
@@ -431,10 +447,12 @@ func (p *Plugin) sendReminder(bot *gotgbot.Bot, id int64) {
 	sb.WriteString("<b>ERINNERUNG:</b>\n")
 	sb.WriteString(utils.Escape(reminder.Text))
 
+	sendOptions := utils.DefaultSendOptions()
+	sendOptions.MessageThreadId = reminder.ThreadID
 	_, err = bot.SendMessage(
 		recipient,
 		sb.String(),
-		utils.DefaultSendOptions(),
+		sendOptions,
 	)
 
 	if err != nil {
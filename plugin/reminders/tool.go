@@ -0,0 +1,121 @@
+package reminders
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Brawl345/gobot/llm"
+	"github.com/Brawl345/gobot/plugin"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+const (
+	toolTimeLayout        = "2006-01-02 15:04"
+	MaxRemindersPerPrompt = 3
+)
+
+// ReminderTool creates reminders for the user who asked, in the chat and topic they asked in.
+type ReminderTool struct {
+	plugin   *Plugin
+	bot      *gotgbot.Bot
+	chat     *gotgbot.Chat
+	threadID int64
+	user     *gotgbot.User
+	mu       sync.Mutex
+	created  int
+}
+
+func (p *Plugin) Tools(b *gotgbot.Bot, c plugin.GobotContext) []llm.Tool {
+	if c.EffectiveUser == nil {
+		return nil
+	}
+	return []llm.Tool{&ReminderTool{
+		plugin:   p,
+		bot:      b,
+		chat:     c.EffectiveChat,
+		threadID: c.ThreadID,
+		user:     c.EffectiveUser,
+	}}
+}
+
+func (t *ReminderTool) Definition() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Name: "create_reminder",
+		Description: fmt.Sprintf("Erstellt eine Erinnerung für den fragenden Nutzer, die zur angegebenen Zeit in diesem Chat gesendet wird. "+
+			"Nur nutzen, wenn der Nutzer ausdrücklich darum bittet. Aktuelle Zeit: %s", time.Now().Format(toolTimeLayout)),
+		Parameters: llm.Parameters{
+			Type: "object",
+			Properties: map[string]llm.Property{
+				"time": {
+					Type:        "string",
+					Description: "Zeitpunkt im Format \"JJJJ-MM-TT HH:MM\", z.B. \"2025-12-24 18:00\"",
+				},
+				"text": {
+					Type:        "string",
+					Description: "Woran erinnert werden soll",
+				},
+			},
+			Required: []string{"time", "text"},
+		},
+		Strict: true,
+	}
+}
+
+func (t *ReminderTool) Execute(arguments string) (llm.ToolOutput, error) {
+	var args struct {
+		Time string `json:"time"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return llm.ToolOutput{}, fmt.Errorf("invalid arguments: %w", err)
+	}
+	log.Debug().
+		Str("time", args.Time).
+		Int64("chat_id", t.chat.Id).
+		Int64("user_id", t.user.Id).
+		Msg("create_reminder tool call")
+
+	text := strings.TrimSpace(args.Text)
+	if text == "" {
+		return llm.ToolOutput{Text: "Der Text darf nicht leer sein."}, nil
+	}
+
+	remindTime, err := time.ParseInLocation(toolTimeLayout, strings.TrimSpace(args.Time), time.Local)
+	if err != nil {
+		return llm.ToolOutput{Text: fmt.Sprintf("Ungültige Zeit, erwartet wird \"%s\".", toolTimeLayout)}, nil
+	}
+	if remindTime.Before(time.Now()) {
+		return llm.ToolOutput{Text: "Die Zeit liegt in der Vergangenheit."}, nil
+	}
+	if remindTime.After(time.Now().AddDate(1, 0, 0)) {
+		return llm.ToolOutput{Text: "Erinnerungen gehen höchstens ein Jahr im Voraus."}, nil
+	}
+
+	t.mu.Lock()
+	if t.created >= MaxRemindersPerPrompt {
+		t.mu.Unlock()
+		return llm.ToolOutput{Text: fmt.Sprintf("Pro Nachricht können höchstens %d Erinnerungen erstellt werden.", MaxRemindersPerPrompt)}, nil
+	}
+	t.created++
+	t.mu.Unlock()
+
+	id, err := t.plugin.reminderService.SaveReminder(t.chat, t.threadID, t.user, remindTime, text)
+	if err != nil {
+		return llm.ToolOutput{}, err
+	}
+
+	time.AfterFunc(time.Until(remindTime), func() {
+		t.plugin.sendReminder(t.bot, id)
+	})
+
+	return llm.ToolOutput{
+		Text: fmt.Sprintf("Erinnerung #%d für %s gespeichert, löschen mit /remind_delete %d.", id, remindTime.Format("02.01.2006 15:04"), id),
+	}, nil
+}
+
+func (t *ReminderTool) Emoji() string {
+	return "⏰"
+}
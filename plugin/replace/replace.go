@@ -25,15 +25,27 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	return nil
 }
 
+func (p *Plugin) Help() plugin.Help {
+	return plugin.Help{
+		Description: "Ersetzt Text in der beantworteten Nachricht. r/ unterstützt reguläre Ausdrücke.",
+		Examples: []string{
+			"s/Hund/Katze",
+			"r/\\d+/Zahl",
+		},
+	}
+}
+
 func (p *Plugin) Handlers(*gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile("^/?s/(.*[^/])/(.*[^/])?/?$"),
 			HandlerFunc: onReplace,
+			HandleEdits: true,
 		},
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile("^/?r/(.*[^/])/(.*[^/])?/?$"),
 			HandlerFunc: onRegexReplace,
+			HandleEdits: true,
 		},
 	}
 }
@@ -0,0 +1,108 @@
+package plugin
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	SettingBool SettingType = iota
+	SettingEnum
+	SettingInt
+	SettingString
+)
+
+// Maximum length of string settings
+const maxSettingLength = 255
+
+type (
+	// SettingsProvider can be implemented by plugins that have settings. They can be changed with /settings.
+	SettingsProvider interface {
+		Settings() []Setting
+	}
+
+	SettingType int
+
+	Setting struct {
+		Key         string // Unique within the plugin, only letters, digits and underscores
+		Name        string // Shown in /settings
+		Description string
+		Type        SettingType
+		Default     string
+		Options     []string // Allowed values of enum settings
+		Min         int64    // Bounds of int settings, both zero for no bounds
+		Max         int64
+		Pattern     *regexp.Regexp // Values of string settings must match, optional
+		PerUser     bool           // Stored per user instead of per chat
+		// Limits the users themselves, like a quota. In private chats, where everyone is the admin
+		// of their chat, only bot admins may change it.
+		LimitsUsers bool
+	}
+
+	// ValidationError is returned for values a setting doesn't accept, the message is meant for users.
+	ValidationError struct {
+		Message string
+	}
+)
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// Normalize validates the value for the setting and returns it in the form it is stored in.
+func (s Setting) Normalize(value string) (string, error) {
+	value = strings.TrimSpace(value)
+
+	switch s.Type {
+	case SettingBool:
+		switch strings.ToLower(value) {
+		case "1", "true", "an", "ein", "ja", "on", "yes":
+			return "true", nil
+		case "0", "false", "aus", "nein", "off", "no":
+			return "false", nil
+		}
+		return "", &ValidationError{"Erlaubt sind \"an\" und \"aus\"."}
+	case SettingEnum:
+		index := slices.IndexFunc(s.Options, func(option string) bool {
+			return strings.EqualFold(option, value)
+		})
+		if index == -1 {
+			return "", &ValidationError{fmt.Sprintf("Erlaubt sind: %s", strings.Join(s.Options, ", "))}
+		}
+		return s.Options[index], nil
+	case SettingInt:
+		number, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "", &ValidationError{"Das ist keine Zahl."}
+		}
+		if (s.Min != 0 || s.Max != 0) && (number < s.Min || number > s.Max) {
+			return "", &ValidationError{fmt.Sprintf("Die Zahl muss zwischen %d und %d liegen.", s.Min, s.Max)}
+		}
+		return strconv.FormatInt(number, 10), nil
+	default:
+		if value == "" {
+			return "", &ValidationError{"Der Wert darf nicht leer sein."}
+		}
+		if len([]rune(value)) > maxSettingLength {
+			return "", &ValidationError{fmt.Sprintf("Der Wert darf höchstens %d Zeichen lang sein.", maxSettingLength)}
+		}
+		if s.Pattern != nil && !s.Pattern.MatchString(value) {
+			return "", &ValidationError{"Dieser Wert ist ungültig."}
+		}
+		return value, nil
+	}
+}
+
+// Format returns the value for display.
+func (s Setting) Format(value string) string {
+	if s.Type == SettingBool {
+		if value == "true" {
+			return "✅ An"
+		}
+		return "❌ Aus"
+	}
+	return value
+}
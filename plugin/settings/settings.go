@@ -0,0 +1,473 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Brawl345/gobot/logger"
+	"github.com/Brawl345/gobot/model"
+	"github.com/Brawl345/gobot/plugin"
+	"github.com/Brawl345/gobot/utils"
+	"github.com/Brawl345/gobot/utils/tgUtils"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/rs/xid"
+)
+
+var log = logger.New("settings")
+
+const (
+	buttonTTL     = time.Hour
+	optionsPerRow = 3
+)
+
+const (
+	actionList   = "list"
+	actionPlugin = "plugin"
+	actionEdit   = "edit"
+	actionSet    = "set"
+	actionReset  = "reset"
+)
+
+type (
+	Plugin struct {
+		auditService    model.AuditService
+		callbackService model.CallbackService
+		managerService  model.ManagerService
+		settingsService model.SettingsService
+	}
+
+	// menuPayload is stored for every button of the menu
+	menuPayload struct {
+		Action string `json:"action"`
+		Plugin string `json:"plugin,omitempty"`
+		Key    string `json:"key,omitempty"`
+		Value  string `json:"value,omitempty"`
+	}
+
+	// menu is a rendered page of the settings menu
+	menu struct {
+		text    string
+		buttons [][]gotgbot.InlineKeyboardButton
+	}
+)
+
+func New(auditService model.AuditService, callbackService model.CallbackService, managerService model.ManagerService, settingsService model.SettingsService) *Plugin {
+	return &Plugin{
+		auditService:    auditService,
+		callbackService: callbackService,
+		managerService:  managerService,
+		settingsService: settingsService,
+	}
+}
+
+func (*Plugin) Name() string {
+	return "settings"
+}
+
+func (p *Plugin) Commands() []gotgbot.BotCommand {
+	return []gotgbot.BotCommand{
+		{
+			Command:     "settings",
+			Description: "Einstellungen anzeigen und ändern",
+		},
+	}
+}
+
+func (p *Plugin) Help() plugin.Help {
+	return plugin.Help{
+		Description: "Zeigt die Einstellungen der Plugins an. Einstellungen des Chats können in Gruppen nur Administratoren ändern, " +
+			"persönliche Einstellungen gelten in allen Chats. Texte und Zahlen werden mit /settings <Plugin> <Einstellung> <Wert> gesetzt.",
+		Examples: []string{"/settings", "/settings wikipedia", "/settings wikipedia lang en"},
+	}
+}
+
+func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
+	return []plugin.Handler{
+		&plugin.CommandHandler{
+			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/settings(?:@%s)?(?: (\w+))?$`, botInfo.Username)),
+			HandlerFunc: p.onSettings,
+		},
+		&plugin.CommandHandler{
+			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?is)^/settings(?:@%s)? (\w+) (\w+) (.+)$`, botInfo.Username)),
+			HandlerFunc: p.onSet,
+		},
+		&plugin.CallbackHandler{
+			Trigger:     regexp.MustCompile(`^settings:(\w+)$`),
+			HandlerFunc: p.onCallback,
+		},
+	}
+}
+
+// providers returns the plugins with settings that can be used in the chat, sorted by name.
+func (p *Plugin) providers(c plugin.GobotContext) []plugin.Plugin {
+	var plugins []plugin.Plugin
+	for _, plg := range p.managerService.Plugins() {
+		if _, ok := plg.(plugin.SettingsProvider); !ok {
+			continue
+		}
+		name := plg.Name()
+		if !p.managerService.IsPluginEnabled(name) {
+			continue
+		}
+		if tgUtils.FromGroup(c.EffectiveMessage) && p.managerService.IsPluginDisabledForChat(c.EffectiveChat, name) {
+			continue
+		}
+		plugins = append(plugins, plg)
+	}
+
+	slices.SortFunc(plugins, func(a, b plugin.Plugin) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return plugins
+}
+
+func (p *Plugin) findSetting(c plugin.GobotContext, pluginName string, key string) (plugin.Plugin, plugin.Setting, bool) {
+	for _, plg := range p.providers(c) {
+		if !strings.EqualFold(plg.Name(), pluginName) {
+			continue
+		}
+		if key == "" {
+			return plg, plugin.Setting{}, true
+		}
+		for _, setting := range plg.(plugin.SettingsProvider).Settings() {
+			if strings.EqualFold(setting.Key, key) {
+				return plg, setting, true
+			}
+		}
+	}
+	return nil, plugin.Setting{}, false
+}
+
+// canChange reports whether the user may change the setting. Chat settings in groups are reserved for administrators.
+func canChange(b *gotgbot.Bot, c plugin.GobotContext, setting plugin.Setting) (bool, error) {
+	if setting.PerUser {
+		return true, nil
+	}
+	if setting.LimitsUsers && c.EffectiveChat.Type == gotgbot.ChatTypePrivate {
+		return tgUtils.IsAdmin(c.EffectiveUser), nil
+	}
+	return tgUtils.IsChatAdmin(b, c.EffectiveChat, c.EffectiveUser)
+}
+
+func (p *Plugin) button(c plugin.GobotContext, text string, payload menuPayload) (gotgbot.InlineKeyboardButton, error) {
+	return tgUtils.TokenButton(p.callbackService, text, "settings", payload, c.EffectiveUser, buttonTTL)
+}
+
+func (p *Plugin) listMenu(c plugin.GobotContext) (menu, error) {
+	providers := p.providers(c)
+	if len(providers) == 0 {
+		return menu{text: "<i>Keine Plugins mit Einstellungen verfügbar.</i>"}, nil
+	}
+
+	var buttons []gotgbot.InlineKeyboardButton
+	for _, plg := range providers {
+		button, err := p.button(c, plg.Name(), menuPayload{Action: actionPlugin, Plugin: plg.Name()})
+		if err != nil {
+			return menu{}, err
+		}
+		buttons = append(buttons, button)
+	}
+
+	return menu{
+		text:    "⚙️ <b>Einstellungen</b>\nWähle ein Plugin aus:",
+		buttons: tgUtils.InlineKeyboard(optionsPerRow, buttons...).InlineKeyboard,
+	}, nil
+}
+
+func (p *Plugin) pluginMenu(c plugin.GobotContext, plg plugin.Plugin) (menu, error) {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("⚙️ <b>Einstellungen: %s</b>\n", utils.Escape(plg.Name())))
+
+	var buttons [][]gotgbot.InlineKeyboardButton
+	for _, setting := range plg.(plugin.SettingsProvider).Settings() {
+		value := setting.Format(p.settingsService.Get(model.ScopeOf(setting, c.EffectiveChat, c.EffectiveUser), plg.Name(), setting))
+
+		sb.WriteString(fmt.Sprintf("\n<b>%s:</b> %s", utils.Escape(setting.Name), utils.Escape(value)))
+		if setting.PerUser {
+			sb.WriteString(" <i>(persönlich)</i>")
+		}
+		if setting.Description != "" {
+			sb.WriteString(fmt.Sprintf("\n<i>%s</i>", utils.Escape(setting.Description)))
+		}
+		sb.WriteString("\n")
+
+		payload := menuPayload{Action: actionEdit, Plugin: plg.Name(), Key: setting.Key}
+		if setting.Type == plugin.SettingBool {
+			payload.Action = actionSet
+			payload.Value = "true"
+			if value == setting.Format("true") {
+				payload.Value = "false"
+			}
+		}
+
+		button, err := p.button(c, fmt.Sprintf("%s: %s", setting.Name, value), payload)
+		if err != nil {
+			return menu{}, err
+		}
+		buttons = append(buttons, []gotgbot.InlineKeyboardButton{button})
+	}
+
+	back, err := p.button(c, "« Zurück", menuPayload{Action: actionList})
+	if err != nil {
+		return menu{}, err
+	}
+	buttons = append(buttons, []gotgbot.InlineKeyboardButton{back})
+
+	return menu{text: sb.String(), buttons: buttons}, nil
+}
+
+func (p *Plugin) settingMenu(c plugin.GobotContext, plg plugin.Plugin, setting plugin.Setting) (menu, error) {
+	value := p.settingsService.Get(model.ScopeOf(setting, c.EffectiveChat, c.EffectiveUser), plg.Name(), setting)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("⚙️ <b>%s</b> (%s)\n", utils.Escape(setting.Name), utils.Escape(plg.Name())))
+	if setting.Description != "" {
+		sb.WriteString(fmt.Sprintf("<i>%s</i>\n", utils.Escape(setting.Description)))
+	}
+	sb.WriteString(fmt.Sprintf("\n<b>Aktuell:</b> %s\n<b>Standard:</b> %s", utils.Escape(setting.Format(value)), utils.Escape(setting.Format(setting.Default))))
+
+	var buttons [][]gotgbot.InlineKeyboardButton
+
+	if setting.Type == plugin.SettingEnum {
+		var options []gotgbot.InlineKeyboardButton
+		for _, option := range setting.Options {
+			text := option
+			if option == value {
+				text = "• " + option
+			}
+			button, err := p.button(c, text, menuPayload{Action: actionSet, Plugin: plg.Name(), Key: setting.Key, Value: option})
+			if err != nil {
+				return menu{}, err
+			}
+			options = append(options, button)
+		}
+		buttons = append(buttons, tgUtils.InlineKeyboard(optionsPerRow, options...).InlineKeyboard...)
+	} else {
+		sb.WriteString(fmt.Sprintf(
+			"\n\nÄndern mit:\n<code>/settings %s %s &lt;Wert&gt;</code>",
+			utils.Escape(plg.Name()),
+			utils.Escape(setting.Key),
+		))
+	}
+
+	reset, err := p.button(c, "Zurücksetzen", menuPayload{Action: actionReset, Plugin: plg.Name(), Key: setting.Key})
+	if err != nil {
+		return menu{}, err
+	}
+	back, err := p.button(c, "« Zurück", menuPayload{Action: actionPlugin, Plugin: plg.Name()})
+	if err != nil {
+		return menu{}, err
+	}
+	buttons = append(buttons, []gotgbot.InlineKeyboardButton{reset, back})
+
+	return menu{text: sb.String(), buttons: buttons}, nil
+}
+
+func (p *Plugin) onSettings(b *gotgbot.Bot, c plugin.GobotContext) error {
+	var (
+		m   menu
+		err error
+	)
+
+	if pluginName := c.Matches[1]; pluginName != "" {
+		plg, _, ok := p.findSetting(c, pluginName, "")
+		if !ok {
+			_, err := c.EffectiveMessage.ReplyMessage(b, "❌ Dieses Plugin hat keine Einstellungen.", utils.DefaultSendOptions())
+			return err
+		}
+		m, err = p.pluginMenu(c, plg)
+	} else {
+		m, err = p.listMenu(c)
+	}
+
+	if err != nil {
+		guid := xid.New().String()
+		log.Err(err).
+			Str("guid", guid).
+			Int64("chat_id", c.EffectiveChat.Id).
+			Msg("Failed to create settings menu")
+		_, err := c.EffectiveMessage.ReplyMessage(b, fmt.Sprintf("❌ Es ist ein Fehler aufgetreten.%s", utils.EmbedGUID(guid)),
+			utils.DefaultSendOptions())
+		return err
+	}
+
+	_, err = c.EffectiveMessage.ReplyMessage(b, m.text, &gotgbot.SendMessageOpts{
+		ReplyParameters:     &gotgbot.ReplyParameters{AllowSendingWithoutReply: true},
+		LinkPreviewOptions:  &gotgbot.LinkPreviewOptions{IsDisabled: true},
+		DisableNotification: true,
+		ParseMode:           gotgbot.ParseModeHTML,
+		ReplyMarkup:         gotgbot.InlineKeyboardMarkup{InlineKeyboard: m.buttons},
+	})
+	return err
+}
+
+func (p *Plugin) onSet(b *gotgbot.Bot, c plugin.GobotContext) error {
+	plg, setting, ok := p.findSetting(c, c.Matches[1], c.Matches[2])
+	if !ok {
+		_, err := c.EffectiveMessage.ReplyMessage(b, "❌ Diese Einstellung existiert nicht.", utils.DefaultSendOptions())
+		return err
+	}
+
+	allowed, err := canChange(b, c, setting)
+	if err != nil {
+		log.Err(err).
+			Int64("chat_id", c.EffectiveChat.Id).
+			Msg("Failed to check if user is a chat administrator")
+	}
+	if !allowed {
+		_, err := c.EffectiveMessage.ReplyMessage(b, "❌ Nur Administratoren können die Einstellungen dieses Chats ändern.", utils.DefaultSendOptions())
+		return err
+	}
+
+	err = p.settingsService.Set(model.ScopeOf(setting, c.EffectiveChat, c.EffectiveUser), plg.Name(), setting, c.Matches[3])
+	if err != nil {
+		if _, ok := errors.AsType[*plugin.ValidationError](err); ok {
+			_, err := c.EffectiveMessage.ReplyMessage(b, fmt.Sprintf("❌ %s", utils.Escape(err.Error())), utils.DefaultSendOptions())
+			return err
+		}
+
+		guid := xid.New().String()
+		log.Err(err).
+			Str("guid", guid).
+			Str("plugin", plg.Name()).
+			Str("key", setting.Key).
+			Msg("Failed to save setting")
+		_, err := c.EffectiveMessage.ReplyMessage(b, fmt.Sprintf("❌ Es ist ein Fehler aufgetreten.%s", utils.EmbedGUID(guid)),
+			utils.DefaultSendOptions())
+		return err
+	}
+
+	p.recordChange(c, plg, setting, model.AuditSettingSet, c.Matches[3])
+
+	return tgUtils.AddReactionWithFallback(b, c.EffectiveMessage, "👍", &tgUtils.ReactionFallbackOpts{
+		Fallback: "✅ Gespeichert.",
+	})
+}
+
+func (p *Plugin) onCallback(b *gotgbot.Bot, c plugin.GobotContext) error {
+	var payload menuPayload
+	err := p.callbackService.Get(tgUtils.CallbackToken(c.CallbackQuery.Data), c.EffectiveUser, &payload)
+	if err != nil {
+		var text string
+		switch {
+		case errors.Is(err, model.ErrNotFound):
+			text = "❌ Dieses Menü ist abgelaufen, bitte sende /settings erneut."
+		case errors.Is(err, model.ErrNotOwner):
+			text = "❌ Nur wer /settings gesendet hat, kann dieses Menü nutzen."
+		default:
+			log.Err(err).
+				Str("data", c.CallbackQuery.Data).
+				Msg("Failed to get settings payload")
+			text = "❌ Es ist ein Fehler aufgetreten."
+		}
+		return answerAlert(b, c, text)
+	}
+
+	if c.EffectiveMessage == nil {
+		_, err := c.CallbackQuery.Answer(b, nil)
+		return err
+	}
+
+	var (
+		plg     plugin.Plugin
+		setting plugin.Setting
+	)
+	if payload.Action != actionList {
+		var ok bool
+		plg, setting, ok = p.findSetting(c, payload.Plugin, payload.Key)
+		if !ok {
+			return answerAlert(b, c, "❌ Diese Einstellung existiert nicht mehr.")
+		}
+	}
+
+	var answer string
+	if payload.Action == actionSet || payload.Action == actionReset {
+		allowed, err := canChange(b, c, setting)
+		if err != nil {
+			log.Err(err).
+				Int64("chat_id", c.EffectiveChat.Id).
+				Msg("Failed to check if user is a chat administrator")
+		}
+		if !allowed {
+			return answerAlert(b, c, "❌ Nur Administratoren können die Einstellungen dieses Chats ändern.")
+		}
+
+		scope := model.ScopeOf(setting, c.EffectiveChat, c.EffectiveUser)
+		if payload.Action == actionSet {
+			err = p.settingsService.Set(scope, plg.Name(), setting, payload.Value)
+			answer = "✅ Gespeichert"
+		} else {
+			err = p.settingsService.Reset(scope, plg.Name(), setting.Key)
+			answer = "✅ Zurückgesetzt"
+		}
+		if err != nil {
+			log.Err(err).
+				Str("plugin", plg.Name()).
+				Str("key", setting.Key).
+				Msg("Failed to save setting")
+			return answerAlert(b, c, "❌ Es ist ein Fehler aufgetreten.")
+		}
+
+		if payload.Action == actionSet {
+			p.recordChange(c, plg, setting, model.AuditSettingSet, payload.Value)
+		} else {
+			p.recordChange(c, plg, setting, model.AuditSettingReset, "")
+		}
+	}
+
+	var m menu
+	switch {
+	case payload.Action == actionList:
+		m, err = p.listMenu(c)
+	case payload.Action == actionEdit || (payload.Action == actionReset && setting.Type != plugin.SettingBool):
+		m, err = p.settingMenu(c, plg, setting)
+	default:
+		m, err = p.pluginMenu(c, plg)
+	}
+	if err != nil {
+		log.Err(err).
+			Int64("chat_id", c.EffectiveChat.Id).
+			Msg("Failed to create settings menu")
+		return answerAlert(b, c, "❌ Es ist ein Fehler aufgetreten.")
+	}
+
+	_, _, err = c.EffectiveMessage.EditText(b, m.text, &gotgbot.EditMessageTextOpts{
+		ParseMode:          gotgbot.ParseModeHTML,
+		LinkPreviewOptions: &gotgbot.LinkPreviewOptions{IsDisabled: true},
+		ReplyMarkup:        gotgbot.InlineKeyboardMarkup{InlineKeyboard: m.buttons},
+	})
+	// Resetting a setting that wasn't changed doesn't change the menu
+	if err != nil && !tgUtils.IsNotModified(err) {
+		log.Err(err).
+			Int64("chat_id", c.EffectiveChat.Id).
+			Msg("Failed to edit settings menu")
+	}
+
+	_, err = c.CallbackQuery.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: answer})
+	return err
+}
+
+// recordChange adds changes of chat settings to the audit log, personal settings are nobody else's business.
+func (p *Plugin) recordChange(c plugin.GobotContext, plg plugin.Plugin, setting plugin.Setting, action string, value string) {
+	if setting.PerUser {
+		return
+	}
+
+	target := fmt.Sprintf("%s.%s", plg.Name(), setting.Key)
+	if value != "" {
+		target = fmt.Sprintf("%s = %s", target, value)
+	}
+	p.auditService.Record(c.EffectiveUser, c.EffectiveChat, action, target)
+}
+
+func answerAlert(b *gotgbot.Bot, c plugin.GobotContext, text string) error {
+	_, err := c.CallbackQuery.Answer(b, &gotgbot.AnswerCallbackQueryOpts{
+		Text:      text,
+		ShowAlert: true,
+	})
+	return err
+}
@@ -0,0 +1,60 @@
+package plugin
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSettingNormalize(t *testing.T) {
+	boolSetting := Setting{Type: SettingBool}
+	enumSetting := Setting{Type: SettingEnum, Options: []string{"OpenAI", "Gemini"}}
+	intSetting := Setting{Type: SettingInt, Min: 1, Max: 10}
+	unboundedIntSetting := Setting{Type: SettingInt}
+	stringSetting := Setting{Type: SettingString}
+	patternSetting := Setting{Type: SettingString, Pattern: regexp.MustCompile(`^[a-z]{2}$`)}
+
+	tests := []struct {
+		name    string
+		setting Setting
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"bool on", boolSetting, " An ", "true", false},
+		{"bool off", boolSetting, "nein", "false", false},
+		{"bool invalid", boolSetting, "vielleicht", "", true},
+		{"enum case-insensitive", enumSetting, "gemini", "Gemini", false},
+		{"enum invalid", enumSetting, "Claude", "", true},
+		{"int", intSetting, "05", "5", false},
+		{"int at bound", intSetting, "10", "10", false},
+		{"int out of bounds", intSetting, "11", "", true},
+		{"int without bounds", unboundedIntSetting, "-500", "-500", false},
+		{"int invalid", intSetting, "fünf", "", true},
+		{"string", stringSetting, "  Hallo  ", "Hallo", false},
+		{"string empty", stringSetting, "   ", "", true},
+		{"string max length", stringSetting, strings.Repeat("ä", maxSettingLength), strings.Repeat("ä", maxSettingLength), false},
+		{"string too long", stringSetting, strings.Repeat("ä", maxSettingLength+1), "", true},
+		{"pattern", patternSetting, "de", "de", false},
+		{"pattern mismatch", patternSetting, "deutsch", "", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.setting.Normalize(tc.value)
+			if tc.wantErr {
+				if _, ok := errors.AsType[*ValidationError](err); !ok {
+					t.Errorf("expected a ValidationError, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
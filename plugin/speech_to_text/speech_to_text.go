@@ -1,12 +1,15 @@
 package speech_to_text
 
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 
+	"github.com/Brawl345/gobot/llm"
 	"github.com/Brawl345/gobot/logger"
 	"github.com/Brawl345/gobot/model"
 	"github.com/Brawl345/gobot/plugin"
@@ -16,23 +19,34 @@ import (
 	"github.com/PaulSonOfLars/gotgbot/v2"
 )
 
-var log = logger.New("speech_to_text")
+var (
+	log = logger.New("speech_to_text")
+
+	ErrNotConfigured = errors.New("speech to text is not configured")
+	ErrTooLarge      = errors.New("file is too large or too long")
+)
 
 const (
-	ApiUrl       = "https://api.openai.com/v1/audio/transcriptions"
-	MaxVoiceSize = 25000000 // File uploads to Whisper are limited to 25 MB
-	MaxDuration  = 180      // 3 minutes
+	DefaultApiUrl = "https://api.openai.com/v1/audio/transcriptions"
+	DefaultModel  = "whisper-1"
+	MaxVoiceSize  = 25000000 // File uploads to Whisper are limited to 25 MB
+	MaxDuration   = 180      // 3 minutes
+
+	// Answers voice replies to its own answers in groups, see OnVoice
+	aiPluginName = "ai"
 )
 
 type (
 	Plugin struct {
 		credentialService model.CredentialService
+		managerService    model.ManagerService
 	}
 )
 
-func New(credentialService model.CredentialService) *Plugin {
+func New(credentialService model.CredentialService, managerService model.ManagerService) *Plugin {
 	return &Plugin{
 		credentialService: credentialService,
+		managerService:    managerService,
 	}
 }
 
@@ -44,6 +58,32 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	return nil
 }
 
+func (p *Plugin) Credentials() plugin.Credentials {
+	return plugin.Credentials{
+		AnyOf: [][]string{
+			{
+				"openai_api_key",
+				// Direct URL to an OpenAI-compatible transcription API, e.g. a whisper.cpp server
+				"speech_to_text_api_url",
+			},
+		},
+		Optional: []string{
+			// Used instead of openai_api_key, which is never sent to other servers
+			"speech_to_text_api_key",
+			"speech_to_text_model",
+			"speech_to_text_auth_header",
+		},
+	}
+}
+
+func (p *Plugin) Help() plugin.Help {
+	return plugin.Help{
+		Description: "Wandelt Sprachnachrichten in Text um. " +
+			"Antworten auf Nachrichten des Bots überlässt es in Gruppen dem Plugin \"ai\", wenn dieses dort aktiviert ist.",
+		PassiveTriggers: []string{"Sprachnachrichten"},
+	}
+}
+
 func (p *Plugin) Handlers(*gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
@@ -54,39 +94,82 @@ func (p *Plugin) Handlers(*gotgbot.User) []plugin.Handler {
 }
 
 func (p *Plugin) OnVoice(b *gotgbot.Bot, c plugin.GobotContext) error {
-	apiKey := p.credentialService.GetKey("openai_api_key")
-	if apiKey == "" {
-		log.Warn().Msg("openai_api_key not found")
+	if p.aiAnswersVoice(b, c) {
 		return nil
 	}
 
-	if c.EffectiveMessage.Voice.FileSize > tgUtils.MaxFilesizeDownload {
-		log.Warn().
-			Int64("filesize", c.EffectiveMessage.Voice.FileSize).
-			Msg("Voice file is too big to download")
+	voice := c.EffectiveMessage.Voice
+	text, err := p.Transcribe(b, voice.FileId, voice.MimeType, voice.FileSize, voice.Duration)
+	if errors.Is(err, ErrNotConfigured) || errors.Is(err, ErrTooLarge) {
+		log.Warn().Err(err).Msg("Can't transcribe voice message")
 		return nil
 	}
-
-	if c.EffectiveMessage.Voice.FileSize > MaxVoiceSize {
-		log.Warn().
-			Int64("filesize", c.EffectiveMessage.Voice.FileSize).
-			Msg(fmt.Sprintf("Voice file is bigger than %d bytes", MaxVoiceSize))
+	if err != nil {
+		log.Err(err).
+			Interface("file", voice).
+			Msg("Failed to transcribe voice message")
 		return nil
 	}
 
-	if c.EffectiveMessage.Voice.Duration > MaxDuration {
-		log.Warn().
-			Int64("duration", c.EffectiveMessage.Voice.Duration).
-			Msg(fmt.Sprintf("Voice message is longer than %d seconds", MaxDuration))
+	if len(text) == 0 {
+		log.Warn().Msg("Voice message contains no text")
 		return nil
 	}
 
-	file, err := httpUtils.DownloadFile(b, c.EffectiveMessage.Voice.FileId)
+	var sb strings.Builder
+
+	sb.WriteString("💬 ")
+	sb.WriteString(utils.TruncateText(text, tgUtils.MaxMessageLength-10, "..."))
+
+	_, err = c.EffectiveMessage.ReplyMessage(b, sb.String(), &gotgbot.SendMessageOpts{
+		ReplyParameters:     &gotgbot.ReplyParameters{AllowSendingWithoutReply: true},
+		LinkPreviewOptions:  &gotgbot.LinkPreviewOptions{IsDisabled: true},
+		DisableNotification: true,
+	})
+	return err
+}
+
+// aiAnswersVoice reports whether the AI plugin continues its conversation with the voice message,
+// it would be transcribed twice otherwise.
+func (p *Plugin) aiAnswersVoice(b *gotgbot.Bot, c plugin.GobotContext) bool {
+	reply := c.EffectiveMessage.ReplyToMessage
+	if !tgUtils.FromGroup(c.EffectiveMessage) || reply == nil || reply.From == nil || reply.From.Id != b.Id {
+		return false
+	}
+	if !p.managerService.IsPluginEnabled(aiPluginName) || p.managerService.IsPluginDisabledForChat(c.EffectiveChat, aiPluginName) {
+		return false
+	}
+	return c.ThreadID == 0 || !p.managerService.IsPluginDisabledForTopic(c.EffectiveChat, c.ThreadID, aiPluginName)
+}
+
+// Transcribe converts a voice message, audio or video file from Telegram to text.
+// It is also used by other plugins, e.g. to pass voice messages to the AI.
+func (p *Plugin) Transcribe(b *gotgbot.Bot, fileID, mimeType string, fileSize, duration int64) (string, error) {
+	apiUrl := p.credentialService.GetKey("speech_to_text_api_url")
+	apiKey := p.credentialService.GetKey("speech_to_text_api_key")
+	if apiUrl == "" {
+		apiUrl = DefaultApiUrl
+		apiKey = cmp.Or(apiKey, p.credentialService.GetKey("openai_api_key"))
+		if apiKey == "" {
+			return "", fmt.Errorf("%w: openai_api_key not found", ErrNotConfigured)
+		}
+	}
+
+	if !strings.HasPrefix(apiUrl, "http://") && !strings.HasPrefix(apiUrl, "https://") {
+		return "", fmt.Errorf("%w: speech_to_text_api_url is invalid", ErrNotConfigured)
+	}
+
+	if fileSize > tgUtils.MaxFilesizeDownload || fileSize > MaxVoiceSize {
+		return "", fmt.Errorf("%w: file has %d bytes", ErrTooLarge, fileSize)
+	}
+
+	if duration > MaxDuration {
+		return "", fmt.Errorf("%w: file is %d seconds long", ErrTooLarge, duration)
+	}
+
+	file, err := httpUtils.DownloadFile(b, fileID)
 	if err != nil {
-		log.Err(err).
-			Interface("file", c.EffectiveMessage.Voice).
-			Msg("Failed to download file")
-		return nil
+		return "", err
 	}
 
 	defer func(file io.ReadCloser) {
@@ -97,22 +180,26 @@ func (p *Plugin) OnVoice(b *gotgbot.Bot, c plugin.GobotContext) error {
 	}(file)
 
 	fileEnding := ".ogg"
-	switch c.EffectiveMessage.Voice.MimeType {
+	switch mimeType {
 	case "audio/mpeg":
 		fileEnding = ".mp3"
-	case "audio/mp4":
+	case "audio/mp4", "audio/x-m4a":
 		fileEnding = ".m4a"
+	case "audio/wav", "audio/x-wav":
+		fileEnding = ".wav"
+	case "video/mp4":
+		fileEnding = ".mp4"
+	case "video/webm", "audio/webm":
+		fileEnding = ".webm"
 	}
 
 	resp, err := httpUtils.MultiPartFormRequestWithHeaders(
-		ApiUrl,
-		map[string]string{
-			"Authorization": fmt.Sprintf("Bearer %s", apiKey),
-		},
+		apiUrl,
+		llm.AuthHeaders(p.credentialService.GetKey("speech_to_text_auth_header"), apiKey),
 		[]httpUtils.MultiPartParam{
 			{
 				Name:  "model",
-				Value: "whisper-1",
+				Value: cmp.Or(p.credentialService.GetKey("speech_to_text_model"), DefaultModel),
 			},
 		},
 		[]httpUtils.MultiPartFile{
@@ -125,8 +212,7 @@ func (p *Plugin) OnVoice(b *gotgbot.Bot, c plugin.GobotContext) error {
 	)
 
 	if err != nil {
-		log.Err(err).Msg("Failed to upload file")
-		return nil
+		return "", fmt.Errorf("failed to upload file: %w", err)
 	}
 
 	defer func(Body io.ReadCloser) {
@@ -140,42 +226,18 @@ func (p *Plugin) OnVoice(b *gotgbot.Bot, c plugin.GobotContext) error {
 		var errorResponse ApiErrorResponse
 		err = json.NewDecoder(resp.Body).Decode(&errorResponse)
 		if err != nil {
-			log.Error().
-				Int("status_code", resp.StatusCode).
-				Msg("Failed to upload file")
-			return nil
+			return "", &httpUtils.HttpError{StatusCode: resp.StatusCode}
 		}
-
-		log.Error().
-			Int("status_code", resp.StatusCode).
-			Str("error_message", errorResponse.Error.Message).
-			Str("error_type", errorResponse.Error.Type).
-			Msg("Failed to upload file")
-		return nil
+		return "", fmt.Errorf("transcription failed with status code %d: %s (%s)",
+			resp.StatusCode, errorResponse.Error.Message, errorResponse.Error.Type)
 	}
 
 	var apiResponse ApiResponse
 
 	err = json.NewDecoder(resp.Body).Decode(&apiResponse)
 	if err != nil {
-		log.Err(err).Msg("Failed to parse body")
-		return nil
-	}
-
-	if len(apiResponse.Text) == 0 {
-		log.Warn().Msg("Voice message contains no text")
-		return nil
+		return "", fmt.Errorf("failed to parse body: %w", err)
 	}
 
-	var sb strings.Builder
-
-	sb.WriteString("💬 ")
-	sb.WriteString(utils.TruncateText(apiResponse.Text, tgUtils.MaxMessageLength-10, "..."))
-
-	_, err = c.EffectiveMessage.ReplyMessage(b, sb.String(), &gotgbot.SendMessageOpts{
-		ReplyParameters:     &gotgbot.ReplyParameters{AllowSendingWithoutReply: true},
-		LinkPreviewOptions:  &gotgbot.LinkPreviewOptions{IsDisabled: true},
-		DisableNotification: true,
-	})
-	return err
+	return strings.TrimSpace(apiResponse.Text), nil
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/Brawl345/gobot/model"
 	"github.com/Brawl345/gobot/plugin"
 	"github.com/Brawl345/gobot/utils"
+	"github.com/Brawl345/gobot/utils/tgUtils"
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/rs/xid"
 )
@@ -17,11 +18,13 @@ var log = logger.New("stats")
 
 type Plugin struct {
 	chatsUsersService model.ChatsUsersService
+	callbackService   model.CallbackService
 }
 
-func New(chatsUsersService model.ChatsUsersService) *Plugin {
+func New(chatsUsersService model.ChatsUsersService, callbackService model.CallbackService) *Plugin {
 	return &Plugin{
 		chatsUsersService: chatsUsersService,
+		callbackService:   callbackService,
 	}
 }
 
@@ -104,6 +107,6 @@ func (p *Plugin) OnStats(b *gotgbot.Bot, c plugin.GobotContext) error {
 	}
 	sb.WriteString(fmt.Sprintf("<b>GESAMT:</b> %s", utils.FormatThousand(totalCount)))
 
-	_, err = c.EffectiveMessage.ReplyMessage(b, sb.String(), utils.DefaultSendOptions())
+	_, err = tgUtils.ReplyPaginated(b, c.EffectiveMessage, sb.String(), p.callbackService, nil, utils.DefaultSendOptions())
 	return err
 }
@@ -33,7 +33,7 @@ const (
 	MaxOutputTokens         = 1000
 	PresencePenalty         = 1.0
 	Temperature             = 0.3
-	SystemPrompt            = "Fasse den folgenden Artikel in drei bis fünf kurzen Stichpunkten zusammen. Antworte IMMER nur Deutsch. Formatiere deine Ausgabe wie folgt:\n" +
+	SystemPrompt            = "Fasse den folgenden Artikel in drei bis fünf kurzen Stichpunkten zusammen. Antworte IMMER nur Deutsch. Du kannst Markdown nutzen. Formatiere deine Ausgabe wie folgt:\n" +
 		"Der Artikel handelt von [Zusammenfassung in einem Satz]\n\n" +
 		"- [Stichpunkt 1]..."
 )
@@ -63,6 +63,13 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	}
 }
 
+func (p *Plugin) Credentials() plugin.Credentials {
+	return plugin.Credentials{
+		Required: []string{"summarize_api_key"},
+		Optional: []string{"summarize_api_url", "summarize_model", "summarize_ctx_window", "summarize_use_new_openai_models"},
+	}
+}
+
 func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
@@ -365,8 +372,8 @@ func (p *Plugin) summarize(b *gotgbot.Bot, c plugin.GobotContext, msg *gotgbot.M
 
 	var sb strings.Builder
 	sb.WriteString("<b>Zusammenfassung:</b>\n")
-	sb.WriteString(utils.Escape(response.Choices[0].Message.Content))
+	sb.WriteString(utils.MarkdownToHTML(response.Choices[0].Message.Content))
 
-	_, err = msg.ReplyMessage(b, sb.String(), utils.DefaultSendOptions())
+	_, err = tgUtils.ReplySplit(b, msg, sb.String(), utils.DefaultSendOptions())
 	return err
 }
@@ -44,19 +44,49 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	return nil
 }
 
+func (p *Plugin) Middlewares() []plugin.Middleware {
+	return []plugin.Middleware{
+		skipNsfw,
+		plugin.ChatAction(gotgbot.ChatActionTyping),
+	}
+}
+
+// skipNsfw ignores messages marked as NSFW so the bot doesn't post their media
+func skipNsfw(next plugin.GobotHandlerFunc) plugin.GobotHandlerFunc {
+	return func(b *gotgbot.Bot, c plugin.GobotContext) error {
+		if strings.Contains(strings.ToLower(c.EffectiveMessage.GetText()), "nsfw") {
+			return nil
+		}
+		return next(b, c)
+	}
+}
+
+func (p *Plugin) Help() plugin.Help {
+	return plugin.Help{
+		Description:     "Zeigt Tweets samt Medien direkt im Chat an.",
+		PassiveTriggers: []string{"Links zu Tweets auf x.com und twitter.com"},
+	}
+}
+
 func (p *Plugin) Handlers(*gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
-			Trigger:     regexp.MustCompile(`(?i)(?:x|twitter)\.com/\w+/status(?:es)?/(\d+)`),
-			HandlerFunc: p.OnStatus,
+			Trigger:            regexp.MustCompile(`(?i)(?:x|twitter)\.com/\w+/status(?:es)?/(\d+)`),
+			HandlerFunc:        p.OnStatus,
+			HandleEdits:        true,
+			HandleChannelPosts: true,
 		},
 		&plugin.CommandHandler{
-			Trigger:     regexp.MustCompile(`(?i)(?:x|twitter)\.com/i/web/status(?:es)?/(\d+)`),
-			HandlerFunc: p.OnStatus,
+			Trigger:            regexp.MustCompile(`(?i)(?:x|twitter)\.com/i/web/status(?:es)?/(\d+)`),
+			HandlerFunc:        p.OnStatus,
+			HandleEdits:        true,
+			HandleChannelPosts: true,
 		},
 		&plugin.CommandHandler{
-			Trigger:     regexp.MustCompile(`(?i)(?:x|twitter)\.com/status(?:es)?/(\d+)`),
-			HandlerFunc: p.OnStatus,
+			Trigger:            regexp.MustCompile(`(?i)(?:x|twitter)\.com/status(?:es)?/(\d+)`),
+			HandlerFunc:        p.OnStatus,
+			HandleEdits:        true,
+			HandleChannelPosts: true,
 		},
 	}
 }
@@ -91,13 +121,6 @@ func (p *Plugin) getToken() string {
 }
 
 func (p *Plugin) OnStatus(b *gotgbot.Bot, c plugin.GobotContext) error {
-	isNsfw := strings.Contains(strings.ToLower(c.EffectiveMessage.GetText()), "nsfw")
-	if isNsfw {
-		return nil
-	}
-
-	_, _ = c.EffectiveChat.SendAction(b, gotgbot.ChatActionTyping, nil)
-
 	guestToken := p.getToken()
 	if guestToken == "" {
 		err := p.renewToken()
@@ -38,6 +38,13 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	return nil
 }
 
+func (p *Plugin) Help() plugin.Help {
+	return plugin.Help{
+		Description:     "Lädt verlinkte Dateien herunter und sendet sie in den Chat.",
+		PassiveTriggers: []string{"Links zu Bildern, Videos, Audios und Archiven"},
+	}
+}
+
 func (p *Plugin) Handlers(*gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
@@ -35,17 +35,21 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	}
 }
 
+func (p *Plugin) Middlewares() []plugin.Middleware {
+	return []plugin.Middleware{plugin.ChatAction(gotgbot.ChatActionTyping)}
+}
+
 func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/ud(?:@%s)? (.+)$`, botInfo.Username)),
 			HandlerFunc: onUrbanDictionary,
+			HandleEdits: true,
 		},
 	}
 }
 
 func onUrbanDictionary(b *gotgbot.Bot, c plugin.GobotContext) error {
-	_, _ = c.EffectiveChat.SendAction(b, gotgbot.ChatActionTyping, nil)
 	query := c.Matches[1]
 
 	var response Response
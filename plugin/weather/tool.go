@@ -0,0 +1,108 @@
+package weather
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Brawl345/gobot/llm"
+	"github.com/Brawl345/gobot/model"
+	"github.com/Brawl345/gobot/plugin"
+	"github.com/Brawl345/gobot/utils/httpUtils"
+	"github.com/Brawl345/gobot/utils/tgUtils"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+// WeatherTool looks up the weather, the home location is the one of the user who asked.
+type WeatherTool struct {
+	geocodingService model.GeocodingService
+	homeService      model.HomeService
+	user             *gotgbot.User
+}
+
+func (p *Plugin) Tools(_ *gotgbot.Bot, c plugin.GobotContext) []llm.Tool {
+	return []llm.Tool{&WeatherTool{
+		geocodingService: p.geocodingService,
+		homeService:      p.homeService,
+		user:             c.EffectiveUser,
+	}}
+}
+
+func (t *WeatherTool) Definition() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Name:        "weather",
+		Description: "Ruft das aktuelle Wetter und die Vorhersage für die nächsten Tage ab.",
+		Parameters: llm.Parameters{
+			Type: "object",
+			Properties: map[string]llm.Property{
+				"location": {
+					Type:        "string",
+					Description: "Ort, z.B. \"Berlin\". Leer lassen für den Heimatort des fragenden Nutzers.",
+				},
+			},
+			Required: []string{"location"},
+		},
+		Strict: true,
+	}
+}
+
+func (t *WeatherTool) Execute(arguments string) (llm.ToolOutput, error) {
+	var args struct {
+		Location string `json:"location"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return llm.ToolOutput{}, fmt.Errorf("invalid arguments: %w", err)
+	}
+	log.Debug().Str("location", args.Location).Msg("weather tool call")
+
+	var err error
+	var venue gotgbot.Venue
+	if strings.TrimSpace(args.Location) != "" {
+		venue, err = t.geocodingService.Geocode(args.Location)
+	} else {
+		venue, err = t.homeService.GetHome(t.user)
+	}
+	if err != nil {
+		if errors.Is(err, model.ErrHomeAddressNotSet) {
+			return llm.ToolOutput{Text: "Der Nutzer hat keinen Heimatort gesetzt (geht mit /home ORT)."}, nil
+		}
+		if errors.Is(err, model.ErrAddressNotFound) {
+			return llm.ToolOutput{Text: "Ort nicht gefunden."}, nil
+		}
+		return llm.ToolOutput{}, err
+	}
+
+	requestUrl := fmt.Sprintf("https://api.open-meteo.com/v1/forecast?latitude=%f&longitude=%f&daily=weathercode,temperature_2m_max,temperature_2m_min&current_weather=true&timezone=auto", venue.Location.Latitude, venue.Location.Longitude)
+
+	var response Response
+	err = httpUtils.MakeRequest(httpUtils.RequestOptions{
+		Method:   httpUtils.MethodGet,
+		URL:      requestUrl,
+		Response: &response,
+	})
+	if err != nil {
+		return llm.ToolOutput{}, err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Wetter in %s\n", venue.Address))
+	sb.WriteString(fmt.Sprintf("Jetzt: %s, %s\n",
+		response.CurrentWeather.Temperature.String(),
+		response.CurrentWeather.Weathercode.Description(),
+	))
+	for day := range response.Daily.Time {
+		forecast, err := response.Daily.Forecast(day)
+		if err != nil {
+			continue
+		}
+		sb.WriteString(tgUtils.StripHTML(forecast))
+		sb.WriteString("\n")
+	}
+
+	return llm.ToolOutput{Text: sb.String()}, nil
+}
+
+func (t *WeatherTool) Emoji() string {
+	return "🌡"
+}
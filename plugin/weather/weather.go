@@ -56,26 +56,32 @@ func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/w(?:@%s)? (.+)$`, botInfo.Username)),
 			HandlerFunc: p.onWeather,
+			HandleEdits: true,
 		},
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/w(?:@%s)?$`, botInfo.Username)),
 			HandlerFunc: p.onWeather,
+			HandleEdits: true,
 		},
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/f(?:@%s)?$`, botInfo.Username)),
 			HandlerFunc: p.onForecast,
+			HandleEdits: true,
 		},
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/f(?:@%s)? (.+)$`, botInfo.Username)),
 			HandlerFunc: p.onForecast,
+			HandleEdits: true,
 		},
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/fh(?:@%s)?$`, botInfo.Username)),
 			HandlerFunc: p.onHourlyForecast,
+			HandleEdits: true,
 		},
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/fh(?:@%s)? (.+)$`, botInfo.Username)),
 			HandlerFunc: p.onHourlyForecast,
+			HandleEdits: true,
 		},
 	}
 }
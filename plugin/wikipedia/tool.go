@@ -0,0 +1,115 @@
+package wikipedia
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/Brawl345/gobot/llm"
+	"github.com/Brawl345/gobot/model"
+	"github.com/Brawl345/gobot/plugin"
+	"github.com/Brawl345/gobot/utils"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+const maxToolTextLength = 8000
+
+type WikipediaTool struct {
+	lang     string
+	mu       sync.Mutex
+	articles []llm.Source
+}
+
+func (p *Plugin) Tools(_ *gotgbot.Bot, c plugin.GobotContext) []llm.Tool {
+	lang := p.settingsService.Get(model.ScopeOf(settingLanguage, c.EffectiveChat, c.EffectiveUser), p.Name(), settingLanguage)
+	return []llm.Tool{&WikipediaTool{lang: lang}}
+}
+
+func (t *WikipediaTool) Definition() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Name:        "wikipedia",
+		Description: "Schlägt einen Artikel in der Wikipedia nach und gibt dessen Einleitung zurück.",
+		Parameters: llm.Parameters{
+			Type: "object",
+			Properties: map[string]llm.Property{
+				"title": {
+					Type:        "string",
+					Description: "Titel des Artikels, z.B. \"Telegram (Messenger)\"",
+				},
+				"lang": {
+					Type:        "string",
+					Description: fmt.Sprintf("Sprachversion, z.B. \"en\". Leer lassen für die Standardsprache (%s).", t.lang),
+				},
+			},
+			Required: []string{"title", "lang"},
+		},
+		Strict: true,
+	}
+}
+
+func (t *WikipediaTool) Execute(arguments string) (llm.ToolOutput, error) {
+	var args struct {
+		Title string `json:"title"`
+		Lang  string `json:"lang"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return llm.ToolOutput{}, fmt.Errorf("invalid arguments: %w", err)
+	}
+	lang := strings.ToLower(strings.TrimSpace(args.Lang))
+	if lang == "" {
+		lang = t.lang
+	}
+	if _, err := settingLanguage.Normalize(lang); err != nil {
+		return llm.ToolOutput{Text: "Ungültige Sprachversion."}, nil
+	}
+	log.Debug().Str("title", args.Title).Str("lang", lang).Msg("wikipedia tool call")
+
+	response, err := fetchArticle(lang, args.Title, true, true)
+	if err != nil {
+		if _, ok := errors.AsType[*net.DNSError](err); ok {
+			return llm.ToolOutput{Text: "Diese Wikipedia-Sprachversion existiert nicht."}, nil
+		}
+		return llm.ToolOutput{}, err
+	}
+	if len(response.Query.Pages) == 0 || response.Query.Pages[0].Missing || response.Query.Pages[0].Invalid {
+		return llm.ToolOutput{Text: "Artikel nicht gefunden."}, nil
+	}
+
+	article := response.Query.Pages[0]
+	t.mu.Lock()
+	t.articles = append(t.articles, llm.Source{Title: article.Title, URL: article.URL})
+	t.mu.Unlock()
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s (%s)\n\n", article.Title, article.URL))
+
+	if article.Pageprops.Disambiguation {
+		disambResponse, err := fetchArticle(lang, article.Title, false, false)
+		if err != nil {
+			return llm.ToolOutput{}, err
+		}
+		sb.WriteString("Dies ist eine Begriffsklärungsseite. Mögliche Artikel:\n")
+		if len(disambResponse.Query.Pages) > 0 {
+			for _, match := range regexDisambiguation.FindAllStringSubmatch(disambResponse.Query.Pages[0].Text, -1) {
+				sb.WriteString(fmt.Sprintf("- %s\n", strings.TrimSpace(regexHTML.ReplaceAllString(match[1], ""))))
+			}
+		}
+		return llm.ToolOutput{Text: utils.TruncateText(sb.String(), maxToolTextLength, "...")}, nil
+	}
+
+	sb.WriteString(strings.TrimSpace(article.Text))
+	return llm.ToolOutput{Text: utils.TruncateText(sb.String(), maxToolTextLength, "...")}, nil
+}
+
+func (t *WikipediaTool) Emoji() string {
+	return "📖"
+}
+
+func (t *WikipediaTool) Sources() []llm.Source {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]llm.Source(nil), t.articles...)
+}
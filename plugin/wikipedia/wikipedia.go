@@ -7,26 +7,53 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/Brawl345/gobot/logger"
+	"github.com/Brawl345/gobot/model"
 	"github.com/Brawl345/gobot/plugin"
 	"github.com/Brawl345/gobot/utils"
 	"github.com/Brawl345/gobot/utils/httpUtils"
+	"github.com/Brawl345/gobot/utils/tgUtils"
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/rs/xid"
 )
 
 var log = logger.New("wikipedia")
 
+var settingLanguage = plugin.Setting{
+	Key:         "lang",
+	Name:        "Sprache",
+	Description: "Sprachversion, die /wiki durchsucht, z.B. \"en\" für Englisch",
+	Type:        plugin.SettingString,
+	Default:     "de",
+	Pattern:     regexp.MustCompile(`^[a-z][a-z-]{1,11}$`),
+}
+
 const (
 	maxNumDisambiguationList = 5
+	disambiguationButtonTTL  = 24 * time.Hour
 	userAgent                = "Gobot/1.0 (Telegram Bot; +https://github.com/Brawl345/gobot)"
 )
 
-type Plugin struct{}
+type (
+	Plugin struct {
+		callbackService model.CallbackService
+		settingsService model.SettingsService
+	}
 
-func New() *Plugin {
-	return &Plugin{}
+	// articlePayload is stored for the buttons of disambiguation pages
+	articlePayload struct {
+		Lang  string `json:"lang"`
+		Title string `json:"title"`
+	}
+)
+
+func New(callbackService model.CallbackService, settingsService model.SettingsService) *Plugin {
+	return &Plugin{
+		callbackService: callbackService,
+		settingsService: settingsService,
+	}
 }
 
 func (p *Plugin) Name() string {
@@ -46,19 +73,40 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	}
 }
 
+func (p *Plugin) Help() plugin.Help {
+	return plugin.Help{
+		Description: "Schlägt Artikel in der Wikipedia nach. Andere Sprachen gehen mit /wiki_<Sprachcode>, " +
+			"die Standardsprache kann mit /settings wikipedia geändert werden.",
+		Examples:        []string{"/wiki Telegram", "/wiki_fr Paris"},
+		PassiveTriggers: []string{"Links zu Wikipedia-Artikeln"},
+	}
+}
+
+func (p *Plugin) Settings() []plugin.Setting {
+	return []plugin.Setting{settingLanguage}
+}
+
 func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/wiki(?:@%s)? (?P<query>.+)$`, botInfo.Username)),
-			HandlerFunc: onArticle,
+			HandlerFunc: p.onArticle,
+			HandleEdits: true,
 		},
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/wiki_(?P<lang>\w+)(?:@%s)? (?P<query>.+)$`, botInfo.Username)),
-			HandlerFunc: onArticle,
+			HandlerFunc: p.onArticle,
+			HandleEdits: true,
 		},
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(`(?i)https?://(?P<lang>\w+).(?:m.)?wikipedia.org/wiki/(?P<query>[^\s#]+)(?:#(?P<section>\S+))?`),
-			HandlerFunc: onArticle,
+			HandlerFunc: p.onArticle,
+			HandleEdits: true,
+		},
+		&plugin.CallbackHandler{
+			Trigger:     regexp.MustCompile(`^wiki:(\w+)$`),
+			HandlerFunc: p.onDisambiguationCallback,
+			Cooldown:    time.Second,
 		},
 	}
 }
@@ -102,11 +150,11 @@ func fetchArticle(lang, titles string, exintro, explaintext bool) (*Response, er
 	return &response, nil
 }
 
-func onArticle(b *gotgbot.Bot, c plugin.GobotContext) error {
+func (p *Plugin) onArticle(b *gotgbot.Bot, c plugin.GobotContext) error {
 	query := c.NamedMatches["query"]
 	lang := c.NamedMatches["lang"]
 	if lang == "" {
-		lang = "de"
+		lang = p.settingsService.Get(model.ScopeOf(settingLanguage, c.EffectiveChat, c.EffectiveUser), p.Name(), settingLanguage)
 	}
 	section := c.NamedMatches["section"]
 
@@ -190,6 +238,8 @@ func onArticle(b *gotgbot.Bot, c plugin.GobotContext) error {
 
 		matches := regexDisambiguation.FindAllStringSubmatch(disambResponse.Query.Pages[0].Text, -1)
 		sb.WriteString("<i>Dies ist eine Begriffsklärungsseite.</i>\n")
+
+		var buttons []gotgbot.InlineKeyboardButton
 		if len(matches) > 0 {
 			sb.WriteString("\n<b>Meintest du:</b>\n")
 			for i, match := range matches {
@@ -199,25 +249,37 @@ func onArticle(b *gotgbot.Bot, c plugin.GobotContext) error {
 				articleTitle := strings.TrimSpace(match[1])
 				articleTitle = regexHTML.ReplaceAllString(articleTitle, "")
 				sb.WriteString(fmt.Sprintf("* %s\n", utils.Escape(articleTitle)))
+
+				button, err := tgUtils.TokenButton(
+					p.callbackService,
+					utils.TruncateText(articleTitle, 40),
+					"wiki",
+					articlePayload{Lang: lang, Title: articleTitle},
+					c.EffectiveUser,
+					disambiguationButtonTTL,
+				)
+				if err != nil {
+					log.Err(err).
+						Str("title", articleTitle).
+						Msg("Failed to create button for disambiguation")
+					continue
+				}
+				buttons = append(buttons, button)
 			}
 		}
 
+		buttons = append(buttons, gotgbot.InlineKeyboardButton{
+			Text:  "...weitere?",
+			Url:   article.URL,
+			Style: gotgbot.KeyboardButtonStylePrimary,
+		})
+
 		_, err = c.EffectiveMessage.ReplyMessage(b, sb.String(), &gotgbot.SendMessageOpts{
 			ReplyParameters:     &gotgbot.ReplyParameters{AllowSendingWithoutReply: true},
 			LinkPreviewOptions:  &gotgbot.LinkPreviewOptions{IsDisabled: true},
 			DisableNotification: true,
 			ParseMode:           gotgbot.ParseModeHTML,
-			ReplyMarkup: &gotgbot.InlineKeyboardMarkup{
-				InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
-					{
-						{
-							Text:  "...weitere?",
-							Url:   article.URL,
-							Style: gotgbot.KeyboardButtonStylePrimary,
-						},
-					},
-				},
-			},
+			ReplyMarkup:         tgUtils.InlineKeyboard(1, buttons...),
 		})
 		return err
 	}
@@ -267,3 +329,42 @@ func onArticle(b *gotgbot.Bot, c plugin.GobotContext) error {
 	_, err = c.EffectiveMessage.ReplyMessage(b, sb.String(), utils.DefaultSendOptions())
 	return err
 }
+
+func (p *Plugin) onDisambiguationCallback(b *gotgbot.Bot, c plugin.GobotContext) error {
+	var payload articlePayload
+	err := p.callbackService.Get(tgUtils.CallbackToken(c.CallbackQuery.Data), c.EffectiveUser, &payload)
+	if err != nil {
+		var text string
+		switch {
+		case errors.Is(err, model.ErrNotFound):
+			text = "❌ Dieser Button ist abgelaufen."
+		case errors.Is(err, model.ErrNotOwner):
+			text = "❌ Nur wer gesucht hat, kann diesen Button nutzen."
+		default:
+			log.Err(err).
+				Str("data", c.CallbackQuery.Data).
+				Msg("Failed to get disambiguation payload")
+			text = "❌ Es ist ein Fehler aufgetreten."
+		}
+		_, err := c.CallbackQuery.Answer(b, &gotgbot.AnswerCallbackQueryOpts{
+			Text:      text,
+			ShowAlert: true,
+		})
+		return err
+	}
+
+	_, err = c.CallbackQuery.Answer(b, nil)
+	if err != nil {
+		return err
+	}
+
+	if c.EffectiveMessage == nil {
+		return nil
+	}
+
+	c.NamedMatches = map[string]string{
+		"lang":  payload.Lang,
+		"query": payload.Title,
+	}
+	return p.onArticle(b, c)
+}
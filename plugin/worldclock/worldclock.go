@@ -43,15 +43,23 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	}
 }
 
+func (p *Plugin) Credentials() plugin.Credentials {
+	return plugin.Credentials{
+		Required: []string{"timezonedb_api_key"},
+	}
+}
+
 func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 	return []plugin.Handler{
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/time?(?:@%s)?$`, botInfo.Username)),
 			HandlerFunc: p.onTime,
+			HandleEdits: true,
 		},
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/time?(?:@%s)? (.+)$`, botInfo.Username)),
 			HandlerFunc: p.onTime,
+			HandleEdits: true,
 		},
 	}
 }
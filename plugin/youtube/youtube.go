@@ -42,24 +42,45 @@ func (p *Plugin) Commands() []gotgbot.BotCommand {
 	}
 }
 
+func (p *Plugin) Credentials() plugin.Credentials {
+	return plugin.Credentials{
+		Required: []string{"google_api_key"},
+	}
+}
+
+func (p *Plugin) Help() plugin.Help {
+	return plugin.Help{
+		Description:     "Sucht Videos auf YouTube und zeigt Infos zu verlinkten Videos an.",
+		Examples:        []string{"/yt Never Gonna Give You Up"},
+		PassiveTriggers: []string{"Links zu YouTube-Videos"},
+	}
+}
+
 func (p *Plugin) Handlers(botInfo *gotgbot.User) []plugin.Handler {
 	// For videoId see https://webapps.stackexchange.com/a/101153
 	return []plugin.Handler{
 		&plugin.CommandHandler{
-			Trigger:     regexp.MustCompile(`(?i)youtube\.com/watch(?:\?|\?.+&)?v=([\dA-Za-z_-]{10}[048AEIMQUYcgkosw])`),
-			HandlerFunc: p.OnYouTubeLink,
+			Trigger:            regexp.MustCompile(`(?i)youtube\.com/watch(?:\?|\?.+&)?v=([\dA-Za-z_-]{10}[048AEIMQUYcgkosw])`),
+			HandlerFunc:        p.OnYouTubeLink,
+			HandleEdits:        true,
+			HandleChannelPosts: true,
 		},
 		&plugin.CommandHandler{
-			Trigger:     regexp.MustCompile(`(?i)youtube\.com/(?:embed|shorts|live)/([\dA-Za-z_-]{10}[048AEIMQUYcgkosw])`),
-			HandlerFunc: p.OnYouTubeLink,
+			Trigger:            regexp.MustCompile(`(?i)youtube\.com/(?:embed|shorts|live)/([\dA-Za-z_-]{10}[048AEIMQUYcgkosw])`),
+			HandlerFunc:        p.OnYouTubeLink,
+			HandleEdits:        true,
+			HandleChannelPosts: true,
 		},
 		&plugin.CommandHandler{
-			Trigger:     regexp.MustCompile(`(?i)youtu\.be/([\dA-Za-z_-]{10}[048AEIMQUYcgkosw])`),
-			HandlerFunc: p.OnYouTubeLink,
+			Trigger:            regexp.MustCompile(`(?i)youtu\.be/([\dA-Za-z_-]{10}[048AEIMQUYcgkosw])`),
+			HandlerFunc:        p.OnYouTubeLink,
+			HandleEdits:        true,
+			HandleChannelPosts: true,
 		},
 		&plugin.CommandHandler{
 			Trigger:     regexp.MustCompile(fmt.Sprintf(`(?i)^/yt(?:@%s)? (.+)$`, botInfo.Username)),
 			HandlerFunc: p.onYouTubeSearch,
+			HandleEdits: true,
 		},
 	}
 }
@@ -9,6 +9,7 @@ import (
 	"mime/multipart"
 	"net"
 	"net/http"
+	"net/textproto"
 	"net/url"
 	"regexp"
 	"strings"
@@ -99,9 +100,10 @@ type (
 	}
 
 	MultiPartFile struct {
-		FieldName string
-		FileName  string
-		Content   io.Reader
+		FieldName   string
+		FileName    string
+		ContentType string // Optional, defaults to application/octet-stream
+		Content     io.Reader
 	}
 )
 
@@ -338,6 +340,10 @@ func MultiPartFormRequest(url string, params []MultiPartParam, files []MultiPart
 }
 
 func MultiPartFormRequestWithHeaders(url string, headers map[string]string, params []MultiPartParam, files []MultiPartFile) (*http.Response, error) {
+	return MultiPartFormRequestWithClient(DefaultHttpClient, url, headers, params, files)
+}
+
+func MultiPartFormRequestWithClient(client *http.Client, url string, headers map[string]string, params []MultiPartParam, files []MultiPartFile) (*http.Response, error) {
 	log.Debug().
 		Str("url", RedactURL(url)).
 		Interface("params", params).
@@ -361,7 +367,16 @@ func MultiPartFormRequestWithHeaders(url string, headers map[string]string, para
 	}
 
 	for _, file := range files {
-		fw, err := writer.CreateFormFile(file.FieldName, file.FileName)
+		var fw io.Writer
+		var err error
+		if file.ContentType == "" {
+			fw, err = writer.CreateFormFile(file.FieldName, file.FileName)
+		} else {
+			header := make(textproto.MIMEHeader)
+			header.Set("Content-Disposition", multipart.FileContentDisposition(file.FieldName, file.FileName))
+			header.Set("Content-Type", file.ContentType)
+			fw, err = writer.CreatePart(header)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -386,7 +401,7 @@ func MultiPartFormRequestWithHeaders(url string, headers map[string]string, para
 		req.Header.Set(key, value)
 	}
 
-	return DefaultHttpClient.Do(req)
+	return client.Do(req)
 }
 
 func DownloadFile(b *gotgbot.Bot, fileID string) (io.ReadCloser, error) {
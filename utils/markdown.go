@@ -0,0 +1,316 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Unlike Escape, this also escapes ampersands so entities written by the model are shown as they are
+var markdownEscaper = strings.NewReplacer(
+	`&`, "&amp;",
+	`<`, "&lt;",
+	`>`, "&gt;",
+	`"`, "&quot;",
+)
+
+var (
+	regexFence       = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})\\s*([\\w+#.-]*)")
+	regexHeading     = regexp.MustCompile(`^ {0,3}#{1,6}\s+(.*?)(?:\s+#+)?\s*$`)
+	regexRule        = regexp.MustCompile(`^ {0,3}(?:(?:\*\s*){3,}|(?:-\s*){3,}|(?:_\s*){3,})$`)
+	regexQuote       = regexp.MustCompile(`^ {0,3}> ?(.*)$`)
+	regexBulletItem  = regexp.MustCompile(`^(\s*)[-*+]\s+(?:\[([ xX])]\s+)?(.*)$`)
+	regexOrderedItem = regexp.MustCompile(`^(\s*)(\d{1,9}[.)])\s+(.*)$`)
+	regexTableRow    = regexp.MustCompile(`^\s*\|.*\|\s*$`)
+	regexAutolink    = regexp.MustCompile(`^<((?:https?|tg|mailto):[^\s<>]+)>`)
+	regexLinkScheme  = regexp.MustCompile(`^(?i)(?:https?|tg|mailto):`)
+)
+
+// MarkdownToHTML converts Markdown as written by LLMs to the subset of HTML Telegram supports.
+// Headings become bold, lists get bullets and tables are shown preformatted, since Telegram has none of them.
+// The result always has balanced tags and can be split with tgUtils.SplitHTML.
+func MarkdownToHTML(markdown string) string {
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+	var out []string
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if match := regexFence.FindStringSubmatch(line); match != nil {
+			fence := match[1]
+			var code []string
+			for i++; i < len(lines); i++ {
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+					break
+				}
+				code = append(code, lines[i])
+			}
+			out = append(out, preBlock(strings.Join(code, "\n"), match[2]))
+			continue
+		}
+
+		if regexTableRow.MatchString(line) {
+			rows := []string{line}
+			for i+1 < len(lines) && regexTableRow.MatchString(lines[i+1]) {
+				i++
+				rows = append(rows, lines[i])
+			}
+			out = append(out, preBlock(strings.Join(rows, "\n"), ""))
+			continue
+		}
+
+		if regexQuote.MatchString(line) {
+			var quote []string
+			for ; i < len(lines); i++ {
+				match := regexQuote.FindStringSubmatch(lines[i])
+				if match == nil {
+					break
+				}
+				quote = append(quote, match[1])
+			}
+			i--
+			out = append(out, "<blockquote>"+MarkdownToHTML(strings.Join(quote, "\n"))+"</blockquote>")
+			continue
+		}
+
+		switch {
+		case regexRule.MatchString(line):
+			out = append(out, "———")
+		case regexHeading.MatchString(line):
+			out = append(out, "<b>"+markdownInline(regexHeading.FindStringSubmatch(line)[1])+"</b>")
+		case regexBulletItem.MatchString(line):
+			match := regexBulletItem.FindStringSubmatch(line)
+			bullet := "•"
+			if len(match[1])/2%2 == 1 {
+				bullet = "◦"
+			}
+			switch match[2] {
+			case " ":
+				bullet = "☐"
+			case "x", "X":
+				bullet = "☑"
+			}
+			out = append(out, match[1]+bullet+" "+markdownInline(match[3]))
+		case regexOrderedItem.MatchString(line):
+			match := regexOrderedItem.FindStringSubmatch(line)
+			out = append(out, match[1]+match[2]+" "+markdownInline(match[3]))
+		default:
+			out = append(out, markdownInline(line))
+		}
+	}
+
+	return strings.Join(out, "\n")
+}
+
+func preBlock(code string, language string) string {
+	if language == "" {
+		return "<pre>" + markdownEscaper.Replace(code) + "</pre>"
+	}
+	return fmt.Sprintf(
+		"<pre><code class=\"language-%s\">%s</code></pre>",
+		markdownEscaper.Replace(language),
+		markdownEscaper.Replace(code),
+	)
+}
+
+// markdownInline converts the inline formatting of a single line.
+func markdownInline(s string) string {
+	var sb strings.Builder
+
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]):
+			sb.WriteString(markdownEscaper.Replace(s[i+1 : i+2]))
+			i += 2
+			continue
+
+		case c == '`':
+			run := delimiterRun(s, i, '`')
+			if end := strings.Index(s[i+run:], strings.Repeat("`", run)); end != -1 {
+				code := strings.TrimSpace(s[i+run : i+run+end])
+				sb.WriteString("<code>" + markdownEscaper.Replace(code) + "</code>")
+				i += run + end + run
+				continue
+			}
+			sb.WriteString(s[i : i+run])
+			i += run
+			continue
+
+		case c == '<':
+			if match := regexAutolink.FindStringSubmatch(s[i:]); match != nil {
+				url := markdownEscaper.Replace(match[1])
+				sb.WriteString(fmt.Sprintf("<a href=\"%s\">%s</a>", url, url))
+				i += len(match[0])
+				continue
+			}
+
+		case c == '[' || (c == '!' && strings.HasPrefix(s[i+1:], "[")):
+			start := i
+			if c == '!' {
+				start++
+			}
+			if text, url, end, ok := parseLink(s, start); ok {
+				if regexLinkScheme.MatchString(url) {
+					sb.WriteString(fmt.Sprintf("<a href=\"%s\">%s</a>", markdownEscaper.Replace(url), markdownInline(text)))
+				} else {
+					sb.WriteString(markdownInline(text))
+				}
+				i = end
+				continue
+			}
+
+		case c == '*' || c == '_' || c == '~' || c == '|':
+			if tag, delimiter, inner, ok := emphasis(s, i); ok {
+				sb.WriteString("<" + tag + ">" + markdownInline(inner) + "</" + tag + ">")
+				i += len(delimiter) + len(inner) + len(delimiter)
+				continue
+			}
+			run := delimiterRun(s, i, c)
+			sb.WriteString(markdownEscaper.Replace(s[i : i+run]))
+			i += run
+			continue
+		}
+
+		_, size := utf8.DecodeRuneInString(s[i:])
+		sb.WriteString(markdownEscaper.Replace(s[i : i+size]))
+		i += size
+	}
+
+	return sb.String()
+}
+
+// emphasis checks whether a bold, italic, strikethrough or spoiler span starts at i.
+func emphasis(s string, i int) (tag string, delimiter string, inner string, ok bool) {
+	c := s[i]
+	run := delimiterRun(s, i, c)
+
+	switch {
+	case (c == '*' || c == '_') && run >= 2:
+		tag, delimiter = "b", s[i:i+2]
+	case c == '*' || c == '_':
+		tag, delimiter = "i", s[i:i+1]
+	case c == '~' && run >= 2:
+		tag, delimiter = "s", "~~"
+	case c == '|' && run >= 2:
+		tag, delimiter = "tg-spoiler", "||"
+	default:
+		return "", "", "", false
+	}
+
+	// Underscores inside words are part of the word, e.g. in snake_case
+	if c == '_' && i > 0 && isWordChar(s[:i], true) {
+		return "", "", "", false
+	}
+
+	start := i + len(delimiter)
+	if start >= len(s) || s[start] == ' ' {
+		return "", "", "", false
+	}
+
+	end := closingDelimiter(s, start, delimiter)
+	if end == -1 {
+		return "", "", "", false
+	}
+	return tag, delimiter, s[start:end], true
+}
+
+// closingDelimiter finds the delimiter that closes a span opened before start, skipping code spans.
+func closingDelimiter(s string, start int, delimiter string) int {
+	for j := start; j < len(s); {
+		switch {
+		case s[j] == '\\':
+			j += 2
+			continue
+		case s[j] == '`':
+			run := delimiterRun(s, j, '`')
+			if end := strings.Index(s[j+run:], strings.Repeat("`", run)); end != -1 {
+				j += run + end + run
+				continue
+			}
+		case strings.HasPrefix(s[j:], delimiter):
+			run := delimiterRun(s, j, delimiter[0])
+			// A single delimiter must not close at a double one, that's a nested bold span
+			if len(delimiter) == 1 && run >= 2 {
+				j += run
+				continue
+			}
+			afterSpace := s[j-1] == ' '
+			beforeWord := delimiter[0] == '_' && isWordChar(s[j+len(delimiter):], false)
+			if j > start && !afterSpace && !beforeWord {
+				// In "***text***", the bold span closes at the end of the run
+				return j + run - len(delimiter)
+			}
+			j += run
+			continue
+		}
+		j++
+	}
+	return -1
+}
+
+// parseLink parses [text](url) at start and returns the index after it.
+func parseLink(s string, start int) (text string, url string, end int, ok bool) {
+	depth := 0
+	closing := -1
+	for j := start; j < len(s) && closing == -1; j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closing = j
+			}
+		}
+	}
+	if closing == -1 || closing+1 >= len(s) || s[closing+1] != '(' {
+		return "", "", 0, false
+	}
+
+	depth = 0
+	for j := closing + 1; j < len(s); j++ {
+		switch s[j] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				destination := strings.TrimSpace(s[closing+2 : j])
+				// Drop the optional title: [text](url "title")
+				if k := strings.IndexAny(destination, " \t"); k != -1 {
+					destination = destination[:k]
+				}
+				destination = strings.TrimSuffix(strings.TrimPrefix(destination, "<"), ">")
+				return s[start+1 : closing], destination, j + 1, true
+			}
+		}
+	}
+	return "", "", 0, false
+}
+
+func delimiterRun(s string, i int, c byte) int {
+	run := 0
+	for i+run < len(s) && s[i+run] == c {
+		run++
+	}
+	return run
+}
+
+// isWordChar reports whether the last (or first) character of s is a letter or digit.
+func isWordChar(s string, last bool) bool {
+	var r rune
+	if last {
+		r, _ = utf8.DecodeLastRuneInString(s)
+	} else {
+		r, _ = utf8.DecodeRuneInString(s)
+	}
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+func isASCIIPunct(c byte) bool {
+	return c < utf8.RuneSelf && unicode.IsPunct(rune(c)) || strings.IndexByte("$+<=>^`|~", c) != -1
+}
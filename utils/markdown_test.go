@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestMarkdownToHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{"plain text", "Hallo Welt", "Hallo Welt"},
+		{"escapes html", `a < b && c > "d"`, "a &lt; b &amp;&amp; c &gt; &quot;d&quot;"},
+		{"bold", "**fett** und __auch__", "<b>fett</b> und <b>auch</b>"},
+		{"italic", "*kursiv* und _auch_", "<i>kursiv</i> und <i>auch</i>"},
+		{"strikethrough and spoiler", "~~weg~~ ||geheim||", "<s>weg</s> <tg-spoiler>geheim</tg-spoiler>"},
+		{"italic inside bold", "**fett *kursiv* fett**", "<b>fett <i>kursiv</i> fett</b>"},
+		{"bold inside italic", "*kursiv **fett** kursiv*", "<i>kursiv <b>fett</b> kursiv</i>"},
+		{"bold italic", "***beides***", "<b><i>beides</i></b>"},
+		{"snake case", "eine_variable_hier", "eine_variable_hier"},
+		{"unterminated bold", "**offen", "**offen"},
+		{"unterminated italic", "*offen und **fett**", "*offen und <b>fett</b>"},
+		{"unterminated code", "`offen", "`offen"},
+		{"lonely asterisk", "2 * 3 = 6", "2 * 3 = 6"},
+		{"escaped markers", `\*nicht kursiv\*`, "*nicht kursiv*"},
+		{"inline code", "`<b>&amp;</b>`", "<code>&lt;b&gt;&amp;amp;&lt;/b&gt;</code>"},
+		{"markers in inline code", "`**nicht fett**`", "<code>**nicht fett**</code>"},
+		{"code block", "```\nif a < b && c > d {\n}\n```", "<pre>if a &lt; b &amp;&amp; c &gt; d {\n}</pre>"},
+		{"code block with language", "```go\nx := \"<&>\"\n```", "<pre><code class=\"language-go\">x := &quot;&lt;&amp;&gt;&quot;</code></pre>"},
+		{"unterminated code block", "```\n**code**", "<pre>**code**</pre>"},
+		{"link", "[Seite](https://example.com/?a=1&b=2)", `<a href="https://example.com/?a=1&amp;b=2">Seite</a>`},
+		{"link with formatting", "[**fett**](https://example.com)", `<a href="https://example.com"><b>fett</b></a>`},
+		{"link with quote", `[x](https://example.com/"onclick)`, `<a href="https://example.com/&quot;onclick">x</a>`},
+		{"javascript link", "[klick](javascript:alert(1))", "klick"},
+		{"unterminated link", "[text](https://example.com", "[text](https://example.com"},
+		{"autolink", "<https://example.com>", `<a href="https://example.com">https://example.com</a>`},
+		{"heading", "## Titel *kursiv*", "<b>Titel <i>kursiv</i></b>"},
+		{"list", "- eins\n- **zwei**\n  - drei", "• eins\n• <b>zwei</b>\n  ◦ drei"},
+		{"task list", "- [ ] offen\n- [x] erledigt", "☐ offen\n☑ erledigt"},
+		{"ordered list", "1. eins\n2. zwei", "1. eins\n2. zwei"},
+		{"quote", "> **Zitat**\n> weiter", "<blockquote><b>Zitat</b>\nweiter</blockquote>"},
+		{"table", "| a | <b> |\n|---|---|", "<pre>| a | &lt;b&gt; |\n|---|---|</pre>"},
+		{"rule", "---", "———"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := MarkdownToHTML(tc.markdown)
+			if got != tc.want {
+				t.Errorf("MarkdownToHTML(%q)\n got: %q\nwant: %q", tc.markdown, got, tc.want)
+			}
+			if !balancedTags(got) {
+				t.Errorf("MarkdownToHTML(%q) has unbalanced tags: %q", tc.markdown, got)
+			}
+		})
+	}
+}
+
+var regexTestTag = regexp.MustCompile(`<(/?)([a-z-]+)[^>]*>`)
+
+func balancedTags(html string) bool {
+	var open []string
+	for _, match := range regexTestTag.FindAllStringSubmatch(html, -1) {
+		if match[1] == "" {
+			open = append(open, match[2])
+			continue
+		}
+		if len(open) == 0 || open[len(open)-1] != match[2] {
+			return false
+		}
+		open = open[:len(open)-1]
+	}
+	return len(open) == 0 && !strings.Contains(regexTestTag.ReplaceAllString(html, ""), "<")
+}
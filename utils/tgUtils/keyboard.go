@@ -0,0 +1,58 @@
+package tgUtils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+// MaxCallbackDataLength is the maximum length of callback data Telegram accepts, in bytes.
+const MaxCallbackDataLength = 64
+
+// CallbackStore saves button payloads server-side, see model.CallbackService.
+type CallbackStore interface {
+	Create(payload any, owner *gotgbot.User, ttl time.Duration) (string, error)
+}
+
+// TokenButton creates a callback button whose payload is stored in the CallbackStore.
+// The callback data is "prefix:token", so handlers can match it with `^prefix:(\w+)$`.
+// If owner is not nil, only they may press the button.
+func TokenButton(store CallbackStore, text string, prefix string, payload any, owner *gotgbot.User, ttl time.Duration) (gotgbot.InlineKeyboardButton, error) {
+	token, err := store.Create(payload, owner, ttl)
+	if err != nil {
+		return gotgbot.InlineKeyboardButton{}, err
+	}
+
+	data := fmt.Sprintf("%s:%s", prefix, token)
+	if len(data) > MaxCallbackDataLength {
+		return gotgbot.InlineKeyboardButton{}, fmt.Errorf("callback data %q is longer than %d bytes", data, MaxCallbackDataLength)
+	}
+
+	return gotgbot.InlineKeyboardButton{
+		Text:         text,
+		CallbackData: data,
+	}, nil
+}
+
+// CallbackToken returns the token of callback data created by TokenButton.
+func CallbackToken(data string) string {
+	_, token, _ := strings.Cut(data, ":")
+	return token
+}
+
+// InlineKeyboard arranges the buttons in rows with up to perRow buttons each.
+func InlineKeyboard(perRow int, buttons ...gotgbot.InlineKeyboardButton) *gotgbot.InlineKeyboardMarkup {
+	if perRow < 1 {
+		perRow = 1
+	}
+
+	rows := make([][]gotgbot.InlineKeyboardButton, 0, (len(buttons)+perRow-1)/perRow)
+	for start := 0; start < len(buttons); start += perRow {
+		end := min(start+perRow, len(buttons))
+		rows = append(rows, buttons[start:end])
+	}
+
+	return &gotgbot.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
@@ -0,0 +1,176 @@
+package tgUtils
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+const (
+	// PaginationPrefix is the prefix of the callback data of pagination buttons: "pg:<token>:<page>"
+	PaginationPrefix = "pg"
+	PaginationTTL    = 48 * time.Hour
+)
+
+var regexHTMLTag = regexp.MustCompile(`<[^>]*>`)
+
+// ReplySplit replies with the Telegram HTML text, split into as many messages as needed.
+// Only the first message is sent as a reply. Parts Telegram can't parse are sent as plain text instead.
+func ReplySplit(b *gotgbot.Bot, message *gotgbot.Message, text string, opts *gotgbot.SendMessageOpts) ([]*gotgbot.Message, error) {
+	parts := SplitHTML(text, MaxMessageLength)
+	if opts == nil {
+		opts = &gotgbot.SendMessageOpts{}
+	}
+
+	sent := make([]*gotgbot.Message, 0, len(parts))
+	for i, part := range parts {
+		partOpts := *opts
+		// Buttons belong below the last part
+		if i < len(parts)-1 {
+			partOpts.ReplyMarkup = nil
+		}
+
+		var (
+			msg *gotgbot.Message
+			err error
+		)
+		if i == 0 {
+			msg, err = message.Reply(b, part, &partOpts)
+		} else {
+			partOpts.ReplyParameters = nil
+			msg, err = b.SendMessage(message.Chat.Id, part, &partOpts)
+		}
+		if isParseError(err) && partOpts.ParseMode == gotgbot.ParseModeHTML {
+			partOpts.ParseMode = ""
+			if i == 0 {
+				msg, err = message.Reply(b, StripHTML(part), &partOpts)
+			} else {
+				msg, err = b.SendMessage(message.Chat.Id, StripHTML(part), &partOpts)
+			}
+		}
+		if err != nil {
+			return sent, err
+		}
+		sent = append(sent, msg)
+	}
+
+	return sent, nil
+}
+
+// EditSplit replaces the text of message with the first part of the Telegram HTML text and sends the
+// remaining parts as new messages. Parts Telegram can't parse are sent as plain text instead.
+func EditSplit(b *gotgbot.Bot, message *gotgbot.Message, text string, opts *gotgbot.SendMessageOpts) ([]*gotgbot.Message, error) {
+	parts := SplitHTML(text, MaxMessageLength)
+	if opts == nil {
+		opts = &gotgbot.SendMessageOpts{}
+	}
+
+	editOpts := &gotgbot.EditMessageTextOpts{
+		ParseMode:          opts.ParseMode,
+		LinkPreviewOptions: opts.LinkPreviewOptions,
+	}
+	if len(parts) == 1 {
+		// Edited messages can only have inline keyboards
+		switch markup := opts.ReplyMarkup.(type) {
+		case gotgbot.InlineKeyboardMarkup:
+			editOpts.ReplyMarkup = markup
+		case *gotgbot.InlineKeyboardMarkup:
+			if markup != nil {
+				editOpts.ReplyMarkup = *markup
+			}
+		}
+	}
+	edited, _, err := message.EditText(b, parts[0], editOpts)
+	if isParseError(err) && editOpts.ParseMode == gotgbot.ParseModeHTML {
+		editOpts.ParseMode = ""
+		edited, _, err = message.EditText(b, StripHTML(parts[0]), editOpts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sent := []*gotgbot.Message{edited}
+
+	for i, part := range parts[1:] {
+		partOpts := *opts
+		partOpts.ReplyParameters = nil
+		// Buttons belong below the last part
+		if i < len(parts)-2 {
+			partOpts.ReplyMarkup = nil
+		}
+		msg, err := b.SendMessage(message.Chat.Id, part, &partOpts)
+		if isParseError(err) && partOpts.ParseMode == gotgbot.ParseModeHTML {
+			partOpts.ParseMode = ""
+			msg, err = b.SendMessage(message.Chat.Id, StripHTML(part), &partOpts)
+		}
+		if err != nil {
+			return sent, err
+		}
+		sent = append(sent, msg)
+	}
+
+	return sent, nil
+}
+
+// StripHTML removes all tags from Telegram HTML and unescapes the entities, leaving the plain text.
+func StripHTML(text string) string {
+	return html.UnescapeString(regexHTMLTag.ReplaceAllString(text, ""))
+}
+
+func isParseError(err error) bool {
+	tgErr, ok := errors.AsType[*gotgbot.TelegramError](err)
+	return ok && strings.Contains(tgErr.Description, "can't parse entities")
+}
+
+// ReplyPaginated replies with the first page of the Telegram HTML text. If it's too long for one message,
+// the pages are stored in the CallbackStore and can be browsed with ◀/▶ buttons.
+// If owner is not nil, only they may turn the pages.
+func ReplyPaginated(b *gotgbot.Bot, message *gotgbot.Message, text string, store CallbackStore, owner *gotgbot.User, opts *gotgbot.SendMessageOpts) (*gotgbot.Message, error) {
+	pages := SplitHTML(text, MaxMessageLength)
+	if opts == nil {
+		opts = &gotgbot.SendMessageOpts{}
+	}
+
+	if len(pages) <= 1 {
+		return message.Reply(b, text, opts)
+	}
+
+	token, err := store.Create(pages, owner, PaginationTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	pageOpts := *opts
+	pageOpts.ReplyMarkup = PaginationKeyboard(token, 0, len(pages))
+	return message.Reply(b, pages[0], &pageOpts)
+}
+
+// PaginationKeyboard returns the ◀/▶ buttons for the page (starting at 0) of pages stored under the token.
+func PaginationKeyboard(token string, page int, total int) *gotgbot.InlineKeyboardMarkup {
+	var buttons []gotgbot.InlineKeyboardButton
+
+	if page > 0 {
+		buttons = append(buttons, gotgbot.InlineKeyboardButton{
+			Text:         "◀",
+			CallbackData: fmt.Sprintf("%s:%s:%d", PaginationPrefix, token, page-1),
+		})
+	}
+
+	buttons = append(buttons, gotgbot.InlineKeyboardButton{
+		Text:         fmt.Sprintf("%d/%d", page+1, total),
+		CallbackData: fmt.Sprintf("%s:%s:%d", PaginationPrefix, token, page),
+	})
+
+	if page < total-1 {
+		buttons = append(buttons, gotgbot.InlineKeyboardButton{
+			Text:         "▶",
+			CallbackData: fmt.Sprintf("%s:%s:%d", PaginationPrefix, token, page+1),
+		})
+	}
+
+	return InlineKeyboard(len(buttons), buttons...)
+}
@@ -0,0 +1,193 @@
+package tgUtils
+
+import (
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+type (
+	htmlAtomKind int
+
+	// htmlAtom is the smallest unit SplitHTML works with: a tag, an entity like "&amp;" or a single character.
+	htmlAtom struct {
+		raw     string
+		kind    htmlAtomKind
+		tagName string
+		length  int // Visible length in UTF-16 code units, which is what Telegram counts
+	}
+
+	// splitPoint is a position where a part may end, the whitespace at it is dropped.
+	splitPoint struct {
+		index    int
+		priority int
+		length   int
+		open     []htmlAtom
+	}
+)
+
+// Longest entity Telegram supports is "&quot;"
+const maxEntityLength = 8
+
+const (
+	atomText htmlAtomKind = iota
+	atomOpenTag
+	atomCloseTag
+)
+
+// SplitHTML splits Telegram HTML into parts whose visible text is at most maxLength characters long.
+// Tags that are open at a split are closed at the end of the part and reopened in the next one,
+// so every part is valid on its own. Splits happen at paragraphs, lines or words if possible.
+func SplitHTML(text string, maxLength int) []string {
+	atoms := parseHTMLAtoms(text)
+
+	var (
+		parts []string
+		open  []htmlAtom
+		start int
+	)
+
+	for start < len(atoms) {
+		end, next, openAtEnd := nextSplit(atoms, start, open, maxLength)
+
+		var sb strings.Builder
+		for _, tag := range open {
+			sb.WriteString(tag.raw)
+		}
+		for _, atom := range atoms[start:end] {
+			sb.WriteString(atom.raw)
+		}
+		for i := len(openAtEnd) - 1; i >= 0; i-- {
+			sb.WriteString("</" + openAtEnd[i].tagName + ">")
+		}
+
+		if part := strings.TrimSpace(sb.String()); part != "" {
+			parts = append(parts, part)
+		}
+
+		start = next
+		open = openAtEnd
+	}
+
+	return parts
+}
+
+// nextSplit finds the end of the part starting at start and the index the next part starts at.
+// It also returns the tags that are still open at the end of the part.
+func nextSplit(atoms []htmlAtom, start int, open []htmlAtom, maxLength int) (int, int, []htmlAtom) {
+	open = append([]htmlAtom(nil), open...)
+	var (
+		length int
+		best   *splitPoint
+	)
+
+	for i := start; i < len(atoms); i++ {
+		atom := atoms[i]
+
+		if atom.kind == atomText && length+atom.length > maxLength && i > start {
+			// Whitespace is dropped at a split, so it can still end the part
+			best = betterSplit(best, atoms, i, length, open, maxLength)
+			if best != nil && best.index > start {
+				return best.index, best.index + 1, best.open
+			}
+			// No whitespace to split at, so cut the word
+			return i, i, open
+		}
+
+		switch atom.kind {
+		case atomOpenTag:
+			open = append(open, atom)
+		case atomCloseTag:
+			for j := len(open) - 1; j >= 0; j-- {
+				if open[j].tagName == atom.tagName {
+					open = open[:j]
+					break
+				}
+			}
+		case atomText:
+			best = betterSplit(best, atoms, i, length, open, maxLength)
+			length += atom.length
+		}
+	}
+
+	return len(atoms), len(atoms), open
+}
+
+// betterSplit returns the split point at atoms[i] if it is whitespace and better than best.
+// Stronger breaks are preferred unless they would leave the part less than half full.
+func betterSplit(best *splitPoint, atoms []htmlAtom, i int, length int, open []htmlAtom, maxLength int) *splitPoint {
+	priority := splitPriority(atoms, i)
+	if priority == 0 {
+		return best
+	}
+	if best != nil && priority < best.priority && best.length >= maxLength/2 {
+		return best
+	}
+	return &splitPoint{
+		index:    i,
+		priority: priority,
+		length:   length,
+		open:     append([]htmlAtom(nil), open...),
+	}
+}
+
+func splitPriority(atoms []htmlAtom, i int) int {
+	switch atoms[i].raw {
+	case "\n":
+		if i > 0 && atoms[i-1].raw == "\n" {
+			return 3
+		}
+		return 2
+	case " ":
+		return 1
+	default:
+		return 0
+	}
+}
+
+func parseHTMLAtoms(text string) []htmlAtom {
+	atoms := make([]htmlAtom, 0, len(text))
+
+	for len(text) > 0 {
+		switch {
+		case text[0] == '<':
+			end := strings.IndexByte(text, '>')
+			if end == -1 {
+				end = len(text) - 1
+			}
+			raw := text[:end+1]
+			text = text[end+1:]
+
+			if strings.HasPrefix(raw, "</") {
+				atoms = append(atoms, htmlAtom{raw: raw, kind: atomCloseTag, tagName: tagName(raw[2:])})
+			} else {
+				atoms = append(atoms, htmlAtom{raw: raw, kind: atomOpenTag, tagName: tagName(raw[1:])})
+			}
+
+		case text[0] == '&':
+			end := strings.IndexByte(text, ';')
+			if end == -1 || end > maxEntityLength || strings.ContainsAny(text[1:end], " <&") {
+				atoms = append(atoms, htmlAtom{raw: "&", kind: atomText, length: 1})
+				text = text[1:]
+				continue
+			}
+			atoms = append(atoms, htmlAtom{raw: text[:end+1], kind: atomText, length: 1})
+			text = text[end+1:]
+
+		default:
+			r, size := utf8.DecodeRuneInString(text)
+			atoms = append(atoms, htmlAtom{raw: text[:size], kind: atomText, length: max(utf16.RuneLen(r), 1)})
+			text = text[size:]
+		}
+	}
+
+	return atoms
+}
+
+func tagName(tag string) string {
+	tag = strings.TrimSuffix(tag, ">")
+	if i := strings.IndexAny(tag, " \t\n"); i != -1 {
+		tag = tag[:i]
+	}
+	return strings.ToLower(tag)
+}
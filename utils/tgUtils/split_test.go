@@ -0,0 +1,126 @@
+package tgUtils
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplitHTML(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		maxLength int
+		want      []string
+	}{
+		{
+			name:      "fits",
+			text:      "<b>Hallo</b> Welt",
+			maxLength: 20,
+			want:      []string{"<b>Hallo</b> Welt"},
+		},
+		{
+			name:      "splits at words",
+			text:      "eins zwei drei",
+			maxLength: 9,
+			want:      []string{"eins zwei", "drei"},
+		},
+		{
+			name:      "prefers paragraphs",
+			text:      "eins zwei\n\ndrei vier",
+			maxLength: 16,
+			want:      []string{"eins zwei", "drei vier"},
+		},
+		{
+			name:      "fills parts that would be less than half full",
+			text:      "eins\n\nzwei drei",
+			maxLength: 12,
+			want:      []string{"eins\n\nzwei", "drei"},
+		},
+		{
+			name:      "reopens tags",
+			text:      "<b>eins <i>zwei</i> drei</b>",
+			maxLength: 9,
+			want:      []string{"<b>eins <i>zwei</i></b>", "<b>drei</b>"},
+		},
+		{
+			name:      "reopens tags with attributes",
+			text:      `<a href="https://example.com">eins zwei</a>`,
+			maxLength: 4,
+			want:      []string{`<a href="https://example.com">eins</a>`, `<a href="https://example.com">zwei</a>`},
+		},
+		{
+			name:      "closes unclosed tags",
+			text:      "<b>eins zwei",
+			maxLength: 4,
+			want:      []string{"<b>eins</b>", "<b>zwei</b>"},
+		},
+		{
+			name:      "entity counts as one character",
+			text:      "a&amp;b",
+			maxLength: 3,
+			want:      []string{"a&amp;b"},
+		},
+		{
+			name:      "entity at the boundary",
+			text:      "ab&lt;cd",
+			maxLength: 3,
+			want:      []string{"ab&lt;", "cd"},
+		},
+		{
+			name:      "ampersand without entity",
+			text:      "a & b",
+			maxLength: 3,
+			want:      []string{"a &", "b"},
+		},
+		{
+			name:      "emoji count as two characters",
+			text:      "😀😀 😀",
+			maxLength: 4,
+			want:      []string{"😀😀", "😀"},
+		},
+		{
+			name:      "cuts long words",
+			text:      "<code>abcdefgh</code>",
+			maxLength: 3,
+			want:      []string{"<code>abc</code>", "<code>def</code>", "<code>gh</code>"},
+		},
+		{
+			name:      "empty",
+			text:      "",
+			maxLength: 10,
+			want:      nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := SplitHTML(tc.text, tc.maxLength)
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("SplitHTML(%q, %d) = %q, want %q", tc.text, tc.maxLength, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSplitHTMLLongWord(t *testing.T) {
+	word := strings.Repeat("a", MaxMessageLength+100)
+
+	parts := SplitHTML("<b>"+word+"</b>", MaxMessageLength)
+
+	want := []string{
+		"<b>" + word[:MaxMessageLength] + "</b>",
+		"<b>" + word[MaxMessageLength:] + "</b>",
+	}
+	if !slices.Equal(parts, want) {
+		t.Errorf("got %d parts with lengths %v", len(parts), partLengths(parts))
+	}
+}
+
+func partLengths(parts []string) []int {
+	lengths := make([]int, len(parts))
+	for i, part := range parts {
+		lengths[i] = len(part)
+	}
+	return lengths
+}
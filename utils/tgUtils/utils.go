@@ -5,6 +5,7 @@ import (
 	"errors"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/Brawl345/gobot/utils"
@@ -58,17 +59,49 @@ func ContainsMedia(message *gotgbot.Message) bool {
 }
 
 func IsAdmin(user *gotgbot.User) bool {
-	return adminId() == user.Id
+	return user != nil && adminId() == user.Id
+}
+
+// IsChatAdmin reports whether the user is the bot admin or an administrator of the chat.
+// In private chats, the user counts as the administrator.
+func IsChatAdmin(b *gotgbot.Bot, chat *gotgbot.Chat, user *gotgbot.User) (bool, error) {
+	if IsAdmin(user) {
+		return true, nil
+	}
+	if user == nil {
+		return false, nil
+	}
+	if chat.Type == gotgbot.ChatTypePrivate {
+		return chat.Id == user.Id, nil
+	}
+
+	member, err := b.GetChatMember(chat.Id, user.Id, nil)
+	if err != nil {
+		return false, err
+	}
+
+	status := member.GetStatus()
+	return status == "creator" || status == "administrator", nil
 }
 
 func FromGroup(message gotgbot.MaybeInaccessibleMessage) bool {
 	return message.GetChat().Type == gotgbot.ChatTypeGroup || message.GetChat().Type == gotgbot.ChatTypeSupergroup
 }
 
+func FromChannel(message gotgbot.MaybeInaccessibleMessage) bool {
+	return message.GetChat().Type == gotgbot.ChatTypeChannel
+}
+
 func IsPrivate(message *gotgbot.Message) bool {
 	return message.Chat.Type == gotgbot.ChatTypePrivate
 }
 
+// IsNotModified reports whether an edit failed because the message already looks like that.
+func IsNotModified(err error) bool {
+	tgErr, ok := errors.AsType[*gotgbot.TelegramError](err)
+	return ok && strings.Contains(tgErr.Description, "message is not modified")
+}
+
 func IsReply(message *gotgbot.Message) bool {
 	return message.ReplyToMessage != nil
 }
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"errors"
 	"runtime/debug"
 	"time"
@@ -84,6 +85,23 @@ func TimestampToTime(timestamp int64) time.Time {
 	return time.Unix(timestamp, 0)
 }
 
+// RunPeriodically runs job after the delay and then after every interval until ctx is done.
+func RunPeriodically(ctx context.Context, delay, interval time.Duration, job func()) {
+	go func() {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				job()
+				timer.Reset(interval)
+			}
+		}
+	}()
+}
+
 func Ptr[T any](v T) *T {
 	return &v
 }